@@ -0,0 +1,131 @@
+// Package audit records changes in the activity log and announces them. Each
+// entry becomes a domain event, which is queued for webhooks and published to
+// event stream subscribers.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"exam-prep/database"
+	"exam-prep/events"
+	"exam-prep/models"
+	"exam-prep/webhooks"
+	"log/slog"
+)
+
+// Actions recorded in the activity log
+const (
+	Create     = "create"
+	Update     = "update"
+	Delete     = "delete"
+	Restore    = "restore"
+	Complete   = "complete"
+	Uncomplete = "uncomplete"
+	MarkWeak   = "mark_weak"
+	UnmarkWeak = "unmark_weak"
+	Revert     = "revert"
+	Review     = "review"
+)
+
+// eventActions maps actions to the past-tense verb used in event types
+var eventActions = map[string]string{
+	Create:     "created",
+	Update:     "updated",
+	Delete:     "deleted",
+	Restore:    "restored",
+	Complete:   "completed",
+	Uncomplete: "uncompleted",
+	MarkWeak:   "marked_weak",
+	UnmarkWeak: "unmarked_weak",
+	Revert:     "reverted",
+	Review:     "reviewed",
+}
+
+// planEntities are the entity types whose mutations change the study plan
+var planEntities = map[string]bool{
+	"study_plan":     true,
+	"plan_rebalance": true,
+	"plan_template":  true,
+	"plan_bulk":      true,
+}
+
+// EventType names the domain event for a mutation, e.g. topic.completed or
+// note.updated. Every mutation that changes the study plan, including
+// rebalancing and template edits, is reported as plan.changed.
+func EventType(entityType, action string) string {
+	if planEntities[entityType] {
+		return "plan.changed"
+	}
+	return entityType + "." + eventActions[action]
+}
+
+// Event is the domain event for an activity log entry. The event shares the
+// entry's ID, so live and replayed events are numbered alike.
+func Event(a models.Activity) events.Event {
+	data := a.After
+	if len(data) == 0 {
+		data = a.Before
+	}
+
+	subjectID := a.EntityID
+	if a.EntityType != "subject" {
+		var row struct {
+			SubjectID int `json:"subject_id"`
+		}
+		json.Unmarshal(data, &row)
+		subjectID = row.SubjectID
+	}
+
+	return events.Event{
+		ID:        int64(a.ID),
+		Type:      EventType(a.EntityType, a.Action),
+		Actor:     a.Actor,
+		SubjectID: subjectID,
+		EntityID:  a.EntityID,
+		Data:      data,
+		Time:      a.CreatedAt,
+	}
+}
+
+// Record writes an activity log entry for a change that has just been made,
+// queues webhook deliveries for its event and publishes it. An update that
+// flips a topic's is_completed is recorded as Complete or Uncomplete, so
+// completions are logged alike whichever endpoint made them. A change whose
+// entry could not be written has no event ID, so it is not announced:
+// clients resuming from the log could not place it. Failures are logged
+// rather than returned so auditing never breaks the change itself.
+func Record(ctx context.Context, actor, entityType string, entityID int, action string, before, after json.RawMessage) {
+	action = completionAction(entityType, action, before, after)
+	entry, err := database.RecordActivity(ctx, actor, entityType, entityID, action, before, after)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to record activity", "entity_type", entityType, "entity_id", entityID, "error", err)
+		return
+	}
+
+	e := Event(entry)
+	if err := webhooks.Enqueue(ctx, e); err != nil {
+		slog.WarnContext(ctx, "Failed to queue webhooks", "event", e.Type, "error", err)
+	}
+	events.Publish(e)
+}
+
+// completionAction returns Complete or Uncomplete for a topic update whose
+// before and after snapshots differ in is_completed, and action otherwise
+func completionAction(entityType, action string, before, after json.RawMessage) string {
+	if entityType != "topic" || action != Update {
+		return action
+	}
+	var was, is struct {
+		IsCompleted *bool `json:"is_completed"`
+	}
+	if json.Unmarshal(before, &was) != nil || json.Unmarshal(after, &is) != nil {
+		return action
+	}
+	if was.IsCompleted == nil || is.IsCompleted == nil || *was.IsCompleted == *is.IsCompleted {
+		return action
+	}
+	if *is.IsCompleted {
+		return Complete
+	}
+	return Uncomplete
+}
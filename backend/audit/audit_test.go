@@ -0,0 +1,34 @@
+package audit
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCompletionAction(t *testing.T) {
+	open := json.RawMessage(`{"id":1,"name":"Limits","is_completed":false}`)
+	done := json.RawMessage(`{"id":1,"name":"Limits","is_completed":true}`)
+	renamed := json.RawMessage(`{"id":1,"name":"Series","is_completed":false}`)
+
+	tests := []struct {
+		name          string
+		entityType    string
+		action        string
+		before, after json.RawMessage
+		want          string
+	}{
+		{"update completing a topic", "topic", Update, open, done, Complete},
+		{"update reopening a topic", "topic", Update, done, open, Uncomplete},
+		{"update leaving completion alone", "topic", Update, open, renamed, Update},
+		{"update without a snapshot", "topic", Update, nil, done, Update},
+		{"toggle", "topic", Complete, open, done, Complete},
+		{"other entity", "note", Update, open, done, Update},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := completionAction(tt.entityType, tt.action, tt.before, tt.after); got != tt.want {
+				t.Errorf("completionAction = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"exam-prep/events"
+	"exam-prep/models"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ListTrash returns the items in the trash
+func (c *Client) ListTrash(ctx context.Context) ([]models.TrashItem, error) {
+	var items []models.TrashItem
+	err := c.do(ctx, http.MethodGet, "/trash", nil, nil, &items)
+	return items, err
+}
+
+// ActivityFilter narrows the audit log. Zero values match everything.
+type ActivityFilter struct {
+	EntityType string
+	EntityID   int
+	Action     string
+	Actor      string
+	From       string
+	To         string
+	Limit      int
+}
+
+// ListActivity returns audit log entries, newest first
+func (c *Client) ListActivity(ctx context.Context, filter ActivityFilter) ([]models.Activity, error) {
+	query := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	set("entity_type", filter.EntityType)
+	set("action", filter.Action)
+	set("actor", filter.Actor)
+	set("from", filter.From)
+	set("to", filter.To)
+	if filter.EntityID != 0 {
+		query.Set("entity_id", strconv.Itoa(filter.EntityID))
+	}
+	if filter.Limit != 0 {
+		query.Set("limit", strconv.Itoa(filter.Limit))
+	}
+
+	var activity []models.Activity
+	err := c.do(ctx, http.MethodGet, "/activity", query, nil, &activity)
+	return activity, err
+}
+
+// EventFilter selects which events StreamEvents receives. LastEventID
+// resumes after an event already seen.
+type EventFilter struct {
+	SubjectID   int
+	Actor       string
+	Types       []string
+	LastEventID int64
+}
+
+// StreamEvents subscribes to the server's event stream. Events are sent on
+// the returned channel until ctx is cancelled or the stream ends; the error
+// channel then receives the reason, or nil, and both channels are closed.
+// The stream is not retried; reconnect with LastEventID to resume.
+func (c *Client) StreamEvents(ctx context.Context, filter EventFilter) (<-chan events.Event, <-chan error) {
+	out := make(chan events.Event)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(out)
+		defer close(errs)
+		errs <- c.readEvents(ctx, filter, out)
+	}()
+	return out, errs
+}
+
+func (c *Client) readEvents(ctx context.Context, filter EventFilter, out chan<- events.Event) error {
+	query := url.Values{}
+	if filter.SubjectID != 0 {
+		query.Set("subject_id", strconv.Itoa(filter.SubjectID))
+	}
+	if filter.Actor != "" {
+		query.Set("actor", filter.Actor)
+	}
+	if len(filter.Types) > 0 {
+		query.Set("types", strings.Join(filter.Types, ","))
+	}
+
+	target := c.baseURL + "/events"
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	c.authorize(req)
+	req.Header.Set("Accept", "text/event-stream")
+	if filter.LastEventID != 0 {
+		req.Header.Set("Last-Event-ID", strconv.FormatInt(filter.LastEventID, 10))
+	}
+
+	// The stream is long-lived, so the client's request timeout must not apply
+	httpClient := *c.httpClient
+	httpClient.Timeout = 0
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return newAPIError(resp.StatusCode, body)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var e events.Event
+		if err := json.Unmarshal([]byte(data), &e); err != nil {
+			return err
+		}
+		select {
+		case out <- e:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,227 @@
+// Package client is a typed Go client for the exam preparation API. Methods
+// mirror the routes in routes.SetupRoutes and use the request and response
+// structs from the models package.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client talks to the API over HTTP. The zero value is not usable; create
+// one with New.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	token      string
+	user       string
+	timezone   string
+	maxRetries int
+	backoff    time.Duration
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sets the underlying HTTP client
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) { c.httpClient = httpClient }
+}
+
+// WithToken sends the token as a bearer Authorization header
+func WithToken(token string) Option {
+	return func(c *Client) { c.token = token }
+}
+
+// WithUser sets the X-User header that attributes changes and scopes
+// per-user resources such as reminders and settings
+func WithUser(user string) Option {
+	return func(c *Client) { c.user = user }
+}
+
+// WithTimezone sets the X-Timezone header used for "today" and other date math
+func WithTimezone(timezone string) Option {
+	return func(c *Client) { c.timezone = timezone }
+}
+
+// WithRetries sets how many times a failed idempotent request is retried and
+// the delay before the first retry, which doubles on each attempt
+func WithRetries(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.backoff = backoff
+	}
+}
+
+// New returns a client for the API at baseURL, e.g. http://localhost:8080/api
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		maxRetries: 3,
+		backoff:    200 * time.Millisecond,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// envelope is utils.Response with the data left undecoded
+type envelope struct {
+	Success   bool            `json:"success"`
+	Message   string          `json:"message"`
+	Data      json.RawMessage `json:"data"`
+	Error     string          `json:"error"`
+	Code      string          `json:"code"`
+	Details   []FieldError    `json:"details"`
+	RequestID string          `json:"request_id"`
+}
+
+// do sends a JSON request and decodes the envelope's data into out. Only
+// GET and HEAD requests are retried.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	return c.request(ctx, method, path, query, body, out, safeMethod(method))
+}
+
+// doIdempotent is do for a call that can safely be repeated whatever its
+// method, such as a PUT that replaces a resource. It is retried like a GET.
+// Toggles, merge patches, deletes and restores must use do.
+func (c *Client) doIdempotent(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	return c.request(ctx, method, path, query, body, out, true)
+}
+
+func (c *Client) request(ctx context.Context, method, path string, query url.Values, body, out interface{}, retry bool) error {
+	raw, _, err := c.send(ctx, method, path, query, body, retry)
+	if err != nil {
+		return err
+	}
+
+	var env envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	if out == nil || len(env.Data) == 0 || string(env.Data) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(env.Data, out); err != nil {
+		return fmt.Errorf("decoding response data: %w", err)
+	}
+	return nil
+}
+
+// send performs the request, with retries if retry is set, and returns the
+// raw body of a successful response along with its content type
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, body interface{}, retry bool) ([]byte, string, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return nil, "", err
+		}
+	}
+
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	for attempt := 0; ; attempt++ {
+		raw, contentType, err := c.attempt(ctx, method, target, payload)
+		if err == nil || !retry || attempt >= c.maxRetries || !retryable(err) {
+			return raw, contentType, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, "", ctx.Err()
+		case <-time.After(c.backoff << attempt):
+		}
+	}
+}
+
+// attempt performs a single HTTP round trip
+func (c *Client) attempt(ctx context.Context, method, target string, payload []byte) ([]byte, string, error) {
+	var reader io.Reader
+	if payload != nil {
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, "", err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	c.authorize(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	if resp.StatusCode >= 400 {
+		return nil, "", newAPIError(resp.StatusCode, raw)
+	}
+	return raw, resp.Header.Get("Content-Type"), nil
+}
+
+// authorize sets the identity headers on a request
+func (c *Client) authorize(req *http.Request) {
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if c.user != "" {
+		req.Header.Set("X-User", c.user)
+	}
+	if c.timezone != "" {
+		req.Header.Set("X-Timezone", c.timezone)
+	}
+}
+
+// safeMethod reports whether requests with the method can be retried
+// without being marked idempotent
+func safeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
+
+// retryable reports whether a failed request that may be repeated should be
+// sent again: after network errors, 429 or 5xx
+func retryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+	}
+	return true
+}
+
+// idPath formats a path with numeric IDs, e.g. idPath("/topics/%d", 3)
+func idPath(format string, ids ...int) string {
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// created is the data of responses that return a new row's ID
+type created struct {
+	ID int `json:"id"`
+}
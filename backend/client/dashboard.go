@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"exam-prep/models"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// GetDashboard returns the dashboard summary and exam countdown
+func (c *Client) GetDashboard(ctx context.Context) (*models.DashboardData, error) {
+	var dashboard models.DashboardData
+	if err := c.do(ctx, http.MethodGet, "/dashboard", nil, nil, &dashboard); err != nil {
+		return nil, err
+	}
+	return &dashboard, nil
+}
+
+// GetProgress returns topic progress per subject
+func (c *Client) GetProgress(ctx context.Context) ([]models.SubjectProgressItem, error) {
+	var progress []models.SubjectProgressItem
+	err := c.do(ctx, http.MethodGet, "/progress", nil, nil, &progress)
+	return progress, err
+}
+
+// TimeRange selects the dates and subject for time analytics. Empty fields
+// use the server defaults.
+type TimeRange struct {
+	From      string
+	To        string
+	SubjectID int
+}
+
+// GetTimeAnalytics returns planned vs completed hours over a date range
+func (c *Client) GetTimeAnalytics(ctx context.Context, r TimeRange) (*models.TimeAnalytics, error) {
+	query := url.Values{}
+	if r.From != "" {
+		query.Set("from", r.From)
+	}
+	if r.To != "" {
+		query.Set("to", r.To)
+	}
+	if r.SubjectID != 0 {
+		query.Set("subject_id", strconv.Itoa(r.SubjectID))
+	}
+
+	var analytics models.TimeAnalytics
+	if err := c.do(ctx, http.MethodGet, "/analytics/time", query, nil, &analytics); err != nil {
+		return nil, err
+	}
+	return &analytics, nil
+}
+
+// WeeklyReport renders the report for the week containing week
+// (YYYY-MM-DD, empty for this week) as html, md or pdf
+func (c *Client) WeeklyReport(ctx context.Context, format, week string) ([]byte, error) {
+	query := url.Values{}
+	if format != "" {
+		query.Set("format", format)
+	}
+	if week != "" {
+		query.Set("week", week)
+	}
+
+	body, _, err := c.send(ctx, http.MethodGet, "/reports/weekly", query, nil, true)
+	return body, err
+}
+
+// ListReports returns the saved weekly reports without their contents
+func (c *Client) ListReports(ctx context.Context) ([]models.StoredReport, error) {
+	var reports []models.StoredReport
+	err := c.do(ctx, http.MethodGet, "/reports", nil, nil, &reports)
+	return reports, err
+}
+
+// GetReport returns a saved weekly report
+func (c *Client) GetReport(ctx context.Context, id int) (*models.StoredReport, error) {
+	var report models.StoredReport
+	if err := c.do(ctx, http.MethodGet, idPath("/reports/%d", id), nil, nil, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// RenderReport returns a saved weekly report rendered as html, md or pdf
+func (c *Client) RenderReport(ctx context.Context, id int, format string) ([]byte, error) {
+	body, _, err := c.send(ctx, http.MethodGet, idPath("/reports/%d", id), url.Values{"format": {format}}, nil, true)
+	return body, err
+}
+
+// OpenAPISpec returns the API's OpenAPI document as JSON
+func (c *Client) OpenAPISpec(ctx context.Context) ([]byte, error) {
+	body, _, err := c.send(ctx, http.MethodGet, "/docs/openapi.json", nil, nil, true)
+	return body, err
+}
+
+// DocsPage returns the interactive documentation page
+func (c *Client) DocsPage(ctx context.Context) ([]byte, error) {
+	body, _, err := c.send(ctx, http.MethodGet, "/docs", nil, nil, true)
+	return body, err
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// Errors that an APIError matches with errors.Is, by HTTP status
+var (
+	ErrBadRequest    = errors.New("bad request")
+	ErrNotFound      = errors.New("not found")
+	ErrConflict      = errors.New("conflict")
+	ErrUnprocessable = errors.New("unprocessable entity")
+	ErrServer        = errors.New("server error")
+)
+
+// Error codes the API sends in APIError.Code
+const (
+	CodeBadRequest       = "BAD_REQUEST"
+	CodeValidationFailed = "VALIDATION_FAILED"
+	CodeNotFound         = "NOT_FOUND"
+	CodeConflict         = "CONFLICT"
+	CodeFKViolation      = "FK_VIOLATION"
+	CodeTooLarge         = "PAYLOAD_TOO_LARGE"
+	CodeInternal         = "INTERNAL"
+)
+
+// FieldError is one invalid request field reported by the API
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// APIError is an error response from the API. Code is the stable error code,
+// Details lists invalid fields, and Data holds any other details the server
+// sent along, such as plan issues or a dependency cycle. RequestID finds the
+// request in the server's logs.
+type APIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+	Details    []FieldError
+	Data       json.RawMessage
+	RequestID  string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return http.StatusText(e.StatusCode)
+	}
+	return e.Message
+}
+
+// Is lets errors.Is match an APIError against the status errors above
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrUnprocessable:
+		return e.StatusCode == http.StatusUnprocessableEntity
+	case ErrServer:
+		return e.StatusCode >= 500
+	}
+	return false
+}
+
+// DecodeData unmarshals the error's details into v
+func (e *APIError) DecodeData(v interface{}) error {
+	if len(e.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(e.Data, v)
+}
+
+// newAPIError builds an APIError from an error response body, which is
+// usually the utils.Response envelope but may be plain text from a proxy
+func newAPIError(status int, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: status}
+	var env envelope
+	if json.Unmarshal(body, &env) == nil {
+		apiErr.Message = env.Error
+		apiErr.Code = env.Code
+		apiErr.Details = env.Details
+		apiErr.Data = env.Data
+		apiErr.RequestID = env.RequestID
+	} else {
+		apiErr.Message = string(body)
+	}
+	return apiErr
+}
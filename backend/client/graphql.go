@@ -0,0 +1,39 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"exam-prep/graphql"
+	"fmt"
+	"net/http"
+)
+
+// GraphQL runs a query or mutation and decodes its data into out. Errors
+// reported in the response are returned as *graphql.Error values, joined if
+// there are several; any partial data is still decoded.
+func (c *Client) GraphQL(ctx context.Context, query string, variables map[string]interface{}, out interface{}) error {
+	raw, _, err := c.send(ctx, http.MethodPost, "/graphql", nil, graphql.Request{Query: query, Variables: variables}, false)
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Data   json.RawMessage  `json:"data"`
+		Errors []*graphql.Error `json:"errors"`
+	}
+	if err := json.Unmarshal(raw, &response); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	if out != nil && len(response.Data) > 0 && string(response.Data) != "null" {
+		if err := json.Unmarshal(response.Data, out); err != nil {
+			return fmt.Errorf("decoding response data: %w", err)
+		}
+	}
+
+	errs := make([]error, len(response.Errors))
+	for i, gqlErr := range response.Errors {
+		errs[i] = gqlErr
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,205 @@
+package client
+
+import (
+	"context"
+	"exam-prep/models"
+	"net/http"
+	"net/url"
+)
+
+// ListStudyPlans returns every study plan entry
+func (c *Client) ListStudyPlans(ctx context.Context) ([]models.StudyPlan, error) {
+	var plans []models.StudyPlan
+	err := c.do(ctx, http.MethodGet, "/study-plan", nil, nil, &plans)
+	return plans, err
+}
+
+// TodayStudyPlan returns today's entries in the client's timezone
+func (c *Client) TodayStudyPlan(ctx context.Context) ([]models.StudyPlan, error) {
+	var plans []models.StudyPlan
+	err := c.do(ctx, http.MethodGet, "/study-plan/today", nil, nil, &plans)
+	return plans, err
+}
+
+// StudyPlanIssues returns the problems found in the plan
+func (c *Client) StudyPlanIssues(ctx context.Context) ([]models.PlanIssue, error) {
+	var issues []models.PlanIssue
+	err := c.do(ctx, http.MethodGet, "/study-plan/issues", nil, nil, &issues)
+	return issues, err
+}
+
+// StudyPlanDay returns the timeline for a day (YYYY-MM-DD), or today when
+// date is empty
+func (c *Client) StudyPlanDay(ctx context.Context, date string) (*models.DayView, error) {
+	query := url.Values{}
+	if date != "" {
+		query.Set("date", date)
+	}
+
+	var day models.DayView
+	if err := c.do(ctx, http.MethodGet, "/study-plan/day", query, nil, &day); err != nil {
+		return nil, err
+	}
+	return &day, nil
+}
+
+// CreateStudyPlan creates an entry and returns its ID. Entries that break
+// the plan rules fail with ErrUnprocessable; the issues are in the
+// APIError's data.
+func (c *Client) CreateStudyPlan(ctx context.Context, input models.CreateStudyPlanInput) (int, error) {
+	var result created
+	err := c.do(ctx, http.MethodPost, "/study-plan", nil, input, &result)
+	return result.ID, err
+}
+
+// UpdateStudyPlan updates an entry
+func (c *Client) UpdateStudyPlan(ctx context.Context, id int, input models.UpdateStudyPlanInput) error {
+	return c.doIdempotent(ctx, http.MethodPut, idPath("/study-plan/%d", id), nil, input, nil)
+}
+
+// PatchStudyPlan applies a merge patch to an entry and returns its patched fields
+func (c *Client) PatchStudyPlan(ctx context.Context, id int, patch Patch) (*models.StudyPlanPatch, error) {
+	var entry models.StudyPlanPatch
+	if err := c.do(ctx, http.MethodPatch, idPath("/study-plan/%d", id), nil, patch, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// DeleteStudyPlan moves an entry to the trash
+func (c *Client) DeleteStudyPlan(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodDelete, idPath("/study-plan/%d", id), nil, nil, nil)
+}
+
+// RestoreStudyPlan brings an entry back from the trash
+func (c *Client) RestoreStudyPlan(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodPut, idPath("/study-plan/%d/restore", id), nil, nil, nil)
+}
+
+// GenerateStudyPlan schedules a subject's incomplete topics in dependency order
+func (c *Client) GenerateStudyPlan(ctx context.Context, input models.GenerateStudyPlanInput) (*models.GeneratePlanResult, error) {
+	var result models.GeneratePlanResult
+	if err := c.do(ctx, http.MethodPost, "/study-plan/generate", nil, input, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// RebalanceStudyPlan carries missed hours forward into upcoming days
+func (c *Client) RebalanceStudyPlan(ctx context.Context, input models.RebalanceInput) (*models.Rebalance, error) {
+	var rebalance models.Rebalance
+	if err := c.do(ctx, http.MethodPost, "/study-plan/rebalance", nil, input, &rebalance); err != nil {
+		return nil, err
+	}
+	return &rebalance, nil
+}
+
+// ListRebalances returns recent rebalance runs
+func (c *Client) ListRebalances(ctx context.Context) ([]models.Rebalance, error) {
+	var rebalances []models.Rebalance
+	err := c.do(ctx, http.MethodGet, "/study-plan/rebalances", nil, nil, &rebalances)
+	return rebalances, err
+}
+
+// RevertRebalance undoes a rebalance run
+func (c *Client) RevertRebalance(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodPost, idPath("/study-plan/rebalances/%d/revert", id), nil, nil, nil)
+}
+
+// BatchCreateStudyPlans creates several entries in one transaction
+func (c *Client) BatchCreateStudyPlans(ctx context.Context, entries []models.CreateStudyPlanInput) (*models.BulkResult, error) {
+	return c.bulk(ctx, "/study-plan/bulk", models.BatchCreateStudyPlanInput{Entries: entries})
+}
+
+// ShiftStudyPlans moves the entries in a date range by a number of days
+func (c *Client) ShiftStudyPlans(ctx context.Context, input models.ShiftStudyPlanInput) (*models.BulkResult, error) {
+	return c.bulk(ctx, "/study-plan/bulk/shift", input)
+}
+
+// CopyStudyPlanWeek copies one week's entries onto another week
+func (c *Client) CopyStudyPlanWeek(ctx context.Context, input models.CopyWeekInput) (*models.BulkResult, error) {
+	return c.bulk(ctx, "/study-plan/bulk/copy-week", input)
+}
+
+// DeleteStudyPlanRange moves the entries in a date range to the trash
+func (c *Client) DeleteStudyPlanRange(ctx context.Context, input models.DateRangeInput) (*models.BulkResult, error) {
+	return c.bulk(ctx, "/study-plan/bulk/delete", input)
+}
+
+func (c *Client) bulk(ctx context.Context, path string, input interface{}) (*models.BulkResult, error) {
+	var result models.BulkResult
+	if err := c.do(ctx, http.MethodPost, path, nil, input, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListPlanTemplates returns the recurring plan templates
+func (c *Client) ListPlanTemplates(ctx context.Context) ([]models.PlanTemplate, error) {
+	var templates []models.PlanTemplate
+	err := c.do(ctx, http.MethodGet, "/plan-templates", nil, nil, &templates)
+	return templates, err
+}
+
+// GetPlanTemplate returns one plan template
+func (c *Client) GetPlanTemplate(ctx context.Context, id int) (*models.PlanTemplate, error) {
+	var template models.PlanTemplate
+	if err := c.do(ctx, http.MethodGet, idPath("/plan-templates/%d", id), nil, nil, &template); err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// CreatePlanTemplate creates a template and returns its ID and the number
+// of entries it created
+func (c *Client) CreatePlanTemplate(ctx context.Context, input models.CreatePlanTemplateInput) (int, int, error) {
+	var result struct {
+		ID             int `json:"id"`
+		CreatedEntries int `json:"created_entries"`
+	}
+	err := c.do(ctx, http.MethodPost, "/plan-templates", nil, input, &result)
+	return result.ID, result.CreatedEntries, err
+}
+
+// PlanTemplateUpdate identifies what a scoped template edit changed: the
+// study plan entry for an instance edit, or the template that now holds the
+// rule for a future edit
+type PlanTemplateUpdate struct {
+	StudyPlanID int `json:"study_plan_id"`
+	TemplateID  int `json:"template_id"`
+}
+
+// UpdatePlanTemplate edits one occurrence or every future occurrence
+func (c *Client) UpdatePlanTemplate(ctx context.Context, id int, input models.UpdatePlanTemplateInput) (*PlanTemplateUpdate, error) {
+	var result PlanTemplateUpdate
+	if err := c.do(ctx, http.MethodPut, idPath("/plan-templates/%d", id), nil, input, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// DeletePlanTemplate deletes a template and its untouched future entries
+func (c *Client) DeletePlanTemplate(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodDelete, idPath("/plan-templates/%d", id), nil, nil, nil)
+}
+
+// ExpandPlanTemplate materializes a template's occurrences and returns how
+// many entries were created
+func (c *Client) ExpandPlanTemplate(ctx context.Context, id int, input models.ExpandPlanTemplateInput) (int, error) {
+	var result struct {
+		CreatedEntries int `json:"created_entries"`
+	}
+	err := c.do(ctx, http.MethodPost, idPath("/plan-templates/%d/expand", id), nil, input, &result)
+	return result.CreatedEntries, err
+}
+
+// Batch runs several creates, updates and deletes in one transaction. If
+// any operation fails none is applied, and the APIError's Data names the
+// failing operation's index and ref.
+func (c *Client) Batch(ctx context.Context, operations []models.BatchOperation) ([]models.BatchResult, error) {
+	var response models.BatchResponse
+	if err := c.do(ctx, http.MethodPost, "/batch", nil, models.BatchInput{Operations: operations}, &response); err != nil {
+		return nil, err
+	}
+	return response.Results, nil
+}
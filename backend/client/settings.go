@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"exam-prep/models"
+	"net/http"
+	"net/url"
+)
+
+// GetSettings returns the current user's settings
+func (c *Client) GetSettings(ctx context.Context) (*models.UserSettings, error) {
+	var settings models.UserSettings
+	if err := c.do(ctx, http.MethodGet, "/settings", nil, nil, &settings); err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// UpdateSettings updates the current user's settings
+func (c *Client) UpdateSettings(ctx context.Context, input models.UpdateUserSettingsInput) error {
+	return c.doIdempotent(ctx, http.MethodPut, "/settings", nil, input, nil)
+}
+
+// ListReminders returns the current user's reminder rules
+func (c *Client) ListReminders(ctx context.Context) ([]models.ReminderRule, error) {
+	var rules []models.ReminderRule
+	err := c.do(ctx, http.MethodGet, "/reminders", nil, nil, &rules)
+	return rules, err
+}
+
+// CreateReminder creates a reminder rule and returns its ID
+func (c *Client) CreateReminder(ctx context.Context, input models.CreateReminderRuleInput) (int, error) {
+	var result created
+	err := c.do(ctx, http.MethodPost, "/reminders", nil, input, &result)
+	return result.ID, err
+}
+
+// UpdateReminder updates a reminder rule
+func (c *Client) UpdateReminder(ctx context.Context, id int, input models.UpdateReminderRuleInput) error {
+	return c.doIdempotent(ctx, http.MethodPut, idPath("/reminders/%d", id), nil, input, nil)
+}
+
+// DeleteReminder deletes a reminder rule
+func (c *Client) DeleteReminder(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodDelete, idPath("/reminders/%d", id), nil, nil, nil)
+}
+
+// ListNotifications returns the current user's in-app notifications
+func (c *Client) ListNotifications(ctx context.Context, unreadOnly bool) ([]models.Notification, error) {
+	query := url.Values{}
+	if unreadOnly {
+		query.Set("unread", "true")
+	}
+
+	var notifications []models.Notification
+	err := c.do(ctx, http.MethodGet, "/notifications", query, nil, &notifications)
+	return notifications, err
+}
+
+// MarkNotificationRead marks a notification as read
+func (c *Client) MarkNotificationRead(ctx context.Context, id int) error {
+	return c.doIdempotent(ctx, http.MethodPut, idPath("/notifications/%d/read", id), nil, nil, nil)
+}
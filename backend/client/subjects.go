@@ -0,0 +1,263 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"exam-prep/models"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ListSubjects returns every subject with its topic progress
+func (c *Client) ListSubjects(ctx context.Context) ([]models.SubjectWithProgress, error) {
+	var subjects []models.SubjectWithProgress
+	err := c.do(ctx, http.MethodGet, "/subjects", nil, nil, &subjects)
+	return subjects, err
+}
+
+// GetSubject returns one subject with its topic progress
+func (c *Client) GetSubject(ctx context.Context, id int) (*models.SubjectWithProgress, error) {
+	var subject models.SubjectWithProgress
+	if err := c.do(ctx, http.MethodGet, idPath("/subjects/%d", id), nil, nil, &subject); err != nil {
+		return nil, err
+	}
+	return &subject, nil
+}
+
+// CreateSubject creates a subject and returns its ID
+func (c *Client) CreateSubject(ctx context.Context, input models.CreateSubjectInput) (int, error) {
+	var result created
+	err := c.do(ctx, http.MethodPost, "/subjects", nil, input, &result)
+	return result.ID, err
+}
+
+// UpdateSubject updates a subject
+func (c *Client) UpdateSubject(ctx context.Context, id int, input models.UpdateSubjectInput) error {
+	return c.doIdempotent(ctx, http.MethodPut, idPath("/subjects/%d", id), nil, input, nil)
+}
+
+// Patch is a JSON merge patch (RFC 7396). Keys that are left out keep their
+// value and a nil value clears the field.
+type Patch map[string]interface{}
+
+// PatchSubject applies a merge patch to a subject and returns its patched fields
+func (c *Client) PatchSubject(ctx context.Context, id int, patch Patch) (*models.SubjectPatch, error) {
+	var subject models.SubjectPatch
+	if err := c.do(ctx, http.MethodPatch, idPath("/subjects/%d", id), nil, patch, &subject); err != nil {
+		return nil, err
+	}
+	return &subject, nil
+}
+
+// DeleteSubject moves a subject and its topics, notes and plan to the trash
+func (c *Client) DeleteSubject(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodDelete, idPath("/subjects/%d", id), nil, nil, nil)
+}
+
+// RestoreSubject brings a subject back from the trash
+func (c *Client) RestoreSubject(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodPut, idPath("/subjects/%d/restore", id), nil, nil, nil)
+}
+
+// ListTopics returns a subject's topics
+func (c *Client) ListTopics(ctx context.Context, subjectID int) ([]models.Topic, error) {
+	var topics []models.Topic
+	err := c.do(ctx, http.MethodGet, idPath("/subjects/%d/topics", subjectID), nil, nil, &topics)
+	return topics, err
+}
+
+// GetTopicOrder returns a subject's topics in dependency-aware study order
+func (c *Client) GetTopicOrder(ctx context.Context, subjectID int) ([]models.OrderedTopic, error) {
+	var order []models.OrderedTopic
+	err := c.do(ctx, http.MethodGet, idPath("/subjects/%d/topics/order", subjectID), nil, nil, &order)
+	return order, err
+}
+
+// CreateTopic creates a topic and returns its ID
+func (c *Client) CreateTopic(ctx context.Context, input models.CreateTopicInput) (int, error) {
+	var result created
+	err := c.do(ctx, http.MethodPost, "/topics", nil, input, &result)
+	return result.ID, err
+}
+
+// PrerequisiteWarning is returned when a topic is completed before all of
+// its prerequisites
+type PrerequisiteWarning struct {
+	Warnings                []string       `json:"warnings"`
+	IncompletePrerequisites []models.Topic `json:"incomplete_prerequisites"`
+}
+
+// UpdateTopic updates a topic. The warning is non-nil when the update
+// completed a topic whose prerequisites are still incomplete.
+func (c *Client) UpdateTopic(ctx context.Context, id int, input models.UpdateTopicInput) (*PrerequisiteWarning, error) {
+	return c.topicWarning(ctx, idPath("/topics/%d", id), input, true)
+}
+
+// ToggleTopicComplete flips a topic's completion. The warning is non-nil when
+// the topic was completed while some prerequisites are still incomplete.
+func (c *Client) ToggleTopicComplete(ctx context.Context, id int) (*PrerequisiteWarning, error) {
+	return c.topicWarning(ctx, idPath("/topics/%d/complete", id), nil, false)
+}
+
+// topicWarning sends a PUT that may warn about prerequisites. Only an
+// idempotent one is retried; a toggle sent twice would undo itself.
+func (c *Client) topicWarning(ctx context.Context, path string, body interface{}, idempotent bool) (*PrerequisiteWarning, error) {
+	var warning PrerequisiteWarning
+	if err := c.request(ctx, http.MethodPut, path, nil, body, &warning, idempotent); err != nil {
+		return nil, err
+	}
+	if len(warning.Warnings) == 0 {
+		return nil, nil
+	}
+	return &warning, nil
+}
+
+// PatchTopic applies a merge patch to a topic and returns its patched
+// fields. The warning is non-nil when the patch completed a topic whose
+// prerequisites are still incomplete.
+func (c *Client) PatchTopic(ctx context.Context, id int, patch Patch) (*models.TopicPatch, *PrerequisiteWarning, error) {
+	var raw json.RawMessage
+	if err := c.do(ctx, http.MethodPatch, idPath("/topics/%d", id), nil, patch, &raw); err != nil {
+		return nil, nil, err
+	}
+
+	var warned struct {
+		PrerequisiteWarning
+		Topic *models.TopicPatch `json:"topic"`
+	}
+	if err := json.Unmarshal(raw, &warned); err != nil {
+		return nil, nil, err
+	}
+	if warned.Topic != nil {
+		return warned.Topic, &warned.PrerequisiteWarning, nil
+	}
+	var topic models.TopicPatch
+	if err := json.Unmarshal(raw, &topic); err != nil {
+		return nil, nil, err
+	}
+	return &topic, nil, nil
+}
+
+// ToggleTopicWeak flips a topic's weak flag
+func (c *Client) ToggleTopicWeak(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodPut, idPath("/topics/%d/weak", id), nil, nil, nil)
+}
+
+// WeakestTopicsOptions filters the weakest topics ranking. Zero values use
+// the server defaults.
+type WeakestTopicsOptions struct {
+	SubjectID        int
+	Limit            int
+	IncludeCompleted bool
+}
+
+// WeakestTopics ranks topics by recent confidence, weakest first
+func (c *Client) WeakestTopics(ctx context.Context, opts WeakestTopicsOptions) ([]models.WeakTopic, error) {
+	query := url.Values{}
+	if opts.SubjectID != 0 {
+		query.Set("subject_id", strconv.Itoa(opts.SubjectID))
+	}
+	if opts.Limit != 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.IncludeCompleted {
+		query.Set("include_completed", "true")
+	}
+
+	var topics []models.WeakTopic
+	err := c.do(ctx, http.MethodGet, "/topics/weakest", query, nil, &topics)
+	return topics, err
+}
+
+// GetTopicHistory returns a topic with its confidence reviews
+func (c *Client) GetTopicHistory(ctx context.Context, id int) (*models.TopicHistory, error) {
+	var history models.TopicHistory
+	if err := c.do(ctx, http.MethodGet, idPath("/topics/%d/history", id), nil, nil, &history); err != nil {
+		return nil, err
+	}
+	return &history, nil
+}
+
+// ReviewTopic records a 1-5 confidence self-assessment
+func (c *Client) ReviewTopic(ctx context.Context, id int, input models.CreateTopicReviewInput) (*models.TopicReview, error) {
+	var review models.TopicReview
+	if err := c.do(ctx, http.MethodPost, idPath("/topics/%d/reviews", id), nil, input, &review); err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// ListTopicDependencies returns a topic's prerequisites
+func (c *Client) ListTopicDependencies(ctx context.Context, id int) ([]models.Topic, error) {
+	var topics []models.Topic
+	err := c.do(ctx, http.MethodGet, idPath("/topics/%d/dependencies", id), nil, nil, &topics)
+	return topics, err
+}
+
+// AddTopicDependency makes prerequisiteID a prerequisite of the topic. A
+// link that would form a cycle fails with ErrConflict.
+func (c *Client) AddTopicDependency(ctx context.Context, id, prerequisiteID int) error {
+	input := models.CreateTopicDependencyInput{PrerequisiteID: prerequisiteID}
+	return c.do(ctx, http.MethodPost, idPath("/topics/%d/dependencies", id), nil, input, nil)
+}
+
+// RemoveTopicDependency removes a prerequisite from a topic
+func (c *Client) RemoveTopicDependency(ctx context.Context, id, prerequisiteID int) error {
+	return c.do(ctx, http.MethodDelete, idPath("/topics/%d/dependencies/%d", id, prerequisiteID), nil, nil, nil)
+}
+
+// DeleteTopic moves a topic to the trash
+func (c *Client) DeleteTopic(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodDelete, idPath("/topics/%d", id), nil, nil, nil)
+}
+
+// RestoreTopic brings a topic back from the trash
+func (c *Client) RestoreTopic(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodPut, idPath("/topics/%d/restore", id), nil, nil, nil)
+}
+
+// ListNotes returns every note, most recently updated first
+func (c *Client) ListNotes(ctx context.Context) ([]models.Note, error) {
+	var notes []models.Note
+	err := c.do(ctx, http.MethodGet, "/notes", nil, nil, &notes)
+	return notes, err
+}
+
+// ListSubjectNotes returns a subject's notes
+func (c *Client) ListSubjectNotes(ctx context.Context, subjectID int) ([]models.Note, error) {
+	var notes []models.Note
+	err := c.do(ctx, http.MethodGet, idPath("/subjects/%d/notes", subjectID), nil, nil, &notes)
+	return notes, err
+}
+
+// CreateNote creates a note and returns its ID
+func (c *Client) CreateNote(ctx context.Context, input models.CreateNoteInput) (int, error) {
+	var result created
+	err := c.do(ctx, http.MethodPost, "/notes", nil, input, &result)
+	return result.ID, err
+}
+
+// UpdateNote updates a note
+func (c *Client) UpdateNote(ctx context.Context, id int, input models.UpdateNoteInput) error {
+	return c.doIdempotent(ctx, http.MethodPut, idPath("/notes/%d", id), nil, input, nil)
+}
+
+// PatchNote applies a merge patch to a note and returns its patched fields
+func (c *Client) PatchNote(ctx context.Context, id int, patch Patch) (*models.NotePatch, error) {
+	var note models.NotePatch
+	if err := c.do(ctx, http.MethodPatch, idPath("/notes/%d", id), nil, patch, &note); err != nil {
+		return nil, err
+	}
+	return &note, nil
+}
+
+// DeleteNote moves a note to the trash
+func (c *Client) DeleteNote(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodDelete, idPath("/notes/%d", id), nil, nil, nil)
+}
+
+// RestoreNote brings a note back from the trash
+func (c *Client) RestoreNote(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodPut, idPath("/notes/%d/restore", id), nil, nil, nil)
+}
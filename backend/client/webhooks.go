@@ -0,0 +1,59 @@
+package client
+
+import (
+	"context"
+	"exam-prep/models"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ListWebhooks returns the webhook subscriptions
+func (c *Client) ListWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	var hooks []models.Webhook
+	err := c.do(ctx, http.MethodGet, "/webhooks", nil, nil, &hooks)
+	return hooks, err
+}
+
+// CreateWebhook creates a subscription and returns its ID and signing
+// secret. The secret is only ever returned here.
+func (c *Client) CreateWebhook(ctx context.Context, input models.CreateWebhookInput) (int, string, error) {
+	var result struct {
+		ID     int    `json:"id"`
+		Secret string `json:"secret"`
+	}
+	err := c.do(ctx, http.MethodPost, "/webhooks", nil, input, &result)
+	return result.ID, result.Secret, err
+}
+
+// UpdateWebhook updates a subscription
+func (c *Client) UpdateWebhook(ctx context.Context, id int, input models.UpdateWebhookInput) error {
+	return c.doIdempotent(ctx, http.MethodPut, idPath("/webhooks/%d", id), nil, input, nil)
+}
+
+// DeleteWebhook deletes a subscription
+func (c *Client) DeleteWebhook(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodDelete, idPath("/webhooks/%d", id), nil, nil, nil)
+}
+
+// ListWebhookDeliveries returns a subscription's delivery log; limit 0 uses
+// the server default
+func (c *Client) ListWebhookDeliveries(ctx context.Context, id, limit int) ([]models.WebhookDelivery, error) {
+	query := url.Values{}
+	if limit != 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	var deliveries []models.WebhookDelivery
+	err := c.do(ctx, http.MethodGet, idPath("/webhooks/%d/deliveries", id), query, nil, &deliveries)
+	return deliveries, err
+}
+
+// TestWebhook sends a test delivery and returns its outcome
+func (c *Client) TestWebhook(ctx context.Context, id int) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	if err := c.do(ctx, http.MethodPost, idPath("/webhooks/%d/test", id), nil, nil, &delivery); err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"exam-prep/database"
+	"exam-prep/models"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// today prints today's study plan
+func today(ctx context.Context, a *app, args []string) error {
+	day, err := a.api.StudyPlanDay(ctx, "")
+	if err != nil {
+		return err
+	}
+	if a.jsonMode {
+		return printJSON(day)
+	}
+
+	fmt.Printf("%s (%s), %.1f hours planned\n\n", day.Date, day.Timezone, day.TotalHours)
+	entries := append(day.Slots, day.Unscheduled...)
+	if len(entries) == 0 {
+		fmt.Println("Nothing planned today.")
+		return nil
+	}
+
+	rows := [][]string{{"ID", "TIME", "SUBJECT", "DONE", "NOTES"}}
+	for _, e := range entries {
+		slot := "-"
+		if e.StartTime != nil && e.EndTime != nil {
+			slot = *e.StartTime + "-" + *e.EndTime
+		}
+		rows = append(rows, []string{
+			strconv.Itoa(e.ID), slot, e.SubjectName,
+			fmt.Sprintf("%s %.1f/%.1fh", bar(e.HoursCompleted, e.HoursPlanned, 10), e.HoursCompleted, e.HoursPlanned),
+			e.Notes,
+		})
+	}
+	printTable(rows)
+	return nil
+}
+
+// status prints the exam countdown and progress per subject
+func status(ctx context.Context, a *app, args []string) error {
+	dashboard, err := a.api.GetDashboard(ctx)
+	if err != nil {
+		return err
+	}
+	if a.jsonMode {
+		return printJSON(dashboard)
+	}
+
+	fmt.Printf("%d days until the exam on %s\n", dashboard.DaysUntilExam, dashboard.ExamDate)
+	fmt.Printf("Overall %s %.0f%%  (%d/%d topics, %d weak)\n\n",
+		bar(dashboard.OverallProgress, 100, 20), dashboard.OverallProgress,
+		dashboard.CompletedTopics, dashboard.TotalTopics, dashboard.WeakTopics)
+
+	rows := [][]string{{"SUBJECT", "PROGRESS", "", "TOPICS", "WEAK"}}
+	for _, s := range dashboard.SubjectProgress {
+		rows = append(rows, []string{
+			s.Name, bar(s.Progress, 100, 20), fmt.Sprintf("%.0f%%", s.Progress),
+			fmt.Sprintf("%d/%d", s.CompletedTopics, s.TotalTopics), strconv.Itoa(s.WeakTopics),
+		})
+	}
+	printTable(rows)
+	return nil
+}
+
+// setCompleted returns a command that marks a topic complete or incomplete
+func setCompleted(completed bool) command {
+	return func(ctx context.Context, a *app, args []string) error {
+		topic, err := findTopic(ctx, a, args)
+		if err != nil {
+			return err
+		}
+		if topic.IsCompleted == completed {
+			return report(a, topic, "already "+completedLabel(completed))
+		}
+
+		warning, err := a.api.UpdateTopic(ctx, topic.ID, models.UpdateTopicInput{IsCompleted: &completed})
+		if err != nil {
+			return err
+		}
+		topic.IsCompleted = completed
+		if warning != nil {
+			names := make([]string, len(warning.IncompletePrerequisites))
+			for i, t := range warning.IncompletePrerequisites {
+				names[i] = t.Name
+			}
+			return report(a, topic, completedLabel(completed)+"; prerequisites still incomplete: "+strings.Join(names, ", "))
+		}
+		return report(a, topic, completedLabel(completed))
+	}
+}
+
+func completedLabel(completed bool) string {
+	if completed {
+		return "completed"
+	}
+	return "not completed"
+}
+
+// setWeak returns a command that flags a topic as weak or clears the flag.
+// Like the weak toggle endpoint, it records a confidence review that sets
+// the flag, at or just above the weak threshold.
+func setWeak(weak bool) command {
+	return func(ctx context.Context, a *app, args []string) error {
+		topic, err := findTopic(ctx, a, args)
+		if err != nil {
+			return err
+		}
+		label, review := "marked weak", models.CreateTopicReviewInput{Confidence: database.WeakConfidence, Comment: "Marked weak"}
+		if !weak {
+			label, review = "no longer weak", models.CreateTopicReviewInput{Confidence: database.WeakConfidence + 2, Comment: "No longer weak"}
+		}
+		if topic.IsWeak == weak {
+			return report(a, topic, "already "+label)
+		}
+
+		if _, err := a.api.ReviewTopic(ctx, topic.ID, review); err != nil {
+			return err
+		}
+		topic.IsWeak = weak
+		topic.Confidence = &review.Confidence
+		return report(a, topic, label)
+	}
+}
+
+// note adds a quick note to a subject
+func note(ctx context.Context, a *app, args []string) error {
+	if len(args) < 2 {
+		return errors.New(`usage: examprep note <subject> <title> [text...]`)
+	}
+	subject, err := findSubject(ctx, a, args[0])
+	if err != nil {
+		return err
+	}
+
+	input := models.CreateNoteInput{SubjectID: subject.ID, Title: args[1], Content: strings.Join(args[2:], " ")}
+	id, err := a.api.CreateNote(ctx, input)
+	if err != nil {
+		return err
+	}
+	if a.jsonMode {
+		return printJSON(map[string]interface{}{"id": id, "subject_id": subject.ID, "title": input.Title})
+	}
+	fmt.Printf("Added note %d to %s\n", id, subject.Name)
+	return nil
+}
+
+// timer counts study time for a subject until the given number of minutes
+// pass or it is interrupted, then adds the time to the subject's entry on
+// today's plan, creating one if needed
+func timer(ctx context.Context, a *app, args []string) error {
+	if len(args) < 1 {
+		return errors.New("usage: examprep timer <subject> [minutes]")
+	}
+	subject, err := findSubject(ctx, a, args[0])
+	if err != nil {
+		return err
+	}
+
+	var limit time.Duration
+	if len(args) > 1 {
+		minutes, err := strconv.Atoi(args[1])
+		if err != nil || minutes <= 0 {
+			return fmt.Errorf("invalid minutes %q", args[1])
+		}
+		limit = time.Duration(minutes) * time.Minute
+	}
+
+	started := time.Now()
+	fmt.Printf("Studying %s. Press Ctrl+C to stop.\n", subject.Name)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for running := true; running; {
+		select {
+		case <-ctx.Done():
+			running = false
+		case now := <-ticker.C:
+			elapsed := now.Sub(started).Truncate(time.Second)
+			if limit > 0 && elapsed >= limit {
+				fmt.Print("\a")
+				running = false
+			}
+			fmt.Printf("\r%s ", elapsed)
+		}
+	}
+	fmt.Println()
+
+	hours := roundHours(time.Since(started))
+	if hours == 0 {
+		fmt.Println("Less than six minutes studied; nothing logged.")
+		return nil
+	}
+
+	// The interrupt cancelled ctx, so log the time on a fresh one
+	logCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	entry, err := logStudyTime(logCtx, a, subject, hours)
+	if err != nil {
+		return err
+	}
+	if a.jsonMode {
+		return printJSON(entry)
+	}
+	fmt.Printf("Logged %.1fh on %s (%.1f/%.1fh today)\n", hours, subject.Name, entry.HoursCompleted, entry.HoursPlanned)
+	return nil
+}
+
+// logStudyTime adds hours to the subject's entry on today's plan
+func logStudyTime(ctx context.Context, a *app, subject *models.SubjectWithProgress, hours float64) (*models.StudyPlan, error) {
+	plans, err := a.api.TodayStudyPlan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range plans {
+		if p.SubjectID != subject.ID {
+			continue
+		}
+		completed := p.HoursCompleted + hours
+		if err := a.api.UpdateStudyPlan(ctx, p.ID, models.UpdateStudyPlanInput{HoursCompleted: &completed}); err != nil {
+			return nil, err
+		}
+		p.HoursCompleted = completed
+		return &p, nil
+	}
+
+	day, err := a.api.StudyPlanDay(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	id, err := a.api.CreateStudyPlan(ctx, models.CreateStudyPlanInput{
+		SubjectID: subject.ID, StudyDate: day.Date, HoursPlanned: hours, Notes: "Logged with examprep timer",
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := a.api.UpdateStudyPlan(ctx, id, models.UpdateStudyPlanInput{HoursCompleted: &hours}); err != nil {
+		return nil, err
+	}
+	return &models.StudyPlan{ID: id, SubjectID: subject.ID, SubjectName: subject.Name, StudyDate: day.Date,
+		HoursPlanned: hours, HoursCompleted: hours}, nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"exam-prep/models"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// findSubject resolves a subject by ID or case-insensitive name prefix
+func findSubject(ctx context.Context, a *app, name string) (*models.SubjectWithProgress, error) {
+	subjects, err := a.api.ListSubjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	id, _ := strconv.Atoi(name)
+	var matches []models.SubjectWithProgress
+	for _, s := range subjects {
+		if s.ID == id || strings.EqualFold(s.Name, name) {
+			return &s, nil
+		}
+		if strings.HasPrefix(strings.ToLower(s.Name), strings.ToLower(name)) {
+			matches = append(matches, s)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no subject matches %q", name)
+	case 1:
+		return &matches[0], nil
+	}
+	names := make([]string, len(matches))
+	for i, s := range matches {
+		names[i] = s.Name
+	}
+	return nil, fmt.Errorf("%q matches several subjects: %s", name, strings.Join(names, ", "))
+}
+
+// findTopic resolves "Subject: topic" (or a topic ID with a subject, as
+// "Subject: 12") to a topic. The topic name may be a case-insensitive prefix
+// when it is unambiguous.
+func findTopic(ctx context.Context, a *app, args []string) (*models.Topic, error) {
+	spec := strings.Join(args, " ")
+	subjectName, topicName, ok := strings.Cut(spec, ":")
+	if !ok || strings.TrimSpace(topicName) == "" {
+		return nil, errors.New(`name the topic as "Subject: topic"`)
+	}
+	topicName = strings.TrimSpace(topicName)
+
+	subject, err := findSubject(ctx, a, strings.TrimSpace(subjectName))
+	if err != nil {
+		return nil, err
+	}
+	topics, err := a.api.ListTopics(ctx, subject.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	id, _ := strconv.Atoi(topicName)
+	var matches []models.Topic
+	for _, t := range topics {
+		if t.ID == id || strings.EqualFold(t.Name, topicName) {
+			return &t, nil
+		}
+		if strings.HasPrefix(strings.ToLower(t.Name), strings.ToLower(topicName)) {
+			matches = append(matches, t)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("%s has no topic matching %q", subject.Name, topicName)
+	case 1:
+		return &matches[0], nil
+	}
+	names := make([]string, len(matches))
+	for i, t := range matches {
+		names[i] = t.Name
+	}
+	return nil, fmt.Errorf("%q matches several topics: %s", topicName, strings.Join(names, ", "))
+}
@@ -0,0 +1,108 @@
+// Command examprep is a command-line front end for the exam preparation API.
+//
+// Usage:
+//
+//	examprep [flags] <command> [arguments]
+//
+// Commands:
+//
+//	today                      today's study plan
+//	status                     exam countdown and progress per subject
+//	done "Subject: topic"      mark a topic complete
+//	undo "Subject: topic"      mark a topic incomplete
+//	weak "Subject: topic"      flag a topic as weak
+//	strong "Subject: topic"    clear a topic's weak flag
+//	note Subject title [text]  add a quick note
+//	timer Subject [minutes]    run a study timer and log the time on today's plan
+//
+// The API address, user, timezone and token default to EXAMPREP_API,
+// EXAMPREP_USER, EXAMPREP_TZ and EXAMPREP_TOKEN.
+package main
+
+import (
+	"context"
+	"exam-prep/client"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// command runs one subcommand with its arguments
+type command func(ctx context.Context, app *app, args []string) error
+
+var commands = map[string]command{
+	"today":  today,
+	"status": status,
+	"done":   setCompleted(true),
+	"undo":   setCompleted(false),
+	"weak":   setWeak(true),
+	"strong": setWeak(false),
+	"note":   note,
+	"timer":  timer,
+}
+
+// app holds what every command needs
+type app struct {
+	api      *client.Client
+	jsonMode bool
+}
+
+func main() {
+	flags := flag.NewFlagSet("examprep", flag.ExitOnError)
+	apiURL := flags.String("api", env("EXAMPREP_API", "http://localhost:8080/api"), "API base URL")
+	user := flags.String("user", os.Getenv("EXAMPREP_USER"), "user name sent as X-User")
+	timezone := flags.String("tz", os.Getenv("EXAMPREP_TZ"), "IANA timezone, e.g. Asia/Kolkata")
+	token := flags.String("token", os.Getenv("EXAMPREP_TOKEN"), "bearer token")
+	jsonMode := flags.Bool("json", false, "print JSON instead of tables")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: examprep [flags] today|status|done|undo|weak|strong|note|timer [arguments]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(os.Args[1:])
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	run, ok := commands[flags.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "examprep: unknown command %q\n", flags.Arg(0))
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	opts := []client.Option{}
+	if *user != "" {
+		opts = append(opts, client.WithUser(*user))
+	}
+	if *timezone != "" {
+		opts = append(opts, client.WithTimezone(*timezone))
+	}
+	if *token != "" {
+		opts = append(opts, client.WithToken(*token))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	a := &app{api: client.New(*apiURL, opts...), jsonMode: *jsonMode}
+	if err := run(ctx, a, flags.Args()[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "examprep: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// env returns an environment variable or a fallback when it is unset
+func env(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// roundHours rounds to the tenth of an hour the API stores
+func roundHours(d time.Duration) float64 {
+	return float64(int(d.Hours()*10+0.5)) / 10
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"exam-prep/models"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// printJSON writes v as indented JSON
+func printJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// printTable writes rows as aligned columns; the first row is the header
+func printTable(rows [][]string) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
+}
+
+// bar draws value out of total as a progress bar of the given width
+func bar(value, total float64, width int) string {
+	filled := 0
+	if total > 0 {
+		filled = int(value / total * float64(width))
+	}
+	if filled > width {
+		filled = width
+	}
+	if filled < 0 {
+		filled = 0
+	}
+	return "[" + strings.Repeat("#", filled) + strings.Repeat(".", width-filled) + "]"
+}
+
+// report prints the outcome of a topic change
+func report(a *app, topic *models.Topic, outcome string) error {
+	if a.jsonMode {
+		return printJSON(map[string]interface{}{"topic": topic, "result": outcome})
+	}
+	fmt.Printf("%s: %s\n", topic.Name, outcome)
+	return nil
+}
@@ -0,0 +1,52 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"exam-prep/models"
+	"time"
+)
+
+// Snapshot returns the current row of a table as JSON, or nil if it does not exist
+func Snapshot(ctx context.Context, table string, id int) (json.RawMessage, error) {
+	return SnapshotIn(ctx, DB, table, id)
+}
+
+// RowQuerier is a *sql.DB or *sql.Tx
+type RowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// SnapshotIn is Snapshot run through q, which may be a transaction
+func SnapshotIn(ctx context.Context, q RowQuerier, table string, id int) (json.RawMessage, error) {
+	var data []byte
+	err := q.QueryRowContext(ctx, "SELECT row_to_json(x) FROM "+table+" x WHERE x.id = $1", id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// RecordActivity writes an entry to the activity log and returns it with its
+// ID and time
+func RecordActivity(ctx context.Context, actor, entityType string, entityID int, action string, before, after json.RawMessage) (models.Activity, error) {
+	a := models.Activity{Actor: actor, EntityType: entityType, EntityID: entityID, Action: action, Before: before, After: after, CreatedAt: time.Now()}
+	err := DB.QueryRowContext(ctx,
+		"INSERT INTO activity_log (actor, entity_type, entity_id, action, before_data, after_data) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at",
+		actor, entityType, entityID, action, nullJSON(before), nullJSON(after),
+	).Scan(&a.ID, &a.CreatedAt)
+	return a, err
+}
+
+// nullJSON turns an empty JSON value into a SQL NULL
+func nullJSON(data json.RawMessage) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	return []byte(data)
+}
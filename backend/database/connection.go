@@ -2,11 +2,12 @@ package database
 
 import (
 	"database/sql"
+	"exam-prep/telemetry"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 var DB *sql.DB
@@ -17,10 +18,10 @@ func Connect() error {
 	dbURL := os.Getenv("DATABASE_URL")
 
 	if dbURL != "" {
-		log.Println("DEBUG: Found DATABASE_URL, using it...")
+		slog.Debug("Connecting with DATABASE_URL")
 		connStr = dbURL
 	} else {
-		log.Println("DEBUG: DATABASE_URL is empty! Checking individual variables...")
+		slog.Debug("DATABASE_URL is not set, connecting with DB_HOST and related variables")
 		host := os.Getenv("DB_HOST")
 		port := os.Getenv("DB_PORT")
 		user := os.Getenv("DB_USER")
@@ -31,11 +32,12 @@ func Connect() error {
 			host, port, user, password, dbname)
 	}
 
-	var err error
-	DB, err = sql.Open("postgres", connStr)
+	connector, err := pq.NewConnector(connStr)
 	if err != nil {
 		return fmt.Errorf("error opening database: %v", err)
 	}
+	// Statements run with a traced context become spans of the request
+	DB = sql.OpenDB(telemetry.WrapConnector(connector))
 
 	// Test the connection
 	err = DB.Ping()
@@ -43,7 +45,7 @@ func Connect() error {
 		return fmt.Errorf("error connecting to database: %v", err)
 	}
 
-	log.Println("✅ Connected to PostgreSQL database successfully!")
+	slog.Info("Connected to PostgreSQL")
 	return nil
 }
 
@@ -90,6 +92,137 @@ func InitTables() error {
 			notes TEXT,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		)`,
+		// Per-subject exam dates, falling back to EXAM_DATE
+		`ALTER TABLE subjects ADD COLUMN IF NOT EXISTS exam_date DATE`,
+		// Soft deletion columns for the trash
+		`ALTER TABLE subjects ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP`,
+		`ALTER TABLE topics ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP`,
+		`ALTER TABLE notes ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP`,
+		`ALTER TABLE study_plan ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP`,
+		`CREATE TABLE IF NOT EXISTS activity_log (
+			id SERIAL PRIMARY KEY,
+			actor VARCHAR(100) NOT NULL,
+			entity_type VARCHAR(50) NOT NULL,
+			entity_id INTEGER NOT NULL,
+			action VARCHAR(50) NOT NULL,
+			before_data JSONB,
+			after_data JSONB,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_activity_log_entity ON activity_log (entity_type, entity_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_activity_log_created_at ON activity_log (created_at DESC)`,
+		`CREATE TABLE IF NOT EXISTS webhooks (
+			id SERIAL PRIMARY KEY,
+			url TEXT NOT NULL,
+			secret VARCHAR(100) NOT NULL,
+			event_types TEXT[] NOT NULL DEFAULT '{}',
+			active BOOLEAN DEFAULT TRUE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+			id SERIAL PRIMARY KEY,
+			webhook_id INTEGER REFERENCES webhooks(id) ON DELETE CASCADE,
+			event_type VARCHAR(100) NOT NULL,
+			payload JSONB NOT NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'pending',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			last_status_code INTEGER,
+			last_error TEXT,
+			next_attempt_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			delivered_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'pending'`,
+		`CREATE TABLE IF NOT EXISTS reminder_rules (
+			id SERIAL PRIMARY KEY,
+			user_name VARCHAR(100) NOT NULL,
+			rule_type VARCHAR(30) NOT NULL,
+			channel VARCHAR(20) NOT NULL,
+			target TEXT,
+			send_hour INTEGER NOT NULL DEFAULT 20,
+			thresholds INTEGER[] NOT NULL DEFAULT '{}',
+			enabled BOOLEAN DEFAULT TRUE,
+			last_sent_on DATE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		// Missed-session rollover
+		`ALTER TABLE study_plan ADD COLUMN IF NOT EXISTS carried_over DECIMAL(3,1) DEFAULT 0.0`,
+		`CREATE TABLE IF NOT EXISTS plan_rebalances (
+			id SERIAL PRIMARY KEY,
+			actor VARCHAR(100) NOT NULL,
+			daily_cap DECIMAL(3,1) NOT NULL,
+			unplaced_hours DECIMAL(5,1) NOT NULL DEFAULT 0.0,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			reverted_at TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS plan_rebalance_moves (
+			id SERIAL PRIMARY KEY,
+			rebalance_id INTEGER REFERENCES plan_rebalances(id) ON DELETE CASCADE,
+			source_plan_id INTEGER REFERENCES study_plan(id) ON DELETE CASCADE,
+			target_plan_id INTEGER REFERENCES study_plan(id) ON DELETE CASCADE,
+			hours DECIMAL(3,1) NOT NULL,
+			created_target BOOLEAN DEFAULT FALSE
+		)`,
+		// Time-of-day slots
+		`ALTER TABLE study_plan ADD COLUMN IF NOT EXISTS start_time TIME`,
+		`ALTER TABLE study_plan ADD COLUMN IF NOT EXISTS end_time TIME`,
+		// Recurring plan templates
+		`CREATE TABLE IF NOT EXISTS plan_templates (
+			id SERIAL PRIMARY KEY,
+			subject_id INTEGER REFERENCES subjects(id) ON DELETE CASCADE,
+			rrule TEXT NOT NULL,
+			hours_planned DECIMAL(3,1) DEFAULT 1.0,
+			notes TEXT,
+			start_date DATE NOT NULL,
+			end_date DATE,
+			exceptions DATE[] NOT NULL DEFAULT '{}',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`ALTER TABLE study_plan ADD COLUMN IF NOT EXISTS template_id INTEGER REFERENCES plan_templates(id) ON DELETE SET NULL`,
+		`CREATE TABLE IF NOT EXISTS user_settings (
+			user_name VARCHAR(100) PRIMARY KEY,
+			timezone VARCHAR(64) NOT NULL,
+			locale VARCHAR(35) NOT NULL DEFAULT 'en',
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS notifications (
+			id SERIAL PRIMARY KEY,
+			user_name VARCHAR(100) NOT NULL,
+			kind VARCHAR(30) NOT NULL,
+			title VARCHAR(200) NOT NULL,
+			body TEXT,
+			is_read BOOLEAN DEFAULT FALSE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		// Weekly progress reports
+		`CREATE TABLE IF NOT EXISTS weekly_reports (
+			id SERIAL PRIMARY KEY,
+			week_start DATE NOT NULL UNIQUE,
+			week_end DATE NOT NULL,
+			data JSONB NOT NULL,
+			emailed_to VARCHAR(200),
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		// Topic confidence and self-assessment history
+		`ALTER TABLE topics ADD COLUMN IF NOT EXISTS confidence SMALLINT`,
+		`CREATE TABLE IF NOT EXISTS topic_reviews (
+			id SERIAL PRIMARY KEY,
+			topic_id INTEGER REFERENCES topics(id) ON DELETE CASCADE,
+			confidence SMALLINT NOT NULL CHECK (confidence BETWEEN 1 AND 5),
+			comment TEXT,
+			reviewer VARCHAR(100) NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_topic_reviews_topic ON topic_reviews (topic_id, created_at DESC)`,
+		// Topic prerequisites
+		`CREATE TABLE IF NOT EXISTS topic_dependencies (
+			topic_id INTEGER REFERENCES topics(id) ON DELETE CASCADE,
+			prerequisite_id INTEGER REFERENCES topics(id) ON DELETE CASCADE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (topic_id, prerequisite_id),
+			CHECK (topic_id <> prerequisite_id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_topic_dependencies_prerequisite ON topic_dependencies (prerequisite_id)`,
 	}
 
 	for _, query := range queries {
@@ -99,7 +232,7 @@ func InitTables() error {
 		}
 	}
 
-	log.Println("✅ Database tables initialized successfully!")
+	slog.Info("Database tables initialized")
 	return nil
 }
 
@@ -133,7 +266,7 @@ func SeedSubjects() error {
 			if err != nil {
 				return err
 			}
-			log.Printf("✅ Added subject: %s", s.Name)
+			slog.Info("Added default subject", "subject", s.Name)
 		}
 	}
 
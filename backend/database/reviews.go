@@ -0,0 +1,125 @@
+package database
+
+import (
+	"context"
+	"exam-prep/models"
+	"strconv"
+)
+
+// A topic is weak when its latest confidence is at or below WeakConfidence,
+// or when its last RecentReviews reviews average below WeakConfidence
+const (
+	WeakConfidence = 2
+	RecentReviews  = 3
+)
+
+// recentConfidenceSQL averages a topic's last RecentReviews confidence scores
+var recentConfidenceSQL = `(
+	SELECT AVG(r.confidence)::FLOAT FROM (
+		SELECT confidence FROM topic_reviews
+		WHERE topic_id = t.id
+		ORDER BY created_at DESC, id DESC
+		LIMIT ` + strconv.Itoa(RecentReviews) + `
+	) r
+)`
+
+// RecordTopicReview logs a self-assessment for a topic and updates the
+// topic's confidence and derived is_weak flag in the same transaction. It
+// returns a nil review if the topic does not exist or is in the trash.
+func RecordTopicReview(ctx context.Context, topicID, confidence int, comment, reviewer string) (*models.TopicReview, error) {
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM topics WHERE id = $1 AND deleted_at IS NULL)", topicID).Scan(&exists)
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	review := &models.TopicReview{TopicID: topicID, Confidence: confidence, Reviewer: reviewer}
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO topic_reviews (topic_id, confidence, comment, reviewer)
+		VALUES ($1, $2, NULLIF($3, ''), $4)
+		RETURNING id, comment, created_at
+	`, topicID, confidence, comment, reviewer).Scan(&review.ID, &review.Comment, &review.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE topics t
+		SET confidence = $2,
+			is_weak = $2::INT <= $3::INT OR `+recentConfidenceSQL+` < $3::INT
+		WHERE t.id = $1
+	`, topicID, confidence, WeakConfidence)
+	if err != nil {
+		return nil, err
+	}
+
+	return review, tx.Commit()
+}
+
+// TopicReviews returns a topic's reviews, newest first
+func TopicReviews(ctx context.Context, topicID int) ([]models.TopicReview, error) {
+	rows, err := DB.QueryContext(ctx, `
+		SELECT id, topic_id, confidence, comment, reviewer, created_at
+		FROM topic_reviews
+		WHERE topic_id = $1
+		ORDER BY created_at DESC, id DESC
+	`, topicID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviews := []models.TopicReview{}
+	for rows.Next() {
+		var r models.TopicReview
+		if err := rows.Scan(&r.ID, &r.TopicID, &r.Confidence, &r.Comment, &r.Reviewer, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, r)
+	}
+	return reviews, rows.Err()
+}
+
+// WeakestTopics ranks live topics by their recent confidence, lowest first.
+// Topics never reviewed rank after reviewed ones unless they were flagged
+// weak by hand. subjectID 0 ranks topics from every subject.
+func WeakestTopics(ctx context.Context, subjectID, limit int, includeCompleted bool) ([]models.WeakTopic, error) {
+	rows, err := DB.QueryContext(ctx, `
+		SELECT id, name, subject_id, subject_name, is_completed, is_weak, confidence, recent, review_count, last_reviewed_at
+		FROM (
+			SELECT t.id, t.name, t.subject_id, s.name AS subject_name, t.is_completed, t.is_weak, t.confidence,
+				   `+recentConfidenceSQL+` AS recent,
+				   (SELECT COUNT(*) FROM topic_reviews WHERE topic_id = t.id) AS review_count,
+				   (SELECT MAX(created_at) FROM topic_reviews WHERE topic_id = t.id) AS last_reviewed_at
+			FROM topics t
+			JOIN subjects s ON s.id = t.subject_id
+			WHERE t.deleted_at IS NULL AND s.deleted_at IS NULL
+			  AND ($1 = 0 OR t.subject_id = $1)
+			  AND ($3 OR NOT t.is_completed)
+		) ranked
+		ORDER BY is_weak DESC, recent ASC NULLS LAST, last_reviewed_at ASC NULLS FIRST, id
+		LIMIT $2
+	`, subjectID, limit, includeCompleted)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	topics := []models.WeakTopic{}
+	for rows.Next() {
+		var t models.WeakTopic
+		err := rows.Scan(&t.TopicID, &t.TopicName, &t.SubjectID, &t.SubjectName, &t.IsCompleted, &t.IsWeak,
+			&t.Confidence, &t.RecentConfidence, &t.ReviewCount, &t.LastReviewedAt)
+		if err != nil {
+			return nil, err
+		}
+		topics = append(topics, t)
+	}
+	return topics, rows.Err()
+}
@@ -7,7 +7,9 @@ CREATE TABLE IF NOT EXISTS subjects (
     name VARCHAR(100) NOT NULL,
     description TEXT,
     color VARCHAR(7) DEFAULT '#3498db',
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    exam_date DATE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
 );
 
 -- Topics table (topics within each subject)
@@ -17,7 +19,9 @@ CREATE TABLE IF NOT EXISTS topics (
     name VARCHAR(200) NOT NULL,
     is_completed BOOLEAN DEFAULT FALSE,
     is_weak BOOLEAN DEFAULT FALSE,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    confidence SMALLINT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
 );
 
 -- Notes table (short revision notes)
@@ -28,7 +32,8 @@ CREATE TABLE IF NOT EXISTS notes (
     title VARCHAR(200) NOT NULL,
     content TEXT,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
 );
 
 -- Study plan table (daily study schedule)
@@ -36,12 +41,158 @@ CREATE TABLE IF NOT EXISTS study_plan (
     id SERIAL PRIMARY KEY,
     subject_id INTEGER REFERENCES subjects(id) ON DELETE CASCADE,
     study_date DATE NOT NULL,
+    start_time TIME,
+    end_time TIME,
     hours_planned DECIMAL(3,1) DEFAULT 1.0,
     hours_completed DECIMAL(3,1) DEFAULT 0.0,
     notes TEXT,
+    carried_over DECIMAL(3,1) DEFAULT 0.0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+-- Activity log (audit trail of every create/update/delete)
+CREATE TABLE IF NOT EXISTS activity_log (
+    id SERIAL PRIMARY KEY,
+    actor VARCHAR(100) NOT NULL,
+    entity_type VARCHAR(50) NOT NULL,
+    entity_id INTEGER NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    before_data JSONB,
+    after_data JSONB,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_activity_log_entity ON activity_log (entity_type, entity_id);
+CREATE INDEX IF NOT EXISTS idx_activity_log_created_at ON activity_log (created_at DESC);
+
+-- Outgoing webhook subscriptions
+CREATE TABLE IF NOT EXISTS webhooks (
+    id SERIAL PRIMARY KEY,
+    url TEXT NOT NULL,
+    secret VARCHAR(100) NOT NULL,
+    event_types TEXT[] NOT NULL DEFAULT '{}',
+    active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Webhook delivery queue and log
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id SERIAL PRIMARY KEY,
+    webhook_id INTEGER REFERENCES webhooks(id) ON DELETE CASCADE,
+    event_type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_status_code INTEGER,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+
+-- Reminder rules (per user)
+CREATE TABLE IF NOT EXISTS reminder_rules (
+    id SERIAL PRIMARY KEY,
+    user_name VARCHAR(100) NOT NULL,
+    rule_type VARCHAR(30) NOT NULL,
+    channel VARCHAR(20) NOT NULL,
+    target TEXT,
+    send_hour INTEGER NOT NULL DEFAULT 20,
+    thresholds INTEGER[] NOT NULL DEFAULT '{}',
+    enabled BOOLEAN DEFAULT TRUE,
+    last_sent_on DATE,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
+-- Per-user timezone and locale
+CREATE TABLE IF NOT EXISTS user_settings (
+    user_name VARCHAR(100) PRIMARY KEY,
+    timezone VARCHAR(64) NOT NULL,
+    locale VARCHAR(35) NOT NULL DEFAULT 'en',
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- In-app notification inbox
+CREATE TABLE IF NOT EXISTS notifications (
+    id SERIAL PRIMARY KEY,
+    user_name VARCHAR(100) NOT NULL,
+    kind VARCHAR(30) NOT NULL,
+    title VARCHAR(200) NOT NULL,
+    body TEXT,
+    is_read BOOLEAN DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Missed-session rollover runs and the hours each one moved
+CREATE TABLE IF NOT EXISTS plan_rebalances (
+    id SERIAL PRIMARY KEY,
+    actor VARCHAR(100) NOT NULL,
+    daily_cap DECIMAL(3,1) NOT NULL,
+    unplaced_hours DECIMAL(5,1) NOT NULL DEFAULT 0.0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    reverted_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS plan_rebalance_moves (
+    id SERIAL PRIMARY KEY,
+    rebalance_id INTEGER REFERENCES plan_rebalances(id) ON DELETE CASCADE,
+    source_plan_id INTEGER REFERENCES study_plan(id) ON DELETE CASCADE,
+    target_plan_id INTEGER REFERENCES study_plan(id) ON DELETE CASCADE,
+    hours DECIMAL(3,1) NOT NULL,
+    created_target BOOLEAN DEFAULT FALSE
+);
+
+-- Recurring plan templates (weekly timetable)
+CREATE TABLE IF NOT EXISTS plan_templates (
+    id SERIAL PRIMARY KEY,
+    subject_id INTEGER REFERENCES subjects(id) ON DELETE CASCADE,
+    rrule TEXT NOT NULL,
+    hours_planned DECIMAL(3,1) DEFAULT 1.0,
+    notes TEXT,
+    start_date DATE NOT NULL,
+    end_date DATE,
+    exceptions DATE[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE study_plan ADD COLUMN IF NOT EXISTS template_id INTEGER REFERENCES plan_templates(id) ON DELETE SET NULL;
+
+-- Weekly progress reports saved by the report job
+CREATE TABLE IF NOT EXISTS weekly_reports (
+    id SERIAL PRIMARY KEY,
+    week_start DATE NOT NULL UNIQUE,
+    week_end DATE NOT NULL,
+    data JSONB NOT NULL,
+    emailed_to VARCHAR(200),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Topic self-assessments (confidence 1-5); topics.is_weak is derived from these
+CREATE TABLE IF NOT EXISTS topic_reviews (
+    id SERIAL PRIMARY KEY,
+    topic_id INTEGER REFERENCES topics(id) ON DELETE CASCADE,
+    confidence SMALLINT NOT NULL CHECK (confidence BETWEEN 1 AND 5),
+    comment TEXT,
+    reviewer VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_topic_reviews_topic ON topic_reviews (topic_id, created_at DESC);
+
+-- Topic prerequisites (topic_id is studied after prerequisite_id)
+CREATE TABLE IF NOT EXISTS topic_dependencies (
+    topic_id INTEGER REFERENCES topics(id) ON DELETE CASCADE,
+    prerequisite_id INTEGER REFERENCES topics(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (topic_id, prerequisite_id),
+    CHECK (topic_id <> prerequisite_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_topic_dependencies_prerequisite ON topic_dependencies (prerequisite_id);
+
 -- Insert default subjects for Semester 8
 INSERT INTO subjects (name, description, color) VALUES
     ('Flutter', 'Mobile app development with Flutter framework', '#02569B'),
@@ -0,0 +1,110 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// trashChildTables are the tables that follow their subject into the trash
+var trashChildTables = []string{"topics", "notes", "study_plan"}
+
+// SoftDeleteSubject marks a subject and all of its topics, notes and study
+// plan entries as deleted. CURRENT_TIMESTAMP is fixed for the transaction, so
+// every row shares the subject's deleted_at and the cascade can be restored
+// together. It reports false if the subject does not exist or is already in
+// the trash.
+func SoftDeleteSubject(ctx context.Context, id int) (bool, error) {
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	found, err := SoftDeleteSubjectIn(ctx, tx, id)
+	if err != nil || !found {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// SoftDeleteSubjectIn is SoftDeleteSubject inside the caller's transaction
+func SoftDeleteSubjectIn(ctx context.Context, tx *sql.Tx, id int) (bool, error) {
+	result, err := tx.ExecContext(ctx, "UPDATE subjects SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NULL", id)
+	if err != nil {
+		return false, err
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return false, nil
+	}
+
+	for _, table := range trashChildTables {
+		_, err = tx.ExecContext(ctx, "UPDATE "+table+" SET deleted_at = CURRENT_TIMESTAMP WHERE subject_id = $1 AND deleted_at IS NULL", id)
+		if err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// RestoreSubject brings a subject back from the trash together with the
+// topics, notes and study plan entries that were deleted along with it.
+// Children that were trashed on their own before the subject stay in the trash.
+func RestoreSubject(ctx context.Context, id int) (bool, error) {
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var deletedAt time.Time
+	err = tx.QueryRowContext(ctx, "SELECT deleted_at FROM subjects WHERE id = $1 AND deleted_at IS NOT NULL FOR UPDATE", id).Scan(&deletedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	for _, table := range trashChildTables {
+		_, err = tx.ExecContext(ctx,
+			"UPDATE "+table+" SET deleted_at = NULL WHERE subject_id = $1 AND deleted_at = (SELECT deleted_at FROM subjects WHERE id = $1)",
+			id,
+		)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if _, err = tx.ExecContext(ctx, "UPDATE subjects SET deleted_at = NULL WHERE id = $1", id); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// PurgeTrash permanently deletes rows that have been in the trash for longer
+// than the retention period and returns how many rows were removed.
+func PurgeTrash(ctx context.Context, retention time.Duration) (int64, error) {
+	var total int64
+	// Children first so their counts are not hidden by ON DELETE CASCADE
+	for _, table := range []string{"notes", "study_plan", "topics", "subjects"} {
+		result, err := DB.ExecContext(ctx,
+			"DELETE FROM "+table+" WHERE deleted_at < CURRENT_TIMESTAMP - $1 * INTERVAL '1 second'",
+			int64(retention.Seconds()),
+		)
+		if err != nil {
+			return total, fmt.Errorf("error purging %s: %v", table, err)
+		}
+		rowsAffected, _ := result.RowsAffected()
+		total += rowsAffected
+	}
+
+	if total > 0 {
+		slog.Info("Purged trash", "items", total)
+	}
+	return total, nil
+}
@@ -0,0 +1,41 @@
+package docs
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Drift compares the registered API routes with Operations and describes
+// every route that is missing from the spec or documented but not
+// registered. An empty result means the spec covers the router exactly.
+func Drift(routes gin.RoutesInfo) []string {
+	registered := map[string]bool{}
+	for _, route := range routes {
+		if strings.HasPrefix(route.Path, "/api/") {
+			registered[route.Method+" "+route.Path] = true
+		}
+	}
+
+	documented := map[string]bool{}
+	var problems []string
+	for _, op := range Operations {
+		key := op.Method + " " + op.Path
+		if documented[key] {
+			problems = append(problems, "documented twice: "+key)
+		}
+		documented[key] = true
+		if !registered[key] {
+			problems = append(problems, "documented but not registered: "+key)
+		}
+	}
+	for key := range registered {
+		if !documented[key] {
+			problems = append(problems, "registered but not documented: "+key)
+		}
+	}
+
+	sort.Strings(problems)
+	return problems
+}
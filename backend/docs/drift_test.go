@@ -0,0 +1,21 @@
+package docs_test
+
+import (
+	"exam-prep/docs"
+	"exam-prep/routes"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestNoDrift keeps the OpenAPI document in step with the router
+func TestNoDrift(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	routes.SetupRoutes(r)
+
+	if problems := docs.Drift(r.Routes()); len(problems) > 0 {
+		t.Errorf("OpenAPI spec is out of date with the routes:\n  %s", strings.Join(problems, "\n  "))
+	}
+}
@@ -0,0 +1,34 @@
+package docs
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ServeSpec returns the OpenAPI document as JSON
+func ServeSpec(c *gin.Context) {
+	c.JSON(http.StatusOK, Spec())
+}
+
+// ServePage returns an interactive Swagger UI page for the OpenAPI document
+func ServePage(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(page))
+}
+
+const page = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Exam Preparation System API</title>
+<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+<script>
+window.ui = SwaggerUIBundle({ url: "/api/docs/openapi.json", dom_id: "#swagger-ui" });
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,311 @@
+package docs
+
+import (
+	"encoding/json"
+	"exam-prep/utils"
+	"net/http"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	specOnce sync.Once
+	spec     map[string]interface{}
+)
+
+// Spec returns the OpenAPI 3 document built from Operations. Request and
+// response schemas are derived from the model structs' json and binding tags.
+func Spec() map[string]interface{} {
+	specOnce.Do(func() {
+		spec = build(Operations)
+	})
+	return spec
+}
+
+// build assembles the OpenAPI document for a list of operations
+func build(operations []Operation) map[string]interface{} {
+	s := &schemas{components: map[string]interface{}{}, types: map[string]reflect.Type{}}
+	envelope := s.of(reflect.TypeOf(utils.Response{}))
+
+	paths := map[string]map[string]interface{}{}
+	for _, op := range operations {
+		path, parameters := pathParameters(op.Path)
+		for _, p := range op.Query {
+			parameters = append(parameters, map[string]interface{}{
+				"name":        p.Name,
+				"in":          "query",
+				"description": p.Description,
+				"schema":      map[string]interface{}{"type": p.Type},
+			})
+		}
+		parameters = append(parameters,
+			map[string]interface{}{"$ref": "#/components/parameters/User"},
+			map[string]interface{}{"$ref": "#/components/parameters/Timezone"},
+		)
+
+		status := op.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		var content map[string]interface{}
+		errorContent := jsonContent(envelope)
+		switch {
+		case op.ContentType != "":
+			schema := map[string]interface{}{"type": "string"}
+			if op.Data != nil {
+				// The response describes its own errors too
+				schema = s.of(reflect.TypeOf(op.Data))
+				errorContent = map[string]interface{}{op.ContentType: map[string]interface{}{"schema": schema}}
+			}
+			content = map[string]interface{}{op.ContentType: map[string]interface{}{"schema": schema}}
+		case op.Data != nil:
+			content = jsonContent(map[string]interface{}{"allOf": []interface{}{
+				envelope,
+				map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{"data": s.of(reflect.TypeOf(op.Data))},
+				},
+			}})
+		default:
+			content = jsonContent(envelope)
+		}
+
+		operation := map[string]interface{}{
+			"tags":       []string{op.Tag},
+			"summary":    op.Summary,
+			"parameters": parameters,
+			"responses": map[string]interface{}{
+				strconv.Itoa(status): map[string]interface{}{"description": http.StatusText(status), "content": content},
+				"default":            map[string]interface{}{"description": "Error", "content": errorContent},
+			},
+		}
+		if op.Body != nil {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content":  jsonContent(s.of(reflect.TypeOf(op.Body))),
+			}
+		}
+		if op.Method == http.MethodPatch {
+			// Merge patches describe a change to the schema, so any member may
+			// be left out and null clears a field
+			operation["requestBody"] = map[string]interface{}{
+				"required":    true,
+				"description": "A JSON merge patch (RFC 7396) of the schema: absent members are unchanged, null clears a field.",
+				"content": map[string]interface{}{
+					utils.MergePatchType: map[string]interface{}{"schema": s.of(reflect.TypeOf(op.Body))},
+				},
+			}
+		}
+
+		if paths[path] == nil {
+			paths[path] = map[string]interface{}{}
+		}
+		paths[path][strings.ToLower(op.Method)] = operation
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "Exam Preparation System API",
+			"version":     "1.0.0",
+			"description": "Every JSON response is wrapped in the Response envelope; the payload is in data.",
+		},
+		"servers": []interface{}{map[string]interface{}{"url": "/"}},
+		"paths":   paths,
+		"components": map[string]interface{}{
+			"schemas": s.components,
+			"parameters": map[string]interface{}{
+				"User": map[string]interface{}{
+					"name": "X-User", "in": "header", "schema": map[string]interface{}{"type": "string"},
+					"description": "Who is making the change; defaults to anonymous",
+				},
+				"Timezone": map[string]interface{}{
+					"name": "X-Timezone", "in": "header", "schema": map[string]interface{}{"type": "string"},
+					"description": "IANA timezone for date calculations, e.g. Asia/Kolkata",
+				},
+			},
+		},
+	}
+}
+
+// pathParameters converts a gin path to OpenAPI form and describes its
+// path parameters, which are all numeric IDs
+func pathParameters(path string) (string, []interface{}) {
+	parameters := []interface{}{}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, ":") {
+			continue
+		}
+		name := segment[1:]
+		segments[i] = "{" + name + "}"
+		parameters = append(parameters, map[string]interface{}{
+			"name":     name,
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]interface{}{"type": "integer"},
+		})
+	}
+	return strings.Join(segments, "/"), parameters
+}
+
+func jsonContent(schema interface{}) map[string]interface{} {
+	return map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}}
+}
+
+// schemas converts Go types to OpenAPI schemas, collecting named structs as
+// reusable components
+type schemas struct {
+	components map[string]interface{}
+	types      map[string]reflect.Type
+}
+
+// name is a struct's component name. A struct whose name is taken by one
+// from another package, such as graphql.Response next to utils.Response, is
+// prefixed with its package name.
+func (s *schemas) name(t reflect.Type) string {
+	name := t.Name()
+	if taken, ok := s.types[name]; ok && taken != t {
+		pkg := path.Base(t.PkgPath())
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+	s.types[name] = t
+	return name
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	rawType  = reflect.TypeOf(json.RawMessage{})
+)
+
+func (s *schemas) of(t reflect.Type) map[string]interface{} {
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == rawType:
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		inner := s.of(t.Elem())
+		if _, ok := inner["$ref"]; ok {
+			return map[string]interface{}{"allOf": []interface{}{inner}, "nullable": true}
+		}
+		inner["nullable"] = true
+		return inner
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		name := s.name(t)
+		if _, ok := s.components[name]; !ok {
+			s.components[name] = map[string]interface{}{}
+			s.components[name] = s.object(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": s.of(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": s.of(t.Elem())}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	}
+	return map[string]interface{}{}
+}
+
+// object describes a struct's exported fields, following encoding/json's
+// naming and flattening of embedded structs
+func (s *schemas) object(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	var required []string
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			embedded := s.object(field.Type)
+			for key, value := range embedded["properties"].(map[string]interface{}) {
+				properties[key] = value
+			}
+			if names, ok := embedded["required"].([]string); ok {
+				required = append(required, names...)
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema := s.of(field.Type)
+		for _, rule := range strings.Split(field.Tag.Get("binding"), ",") {
+			key, value, _ := strings.Cut(rule, "=")
+			if key == "dive" {
+				break
+			}
+			switch key {
+			case "required":
+				required = append(required, name)
+			case "min", "max", "gte", "lte", "gt":
+				schema = withBound(schema, field.Type, key, value)
+			case "oneof":
+				schema["enum"] = strings.Fields(value)
+			case "date":
+				schema["format"] = "date"
+			case "color", "clock", "date_or_empty":
+				schema["pattern"] = patterns[key]
+			}
+		}
+		properties[name] = schema
+	}
+
+	object := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		object["required"] = required
+	}
+	return object
+}
+
+// patterns are the regular expressions behind the custom string rules
+// registered by utils.SetupValidator
+var patterns = map[string]string{
+	"color":         `^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`,
+	"clock":         `^(([01][0-9]|2[0-3]):[0-5][0-9](:[0-5][0-9])?)?$`,
+	"date_or_empty": `^([0-9]{4}-[0-9]{2}-[0-9]{2})?$`,
+}
+
+// withBound applies a binding min/max/gte/lte/gt rule as the matching schema keyword
+func withBound(schema map[string]interface{}, t reflect.Type, key, value string) map[string]interface{} {
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return schema
+	}
+	keyword := map[string]string{"min": "minimum", "max": "maximum", "gte": "minimum", "lte": "maximum", "gt": "exclusiveMinimum"}[key]
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		keyword = map[string]string{"min": "minLength", "max": "maxLength"}[key]
+	case reflect.Slice, reflect.Array:
+		keyword = map[string]string{"min": "minItems", "max": "maxItems"}[key]
+	}
+	schema[keyword] = n
+	return schema
+}
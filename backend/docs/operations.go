@@ -0,0 +1,217 @@
+package docs
+
+import (
+	"exam-prep/graphql"
+	"exam-prep/models"
+	"net/http"
+)
+
+// Operation documents one route. Path uses gin's :param syntax so it can be
+// compared with the registered routes directly. Body and Data are zero values
+// of the request input and the response envelope's data; nil means none.
+// ContentType marks a response sent without the envelope, described by Data
+// if set and as a string otherwise.
+type Operation struct {
+	Method      string
+	Path        string
+	Tag         string
+	Summary     string
+	Status      int
+	Query       []Param
+	Body        interface{}
+	Data        interface{}
+	ContentType string
+}
+
+// Param is a documented query parameter
+type Param struct {
+	Name        string
+	Type        string
+	Description string
+}
+
+// Response data that handlers build inline with gin.H
+type (
+	createdID struct {
+		ID int `json:"id"`
+	}
+	templateCreated struct {
+		ID             int `json:"id"`
+		CreatedEntries int `json:"created_entries"`
+	}
+	templateUpdated struct {
+		StudyPlanID int `json:"study_plan_id,omitempty"`
+		TemplateID  int `json:"template_id,omitempty"`
+	}
+	templateExpanded struct {
+		CreatedEntries int `json:"created_entries"`
+	}
+	webhookCreated struct {
+		ID     int    `json:"id"`
+		Secret string `json:"secret"`
+	}
+	prerequisiteWarning struct {
+		Warnings                []string       `json:"warnings"`
+		IncompletePrerequisites []models.Topic `json:"incomplete_prerequisites"`
+	}
+)
+
+func str(name, description string) Param  { return Param{name, "string", description} }
+func num(name, description string) Param  { return Param{name, "integer", description} }
+func flag(name, description string) Param { return Param{name, "boolean", description} }
+
+var (
+	fromParam = str("from", "Start date (YYYY-MM-DD)")
+	toParam   = str("to", "End date (YYYY-MM-DD)")
+
+	// Paged lists under /api/v2
+	pageParams = []Param{num("page", "Page number from 1, default 1"), num("per_page", "1-200, default 50")}
+)
+
+// Operations lists every route in routes.SetupRoutes
+var Operations = []Operation{
+	// Dashboard
+	{Method: http.MethodGet, Path: "/api/dashboard", Tag: "Dashboard", Summary: "Dashboard summary", Data: models.DashboardData{}},
+	{Method: http.MethodGet, Path: "/api/progress", Tag: "Dashboard", Summary: "Topic progress per subject", Data: []models.SubjectProgressItem{}},
+	{Method: http.MethodGet, Path: "/api/analytics/time", Tag: "Dashboard", Summary: "Planned vs completed hours by subject, ISO week and weekday",
+		Query: []Param{fromParam, toParam, num("subject_id", "Only this subject")}, Data: models.TimeAnalytics{}},
+
+	// Reports
+	{Method: http.MethodGet, Path: "/api/reports", Tag: "Reports", Summary: "Saved weekly reports", Data: []models.StoredReport{}},
+	{Method: http.MethodGet, Path: "/api/reports/weekly", Tag: "Reports", Summary: "Render the weekly progress report",
+		Query: []Param{str("format", "html (default), md or pdf"), str("week", "Any date in the week (YYYY-MM-DD)")}, ContentType: "text/html"},
+	{Method: http.MethodGet, Path: "/api/reports/:id", Tag: "Reports", Summary: "Saved weekly report",
+		Query: []Param{str("format", "Render as html, md or pdf instead of JSON")}, Data: models.StoredReport{}},
+
+	// Subjects
+	{Method: http.MethodGet, Path: "/api/subjects", Tag: "Subjects", Summary: "List subjects with progress", Data: []models.SubjectWithProgress{}},
+	{Method: http.MethodGet, Path: "/api/subjects/:id", Tag: "Subjects", Summary: "Get a subject", Data: models.SubjectWithProgress{}},
+	{Method: http.MethodPost, Path: "/api/subjects", Tag: "Subjects", Summary: "Create a subject", Status: http.StatusCreated, Body: models.CreateSubjectInput{}, Data: createdID{}},
+	{Method: http.MethodPut, Path: "/api/subjects/:id", Tag: "Subjects", Summary: "Update a subject", Body: models.UpdateSubjectInput{}},
+	{Method: http.MethodPatch, Path: "/api/subjects/:id", Tag: "Subjects", Summary: "Patch a subject", Body: models.SubjectPatch{}, Data: models.SubjectPatch{}},
+	{Method: http.MethodDelete, Path: "/api/subjects/:id", Tag: "Subjects", Summary: "Move a subject and its children to the trash"},
+	{Method: http.MethodPut, Path: "/api/subjects/:id/restore", Tag: "Trash", Summary: "Restore a subject from the trash"},
+
+	// Topics
+	{Method: http.MethodGet, Path: "/api/subjects/:id/topics", Tag: "Topics", Summary: "List a subject's topics", Data: []models.Topic{}},
+	{Method: http.MethodGet, Path: "/api/subjects/:id/topics/order", Tag: "Topics", Summary: "Topics in dependency-aware study order", Data: []models.OrderedTopic{}},
+	{Method: http.MethodPost, Path: "/api/topics", Tag: "Topics", Summary: "Create a topic", Status: http.StatusCreated, Body: models.CreateTopicInput{}, Data: createdID{}},
+	{Method: http.MethodPut, Path: "/api/topics/:id", Tag: "Topics", Summary: "Update a topic", Body: models.UpdateTopicInput{}, Data: prerequisiteWarning{}},
+	{Method: http.MethodPatch, Path: "/api/topics/:id", Tag: "Topics", Summary: "Patch a topic", Body: models.TopicPatch{}, Data: models.TopicPatch{}},
+	{Method: http.MethodPut, Path: "/api/topics/:id/complete", Tag: "Topics", Summary: "Toggle topic completion", Data: prerequisiteWarning{}},
+	{Method: http.MethodPut, Path: "/api/topics/:id/weak", Tag: "Topics", Summary: "Toggle the weak flag"},
+	{Method: http.MethodGet, Path: "/api/topics/weakest", Tag: "Topics", Summary: "Topics ranked by recent confidence",
+		Query: []Param{num("subject_id", "Only this subject"), num("limit", "1-100, default 10"), flag("include_completed", "Include completed topics")}, Data: []models.WeakTopic{}},
+	{Method: http.MethodGet, Path: "/api/topics/:id/history", Tag: "Topics", Summary: "Confidence review history", Data: models.TopicHistory{}},
+	{Method: http.MethodPost, Path: "/api/topics/:id/reviews", Tag: "Topics", Summary: "Record a confidence review", Status: http.StatusCreated, Body: models.CreateTopicReviewInput{}, Data: models.TopicReview{}},
+	{Method: http.MethodGet, Path: "/api/topics/:id/dependencies", Tag: "Topics", Summary: "List a topic's prerequisites", Data: []models.Topic{}},
+	{Method: http.MethodPost, Path: "/api/topics/:id/dependencies", Tag: "Topics", Summary: "Add a prerequisite", Status: http.StatusCreated, Body: models.CreateTopicDependencyInput{}, Data: models.TopicDependency{}},
+	{Method: http.MethodDelete, Path: "/api/topics/:id/dependencies/:prerequisiteId", Tag: "Topics", Summary: "Remove a prerequisite"},
+	{Method: http.MethodDelete, Path: "/api/topics/:id", Tag: "Topics", Summary: "Move a topic to the trash"},
+	{Method: http.MethodPut, Path: "/api/topics/:id/restore", Tag: "Trash", Summary: "Restore a topic from the trash"},
+
+	// Notes
+	{Method: http.MethodGet, Path: "/api/notes", Tag: "Notes", Summary: "List notes", Data: []models.Note{}},
+	{Method: http.MethodGet, Path: "/api/subjects/:id/notes", Tag: "Notes", Summary: "List a subject's notes", Data: []models.Note{}},
+	{Method: http.MethodPost, Path: "/api/notes", Tag: "Notes", Summary: "Create a note", Status: http.StatusCreated, Body: models.CreateNoteInput{}, Data: createdID{}},
+	{Method: http.MethodPut, Path: "/api/notes/:id", Tag: "Notes", Summary: "Update a note", Body: models.UpdateNoteInput{}},
+	{Method: http.MethodPatch, Path: "/api/notes/:id", Tag: "Notes", Summary: "Patch a note", Body: models.NotePatch{}, Data: models.NotePatch{}},
+	{Method: http.MethodDelete, Path: "/api/notes/:id", Tag: "Notes", Summary: "Move a note to the trash"},
+	{Method: http.MethodPut, Path: "/api/notes/:id/restore", Tag: "Trash", Summary: "Restore a note from the trash"},
+
+	// Study plan
+	{Method: http.MethodGet, Path: "/api/study-plan", Tag: "Study plan", Summary: "List study plan entries", Data: []models.StudyPlan{}},
+	{Method: http.MethodGet, Path: "/api/study-plan/today", Tag: "Study plan", Summary: "Today's entries", Data: []models.StudyPlan{}},
+	{Method: http.MethodGet, Path: "/api/study-plan/issues", Tag: "Study plan", Summary: "Problems in the plan", Data: []models.PlanIssue{}},
+	{Method: http.MethodGet, Path: "/api/study-plan/day", Tag: "Study plan", Summary: "Time-ordered view of one day",
+		Query: []Param{str("date", "Day to show (YYYY-MM-DD), default today")}, Data: models.DayView{}},
+	{Method: http.MethodPost, Path: "/api/study-plan", Tag: "Study plan", Summary: "Create an entry", Status: http.StatusCreated, Body: models.CreateStudyPlanInput{}, Data: createdID{}},
+	{Method: http.MethodPut, Path: "/api/study-plan/:id", Tag: "Study plan", Summary: "Update an entry", Body: models.UpdateStudyPlanInput{}},
+	{Method: http.MethodPatch, Path: "/api/study-plan/:id", Tag: "Study plan", Summary: "Patch an entry", Body: models.StudyPlanPatch{}, Data: models.StudyPlanPatch{}},
+	{Method: http.MethodDelete, Path: "/api/study-plan/:id", Tag: "Study plan", Summary: "Move an entry to the trash"},
+	{Method: http.MethodPut, Path: "/api/study-plan/:id/restore", Tag: "Trash", Summary: "Restore an entry from the trash"},
+	{Method: http.MethodPost, Path: "/api/study-plan/generate", Tag: "Study plan", Summary: "Schedule a subject's topics in dependency order", Body: models.GenerateStudyPlanInput{}, Data: models.GeneratePlanResult{}},
+	{Method: http.MethodPost, Path: "/api/study-plan/rebalance", Tag: "Study plan", Summary: "Carry missed hours forward", Body: models.RebalanceInput{}, Data: models.Rebalance{}},
+	{Method: http.MethodGet, Path: "/api/study-plan/rebalances", Tag: "Study plan", Summary: "Recent rebalance runs", Data: []models.Rebalance{}},
+	{Method: http.MethodPost, Path: "/api/study-plan/rebalances/:id/revert", Tag: "Study plan", Summary: "Undo a rebalance run"},
+	{Method: http.MethodPost, Path: "/api/study-plan/bulk", Tag: "Study plan", Summary: "Create several entries at once", Body: models.BatchCreateStudyPlanInput{}, Data: models.BulkResult{}},
+	{Method: http.MethodPost, Path: "/api/study-plan/bulk/shift", Tag: "Study plan", Summary: "Shift entries in a date range", Body: models.ShiftStudyPlanInput{}, Data: models.BulkResult{}},
+	{Method: http.MethodPost, Path: "/api/study-plan/bulk/copy-week", Tag: "Study plan", Summary: "Copy one week onto another", Body: models.CopyWeekInput{}, Data: models.BulkResult{}},
+	{Method: http.MethodPost, Path: "/api/study-plan/bulk/delete", Tag: "Study plan", Summary: "Move entries in a date range to the trash", Body: models.DateRangeInput{}, Data: models.BulkResult{}},
+
+	// Plan templates
+	{Method: http.MethodGet, Path: "/api/plan-templates", Tag: "Plan templates", Summary: "List recurring templates", Data: []models.PlanTemplate{}},
+	{Method: http.MethodGet, Path: "/api/plan-templates/:id", Tag: "Plan templates", Summary: "Get a template", Data: models.PlanTemplate{}},
+	{Method: http.MethodPost, Path: "/api/plan-templates", Tag: "Plan templates", Summary: "Create a template and expand it", Status: http.StatusCreated, Body: models.CreatePlanTemplateInput{}, Data: templateCreated{}},
+	{Method: http.MethodPut, Path: "/api/plan-templates/:id", Tag: "Plan templates", Summary: "Edit one occurrence or all future ones", Body: models.UpdatePlanTemplateInput{}, Data: templateUpdated{}},
+	{Method: http.MethodDelete, Path: "/api/plan-templates/:id", Tag: "Plan templates", Summary: "Delete a template and trash its future entries"},
+	{Method: http.MethodPost, Path: "/api/plan-templates/:id/expand", Tag: "Plan templates", Summary: "Materialize occurrences", Body: models.ExpandPlanTemplateInput{}, Data: templateExpanded{}},
+
+	// Trash and activity
+	{Method: http.MethodGet, Path: "/api/trash", Tag: "Trash", Summary: "List trashed items", Data: []models.TrashItem{}},
+	{Method: http.MethodGet, Path: "/api/activity", Tag: "Activity", Summary: "Audit log",
+		Query: []Param{str("entity_type", "subject, topic, note, ..."), num("entity_id", "Entity ID"), str("action", "create, update, delete, ..."),
+			str("actor", "X-User of the change"), fromParam, toParam, num("limit", "Maximum entries")}, Data: []models.Activity{}},
+	{Method: http.MethodGet, Path: "/api/events", Tag: "Activity", Summary: "Server-Sent Events stream of changes",
+		Query: []Param{num("subject_id", "Only this subject"), str("actor", "Only this actor"), str("types", "Comma separated event types"),
+			num("last_event_id", "Resume after this event")}, ContentType: "text/event-stream"},
+
+	// Webhooks
+	{Method: http.MethodGet, Path: "/api/webhooks", Tag: "Webhooks", Summary: "List webhooks", Data: []models.Webhook{}},
+	{Method: http.MethodPost, Path: "/api/webhooks", Tag: "Webhooks", Summary: "Create a webhook", Status: http.StatusCreated, Body: models.CreateWebhookInput{}, Data: webhookCreated{}},
+	{Method: http.MethodPut, Path: "/api/webhooks/:id", Tag: "Webhooks", Summary: "Update a webhook", Body: models.UpdateWebhookInput{}},
+	{Method: http.MethodDelete, Path: "/api/webhooks/:id", Tag: "Webhooks", Summary: "Delete a webhook"},
+	{Method: http.MethodGet, Path: "/api/webhooks/:id/deliveries", Tag: "Webhooks", Summary: "Delivery log",
+		Query: []Param{num("limit", "1-500, default 50")}, Data: []models.WebhookDelivery{}},
+	{Method: http.MethodPost, Path: "/api/webhooks/:id/test", Tag: "Webhooks", Summary: "Send a test delivery", Data: models.WebhookDelivery{}},
+
+	// Settings, reminders and notifications
+	{Method: http.MethodGet, Path: "/api/settings", Tag: "Settings", Summary: "Current user's settings", Data: models.UserSettings{}},
+	{Method: http.MethodPut, Path: "/api/settings", Tag: "Settings", Summary: "Update the current user's settings", Body: models.UpdateUserSettingsInput{}},
+	{Method: http.MethodGet, Path: "/api/reminders", Tag: "Reminders", Summary: "List the current user's reminders", Data: []models.ReminderRule{}},
+	{Method: http.MethodPost, Path: "/api/reminders", Tag: "Reminders", Summary: "Create a reminder", Status: http.StatusCreated, Body: models.CreateReminderRuleInput{}, Data: createdID{}},
+	{Method: http.MethodPut, Path: "/api/reminders/:id", Tag: "Reminders", Summary: "Update a reminder", Body: models.UpdateReminderRuleInput{}},
+	{Method: http.MethodDelete, Path: "/api/reminders/:id", Tag: "Reminders", Summary: "Delete a reminder"},
+	{Method: http.MethodGet, Path: "/api/notifications", Tag: "Reminders", Summary: "In-app notifications",
+		Query: []Param{flag("unread", "Only unread notifications")}, Data: []models.Notification{}},
+	{Method: http.MethodPut, Path: "/api/notifications/:id/read", Tag: "Reminders", Summary: "Mark a notification as read"},
+
+	// Batch
+	{Method: http.MethodPost, Path: "/api/batch", Tag: "Batch", Summary: "Run several creates, updates and deletes in one transaction", Body: models.BatchInput{}, Data: models.BatchResponse{}},
+
+	// GraphQL
+	{Method: http.MethodGet, Path: "/api/graphql", Tag: "GraphQL", Summary: "Run a GraphQL query", ContentType: "application/json",
+		Query: []Param{str("query", "GraphQL document"), str("variables", "JSON object of variables"), str("operationName", "Operation to run")}, Data: graphql.Response{}},
+	{Method: http.MethodPost, Path: "/api/graphql", Tag: "GraphQL", Summary: "Run a GraphQL query or mutation",
+		Body: graphql.Request{}, Data: graphql.Response{}, ContentType: "application/json"},
+	{Method: http.MethodGet, Path: "/api/graphql/schema", Tag: "GraphQL", Summary: "GraphQL schema in SDL", ContentType: "text/plain"},
+
+	// Version 2: writes respond with the resource, lists are paged
+	{Method: http.MethodGet, Path: "/api/v2/subjects", Tag: "v2", Summary: "Page of subjects with progress", Query: pageParams, Data: []models.SubjectWithProgress{}},
+	{Method: http.MethodGet, Path: "/api/v2/subjects/:id", Tag: "v2", Summary: "Get a subject", Data: models.SubjectWithProgress{}},
+	{Method: http.MethodPost, Path: "/api/v2/subjects", Tag: "v2", Summary: "Create a subject", Status: http.StatusCreated, Body: models.CreateSubjectInput{}, Data: models.SubjectWithProgress{}},
+	{Method: http.MethodPatch, Path: "/api/v2/subjects/:id", Tag: "v2", Summary: "Patch a subject", Body: models.SubjectPatch{}, Data: models.SubjectWithProgress{}},
+	{Method: http.MethodDelete, Path: "/api/v2/subjects/:id", Tag: "v2", Summary: "Move a subject and its children to the trash", Data: models.SubjectWithProgress{}},
+	{Method: http.MethodGet, Path: "/api/v2/topics", Tag: "v2", Summary: "Page of topics",
+		Query: append([]Param{num("subject_id", "Only this subject"), flag("completed", "Only completed or incomplete topics"), flag("weak", "Only weak or other topics")}, pageParams...), Data: []models.Topic{}},
+	{Method: http.MethodGet, Path: "/api/v2/topics/:id", Tag: "v2", Summary: "Get a topic", Data: models.Topic{}},
+	{Method: http.MethodPost, Path: "/api/v2/topics", Tag: "v2", Summary: "Create a topic", Status: http.StatusCreated, Body: models.CreateTopicInput{}, Data: models.Topic{}},
+	{Method: http.MethodPatch, Path: "/api/v2/topics/:id", Tag: "v2", Summary: "Patch a topic", Body: models.TopicPatch{}, Data: models.Topic{}},
+	{Method: http.MethodDelete, Path: "/api/v2/topics/:id", Tag: "v2", Summary: "Move a topic to the trash", Data: models.Topic{}},
+	{Method: http.MethodGet, Path: "/api/v2/notes", Tag: "v2", Summary: "Page of notes, most recently updated first",
+		Query: append([]Param{num("subject_id", "Only this subject"), num("topic_id", "Only this topic")}, pageParams...), Data: []models.Note{}},
+	{Method: http.MethodGet, Path: "/api/v2/notes/:id", Tag: "v2", Summary: "Get a note", Data: models.Note{}},
+	{Method: http.MethodPost, Path: "/api/v2/notes", Tag: "v2", Summary: "Create a note", Status: http.StatusCreated, Body: models.CreateNoteInput{}, Data: models.Note{}},
+	{Method: http.MethodPatch, Path: "/api/v2/notes/:id", Tag: "v2", Summary: "Patch a note", Body: models.NotePatch{}, Data: models.Note{}},
+	{Method: http.MethodDelete, Path: "/api/v2/notes/:id", Tag: "v2", Summary: "Move a note to the trash", Data: models.Note{}},
+	{Method: http.MethodGet, Path: "/api/v2/study-plan", Tag: "v2", Summary: "Page of study plan entries by date",
+		Query: append([]Param{num("subject_id", "Only this subject"), fromParam, toParam}, pageParams...), Data: []models.StudyPlan{}},
+	{Method: http.MethodGet, Path: "/api/v2/study-plan/:id", Tag: "v2", Summary: "Get an entry", Data: models.StudyPlan{}},
+	{Method: http.MethodPost, Path: "/api/v2/study-plan", Tag: "v2", Summary: "Create an entry", Status: http.StatusCreated, Body: models.CreateStudyPlanInput{}, Data: models.StudyPlan{}},
+	{Method: http.MethodPatch, Path: "/api/v2/study-plan/:id", Tag: "v2", Summary: "Patch an entry", Body: models.StudyPlanPatch{}, Data: models.StudyPlan{}},
+	{Method: http.MethodDelete, Path: "/api/v2/study-plan/:id", Tag: "v2", Summary: "Move an entry to the trash", Data: models.StudyPlan{}},
+
+	// Documentation
+	{Method: http.MethodGet, Path: "/api/docs", Tag: "Docs", Summary: "Interactive API documentation", ContentType: "text/html"},
+	{Method: http.MethodGet, Path: "/api/docs/openapi.json", Tag: "Docs", Summary: "This OpenAPI document", ContentType: "application/json"},
+}
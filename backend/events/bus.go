@@ -0,0 +1,130 @@
+package events
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// subscriberBuffer is how many events a slow subscriber can fall behind
+// before further events are dropped for it
+const subscriberBuffer = 64
+
+// Event is a domain event published when data changes. Its ID is the ID of
+// the activity log entry recording the change, so IDs survive restarts and
+// missed events can be replayed from the log. A change that could not be
+// logged is not published.
+type Event struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	Actor     string          `json:"actor"`
+	SubjectID int             `json:"subject_id,omitempty"`
+	EntityID  int             `json:"entity_id"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	Time      time.Time       `json:"time"`
+}
+
+// TypeReset is sent to a reconnecting subscriber that missed more events
+// than can be replayed. The subscriber should reload everything it shows;
+// the reset's ID is where the stream then continues from.
+const TypeReset = "reset"
+
+// Filter selects which events a subscriber receives. Zero values match everything.
+type Filter struct {
+	SubjectID int
+	Actor     string
+	Types     map[string]bool
+}
+
+// Match reports whether an event passes the filter
+func (f Filter) Match(e Event) bool {
+	if f.SubjectID != 0 && e.SubjectID != f.SubjectID {
+		return false
+	}
+	if f.Actor != "" && e.Actor != f.Actor {
+		return false
+	}
+	if len(f.Types) > 0 && !f.Types[e.Type] {
+		return false
+	}
+	return true
+}
+
+// Subscription receives published events that match its filter
+type Subscription struct {
+	C      chan Event
+	filter Filter
+}
+
+// Bus is an in-process publish/subscribe hub
+type Bus struct {
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+}
+
+// NewBus creates an empty event bus
+func NewBus() *Bus {
+	return &Bus{subscribers: make(map[*Subscription]struct{})}
+}
+
+// Publish delivers the event to every matching subscriber
+func (b *Bus) Publish(e Event) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	for sub := range b.subscribers {
+		if !sub.filter.Match(e) {
+			continue
+		}
+		select {
+		case sub.C <- e:
+		default:
+			// Drop rather than block publishers on a stalled client
+		}
+	}
+	return e
+}
+
+// Subscribe registers a subscriber for events published from now on.
+// Callers must Unsubscribe when done.
+func (b *Bus) Subscribe(filter Filter) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub := &Subscription{C: make(chan Event, subscriberBuffer), filter: filter}
+	b.subscribers[sub] = struct{}{}
+	return sub
+}
+
+// Unsubscribe removes a subscriber and closes its channel
+func (b *Bus) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subscribers[sub]; ok {
+		delete(b.subscribers, sub)
+		close(sub.C)
+	}
+}
+
+// Default is the process-wide bus used by the handlers
+var Default = NewBus()
+
+// Publish publishes an event on the default bus
+func Publish(e Event) Event {
+	return Default.Publish(e)
+}
+
+// Subscribe subscribes to the default bus
+func Subscribe(filter Filter) *Subscription {
+	return Default.Subscribe(filter)
+}
+
+// Unsubscribe unsubscribes from the default bus
+func Unsubscribe(sub *Subscription) {
+	Default.Unsubscribe(sub)
+}
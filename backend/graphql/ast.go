@@ -0,0 +1,103 @@
+package graphql
+
+// Document is a parsed GraphQL request: its operations and the fragments
+// they can spread
+type Document struct {
+	Operations []*Operation
+	Fragments  map[string]*Fragment
+}
+
+// Operation is a query, mutation or subscription
+type Operation struct {
+	Type         string
+	Name         string
+	Variables    []*VariableDefinition
+	SelectionSet []Selection
+	Location     Location
+}
+
+// VariableDefinition declares one of an operation's $variables. Type is kept
+// in its written form, e.g. "[ID!]!".
+type VariableDefinition struct {
+	Name       string
+	Type       string
+	Default    interface{}
+	HasDefault bool
+}
+
+// Fragment is a named fragment definition
+type Fragment struct {
+	Name          string
+	TypeCondition string
+	SelectionSet  []Selection
+	Location      Location
+}
+
+// Selection is a *Field, *FragmentSpread or *InlineFragment
+type Selection interface {
+	selection()
+}
+
+// Field selects a field, optionally under an alias
+type Field struct {
+	Alias        string
+	Name         string
+	Arguments    []*Argument
+	Directives   []*Directive
+	SelectionSet []Selection
+	Location     Location
+}
+
+// FragmentSpread is ...Name
+type FragmentSpread struct {
+	Name       string
+	Directives []*Directive
+	Location   Location
+}
+
+// InlineFragment is ... on Type { ... }, with an optional type condition
+type InlineFragment struct {
+	TypeCondition string
+	Directives    []*Directive
+	SelectionSet  []Selection
+	Location      Location
+}
+
+func (*Field) selection()          {}
+func (*FragmentSpread) selection() {}
+func (*InlineFragment) selection() {}
+
+// ResponseKey is the field's alias, or its name without one
+func (f *Field) ResponseKey() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.Name
+}
+
+// Argument is a name: value pair of a field or directive
+type Argument struct {
+	Name  string
+	Value interface{}
+}
+
+// Directive is @name(arguments)
+type Directive struct {
+	Name      string
+	Arguments []*Argument
+}
+
+// Values in the document are int, float64, string, bool, nil, []interface{},
+// map[string]interface{}, Variable or EnumValue.
+type (
+	// Variable is a reference to $name
+	Variable string
+	// EnumValue is a bare name other than true, false or null
+	EnumValue string
+)
+
+// Location is a line and column in the document, both starting at 1
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
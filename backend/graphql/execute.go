@@ -0,0 +1,545 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Request is a GraphQL request as sent over HTTP
+type Request struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+	// ReadOnly rejects mutations, for requests such as GET that must not
+	// change anything
+	ReadOnly bool `json:"-"`
+}
+
+// Response is the result of a request. Data is absent when the request
+// failed before execution began.
+type Response struct {
+	Data       interface{}            `json:"data,omitempty"`
+	Errors     []*Error               `json:"errors,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// Error is a request or field error. Paths name the fields leading to the
+// error but not list indices, as a field is resolved for a whole list at once.
+type Error struct {
+	Message    string                 `json:"message"`
+	Locations  []Location             `json:"locations,omitempty"`
+	Path       []string               `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func requestError(location Location, format string, args ...interface{}) *Error {
+	err := &Error{Message: fmt.Sprintf(format, args...)}
+	if location.Line > 0 {
+		err.Locations = []Location{location}
+	}
+	return err
+}
+
+// Execute runs a request against the schema. Requests that do not parse or
+// validate fail as a whole; errors from resolvers null the field and are
+// reported alongside the rest of the data.
+func (s *Schema) Execute(req Request) *Response {
+	doc, err := Parse(req.Query)
+	if err != nil {
+		syntaxErr := err.(*SyntaxError)
+		return &Response{Errors: []*Error{requestError(syntaxErr.Location, "%s", syntaxErr.Error())}}
+	}
+
+	e := &executor{schema: s, doc: doc, args: map[*Field]Args{}}
+	op, root, reqErr := e.operation(req)
+	e.op = op
+	if reqErr == nil {
+		e.variables, reqErr = e.coerceVariables(op, req.Variables)
+	}
+	if reqErr == nil {
+		reqErr = e.validate(root, op.SelectionSet, nil, 1)
+	}
+	if reqErr != nil {
+		return &Response{Errors: []*Error{reqErr}}
+	}
+
+	// The root has a single parent, so mutation fields run one after
+	// another as the spec requires
+	data := e.selectFields(root, []interface{}{nil}, op.SelectionSet, nil)[0]
+	return &Response{Data: data, Errors: e.errors}
+}
+
+type executor struct {
+	schema    *Schema
+	doc       *Document
+	op        *Operation
+	variables map[string]interface{}
+	args      map[*Field]Args
+	errors    []*Error
+}
+
+// operation picks the operation to run and its root type
+func (e *executor) operation(req Request) (*Operation, *Object, *Error) {
+	var op *Operation
+	for _, candidate := range e.doc.Operations {
+		if req.OperationName == "" || candidate.Name == req.OperationName {
+			if op != nil {
+				return nil, nil, requestError(Location{}, "Must provide operation name if query contains multiple operations")
+			}
+			op = candidate
+		}
+	}
+	switch {
+	case op == nil && req.OperationName != "":
+		return nil, nil, requestError(Location{}, "Unknown operation named %q", req.OperationName)
+	case op == nil:
+		return nil, nil, requestError(Location{}, "Must provide an operation")
+	case op.Type == "subscription":
+		return nil, nil, requestError(op.Location, "Subscriptions are not supported")
+	case op.Type == "mutation" && req.ReadOnly:
+		return nil, nil, requestError(op.Location, "Mutations are not allowed in a read-only request")
+	case op.Type == "mutation" && e.schema.Mutation == nil:
+		return nil, nil, requestError(op.Location, "Schema is not configured for mutations")
+	case op.Type == "mutation":
+		return op, e.schema.Mutation, nil
+	}
+	return op, e.schema.Query, nil
+}
+
+// coerceVariables checks the given variables against the operation's
+// definitions. A variable that was not given and has no default is left out,
+// so arguments using it are treated as not given.
+func (e *executor) coerceVariables(op *Operation, given map[string]interface{}) (map[string]interface{}, *Error) {
+	variables := map[string]interface{}{}
+	for _, def := range op.Variables {
+		if !e.inputType(def.Type) {
+			return nil, requestError(op.Location, "Variable \"$%s\" cannot be of non-input type %q", def.Name, def.Type)
+		}
+		value, ok := given[def.Name]
+		if !ok {
+			if def.HasDefault {
+				value, ok = def.Default, true
+			} else if strings.HasSuffix(def.Type, "!") {
+				return nil, requestError(op.Location, "Variable \"$%s\" of required type %q was not provided", def.Name, def.Type)
+			}
+		}
+		if !ok {
+			continue
+		}
+		coerced, err := e.schema.coerce(value, def.Type)
+		if err != nil {
+			return nil, requestError(op.Location, "Variable \"$%s\" got invalid value %s: %s", def.Name, describe(value), err)
+		}
+		variables[def.Name] = coerced
+	}
+	return variables, nil
+}
+
+func (e *executor) inputType(typ string) bool {
+	name := namedType(typ)
+	return scalars[name] || e.schema.inputs[name] != nil
+}
+
+// MaxDepth is how many levels of fields a query may select, counting the
+// root fields as the first. Each level is resolved with at least one batch,
+// so the limit bounds the work a single request can ask for.
+const MaxDepth = 10
+
+// validate checks a selection set against a type before anything runs and
+// coerces the arguments of every field in it. spreading holds the fragments
+// being expanded, to catch cycles, and depth is the level of the set's fields.
+func (e *executor) validate(object *Object, selections []Selection, spreading []string, depth int) *Error {
+	for _, selection := range selections {
+		switch sel := selection.(type) {
+		case *Field:
+			if err := e.validateField(object, sel, spreading, depth); err != nil {
+				return err
+			}
+		case *FragmentSpread:
+			fragment := e.doc.Fragments[sel.Name]
+			if fragment == nil {
+				return requestError(sel.Location, "Unknown fragment %q", sel.Name)
+			}
+			for _, name := range spreading {
+				if name == sel.Name {
+					return requestError(sel.Location, "Cannot spread fragment %q within itself", sel.Name)
+				}
+			}
+			if err := e.validateCondition(object, fragment.TypeCondition, sel.Location); err != nil {
+				return err
+			}
+			if err := e.validateDirectives(sel.Directives, sel.Location); err != nil {
+				return err
+			}
+			if err := e.validate(object, fragment.SelectionSet, append(spreading, sel.Name), depth); err != nil {
+				return err
+			}
+		case *InlineFragment:
+			if err := e.validateCondition(object, sel.TypeCondition, sel.Location); err != nil {
+				return err
+			}
+			if err := e.validateDirectives(sel.Directives, sel.Location); err != nil {
+				return err
+			}
+			if err := e.validate(object, sel.SelectionSet, spreading, depth); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (e *executor) validateCondition(object *Object, condition string, location Location) *Error {
+	if condition == "" || condition == object.Name {
+		return nil
+	}
+	if e.schema.objects[condition] == nil {
+		return requestError(location, "Unknown type %q", condition)
+	}
+	return requestError(location, "Fragment cannot be spread here as objects of type %q can never be of type %q", object.Name, condition)
+}
+
+func (e *executor) validateField(object *Object, field *Field, spreading []string, depth int) *Error {
+	if depth > MaxDepth {
+		return requestError(field.Location, "Query is too deep: fields may be nested at most %d levels", MaxDepth)
+	}
+	if err := e.validateDirectives(field.Directives, field.Location); err != nil {
+		return err
+	}
+	if field.Name == "__typename" {
+		if len(field.SelectionSet) > 0 {
+			return requestError(field.Location, "Field \"__typename\" must not have a selection since type \"String!\" has no subfields")
+		}
+		return nil
+	}
+
+	def := object.fields[field.Name]
+	if def == nil {
+		return requestError(field.Location, "Cannot query field %q on type %q", field.Name, object.Name)
+	}
+	args, err := e.arguments(def.Args, field.Arguments, fmt.Sprintf("%s.%s", object.Name, def.Name), field.Location)
+	if err != nil {
+		return err
+	}
+	e.args[field] = args
+
+	child := e.schema.objects[namedType(def.Type)]
+	switch {
+	case child == nil && len(field.SelectionSet) > 0:
+		return requestError(field.Location, "Field %q must not have a selection since type %q has no subfields", field.Name, def.Type)
+	case child != nil && len(field.SelectionSet) == 0:
+		return requestError(field.Location, "Field %q of type %q must have a selection of subfields", field.Name, def.Type)
+	case child != nil:
+		return e.validate(child, field.SelectionSet, spreading, depth+1)
+	}
+	return nil
+}
+
+// validateDirectives allows @skip and @include, with a Boolean if argument
+func (e *executor) validateDirectives(directives []*Directive, location Location) *Error {
+	for _, directive := range directives {
+		if directive.Name != "skip" && directive.Name != "include" {
+			return requestError(location, "Unknown directive \"@%s\"", directive.Name)
+		}
+		if _, err := e.arguments(ifArg, directive.Arguments, "@"+directive.Name, location); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var ifArg = []*Arg{{Name: "if", Type: "Boolean!"}}
+
+// arguments coerces the arguments given to a field or directive, filling in
+// defaults
+func (e *executor) arguments(defs []*Arg, given []*Argument, owner string, location Location) (Args, *Error) {
+	args := Args{}
+	for _, argument := range given {
+		var def *Arg
+		for _, candidate := range defs {
+			if candidate.Name == argument.Name {
+				def = candidate
+			}
+		}
+		if def == nil {
+			return nil, requestError(location, "Unknown argument %q on %s", argument.Name, owner)
+		}
+		if _, dup := args[argument.Name]; dup {
+			return nil, requestError(location, "There can be only one argument named %q", argument.Name)
+		}
+		value, present, err := e.substitute(argument.Value)
+		if err != nil {
+			return nil, requestError(location, "%s", err)
+		}
+		if !present {
+			continue
+		}
+		if args[def.Name], err = e.schema.coerce(value, def.Type); err != nil {
+			return nil, requestError(location, "Argument %q on %s has an invalid value: %s", def.Name, owner, err)
+		}
+	}
+	for _, def := range defs {
+		if _, ok := args[def.Name]; ok {
+			continue
+		}
+		if def.Default != nil {
+			args[def.Name] = def.Default
+		} else if strings.HasSuffix(def.Type, "!") {
+			return nil, requestError(location, "Argument %q of type %q is required on %s, but it was not provided", def.Name, def.Type, owner)
+		}
+	}
+	return args, nil
+}
+
+// substitute replaces the variables in a value. A variable on its own that
+// was not given makes the value absent; inside a list or object it is null.
+func (e *executor) substitute(value interface{}) (interface{}, bool, error) {
+	switch v := value.(type) {
+	case Variable:
+		if !e.defined(string(v)) {
+			return nil, false, fmt.Errorf("Variable \"$%s\" is not defined", v)
+		}
+		resolved, ok := e.variables[string(v)]
+		return resolved, ok, nil
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			var err error
+			if list[i], _, err = e.substitute(item); err != nil {
+				return nil, false, err
+			}
+		}
+		return list, true, nil
+	case map[string]interface{}:
+		object := make(map[string]interface{}, len(v))
+		for key, member := range v {
+			resolved, present, err := e.substitute(member)
+			if err != nil {
+				return nil, false, err
+			}
+			if present {
+				object[key] = resolved
+			}
+		}
+		return object, true, nil
+	}
+	return value, true, nil
+}
+
+func (e *executor) defined(name string) bool {
+	for _, def := range e.op.Variables {
+		if def.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldGroup is the fields selected under one response key, merged
+type fieldGroup struct {
+	key    string
+	fields []*Field
+}
+
+// collect flattens fragments and applies @skip and @include, grouping the
+// selected fields by response key in the order they first appear
+func (e *executor) collect(object *Object, selections []Selection, groups []*fieldGroup) []*fieldGroup {
+	for _, selection := range selections {
+		switch sel := selection.(type) {
+		case *Field:
+			if !e.included(sel.Directives) {
+				continue
+			}
+			var group *fieldGroup
+			for _, existing := range groups {
+				if existing.key == sel.ResponseKey() {
+					group = existing
+				}
+			}
+			if group == nil {
+				group = &fieldGroup{key: sel.ResponseKey()}
+				groups = append(groups, group)
+			}
+			group.fields = append(group.fields, sel)
+		case *FragmentSpread:
+			if e.included(sel.Directives) {
+				groups = e.collect(object, e.doc.Fragments[sel.Name].SelectionSet, groups)
+			}
+		case *InlineFragment:
+			if e.included(sel.Directives) {
+				groups = e.collect(object, sel.SelectionSet, groups)
+			}
+		}
+	}
+	return groups
+}
+
+func (e *executor) included(directives []*Directive) bool {
+	for _, directive := range directives {
+		args, _ := e.arguments(ifArg, directive.Arguments, "@"+directive.Name, Location{})
+		if condition, _ := args.Bool("if"); condition == (directive.Name == "skip") {
+			return false
+		}
+	}
+	return true
+}
+
+// selectFields resolves a selection set for every parent at one level,
+// calling each field's resolver once for all of them
+func (e *executor) selectFields(object *Object, parents []interface{}, selections []Selection, path []string) []*orderedMap {
+	results := make([]*orderedMap, len(parents))
+	for i := range results {
+		results[i] = &orderedMap{}
+	}
+
+	for _, group := range e.collect(object, selections, nil) {
+		field := group.fields[0]
+		fieldPath := append(path[:len(path):len(path)], group.key)
+		if field.Name == "__typename" {
+			for _, result := range results {
+				result.set(group.key, object.Name)
+			}
+			continue
+		}
+
+		def := object.fields[field.Name]
+		values, err := def.Resolve(parents, e.args[field])
+		if err == nil && len(values) != len(parents) {
+			err = fmt.Errorf("graphql: %s.%s resolved %d values for %d parents", object.Name, def.Name, len(values), len(parents))
+		}
+		var completed []interface{}
+		if err != nil {
+			e.fail(err, field, fieldPath)
+			completed = make([]interface{}, len(parents))
+		} else {
+			var subSelections []Selection
+			for _, f := range group.fields {
+				subSelections = append(subSelections, f.SelectionSet...)
+			}
+			completed = e.complete(def.Type, values, subSelections, fieldPath)
+		}
+		for i, result := range results {
+			result.set(group.key, completed[i])
+		}
+	}
+	return results
+}
+
+// complete turns resolved values into response values. The objects in a
+// list, or in the lists of every parent, are selected together.
+func (e *executor) complete(typ string, values []interface{}, selections []Selection, path []string) []interface{} {
+	completed := make([]interface{}, len(values))
+
+	if item, isList := unwrapList(typ); isList {
+		var items []interface{}
+		counts := make([]int, len(values))
+		for i, value := range values {
+			counts[i] = -1
+			if isNil(value) {
+				continue
+			}
+			list := reflect.ValueOf(value)
+			if list.Kind() != reflect.Slice && list.Kind() != reflect.Array {
+				e.fail(fmt.Errorf("graphql: expected a list for %s, got %T", typ, value), nil, path)
+				continue
+			}
+			counts[i] = list.Len()
+			for j := 0; j < list.Len(); j++ {
+				items = append(items, list.Index(j).Interface())
+			}
+		}
+		done := e.complete(item, items, selections, path)
+		for i, count := range counts {
+			if count >= 0 {
+				completed[i], done = append([]interface{}{}, done[:count]...), done[count:]
+			}
+		}
+		return completed
+	}
+
+	name := namedType(typ)
+	object := e.schema.objects[name]
+	if object == nil {
+		for i, value := range values {
+			completed[i] = serialize(value, name)
+		}
+		return completed
+	}
+
+	var present []interface{}
+	for _, value := range values {
+		if !isNil(value) {
+			present = append(present, value)
+		}
+	}
+	selected := e.selectFields(object, present, selections, path)
+	for i, value := range values {
+		if !isNil(value) {
+			completed[i], selected = selected[0], selected[1:]
+		}
+	}
+	return completed
+}
+
+// fail records a field error
+func (e *executor) fail(err error, field *Field, path []string) {
+	gqlErr := &Error{Message: err.Error(), Path: path}
+	if e.schema.FormatError != nil {
+		gqlErr.Message, gqlErr.Extensions = e.schema.FormatError(err)
+	}
+	if field != nil {
+		gqlErr.Locations = []Location{field.Location}
+	}
+	e.errors = append(e.errors, gqlErr)
+}
+
+func isNil(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return v.IsNil() && v.Kind() != reflect.Slice
+	}
+	return false
+}
+
+// orderedMap is a response object, which keeps its fields in the order
+// they were selected
+type orderedMap struct {
+	keys   []string
+	values []interface{}
+}
+
+func (m *orderedMap) set(key string, value interface{}) {
+	m.keys = append(m.keys, key)
+	m.values = append(m.values, value)
+}
+
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		b.Write(name)
+		b.WriteByte(':')
+		value, err := json.Marshal(m.values[i])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
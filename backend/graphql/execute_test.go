@@ -0,0 +1,121 @@
+package graphql
+
+import (
+	"strings"
+	"testing"
+)
+
+type testNode struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	ParentID int    `json:"parent_id"`
+}
+
+// testSchema serves a tree where node n has children 2n and 2n+1. It counts
+// the calls to the children resolver and to the parent loader's fetch.
+func testSchema(childCalls, parentFetches *int) *Schema {
+	node := func(id int) *testNode {
+		return &testNode{ID: id, Name: "node", ParentID: id / 2}
+	}
+	parents := NewLoader(func(ids []int) (map[int]interface{}, error) {
+		*parentFetches++
+		found := map[int]interface{}{}
+		for _, id := range ids {
+			if id > 0 {
+				found[id] = node(id)
+			}
+		}
+		return found, nil
+	})
+
+	nodeType := &Object{Name: "Node", Fields: []*FieldDef{
+		{Name: "id", Type: "ID!"},
+		{Name: "name", Type: "String!"},
+		{Name: "children", Type: "[Node!]!", Resolve: func(parents []interface{}, _ Args) ([]interface{}, error) {
+			*childCalls++
+			values := make([]interface{}, len(parents))
+			for i, parent := range parents {
+				id := parent.(*testNode).ID
+				values[i] = []interface{}{node(2 * id), node(2*id + 1)}
+			}
+			return values, nil
+		}},
+		{Name: "parent", Type: "Node", Resolve: func(nodes []interface{}, _ Args) ([]interface{}, error) {
+			ids := make([]int, len(nodes))
+			for i, n := range nodes {
+				ids[i] = n.(*testNode).ParentID
+			}
+			return parents.LoadMany(ids)
+		}},
+	}}
+	query := &Object{Name: "Query", Fields: []*FieldDef{
+		{Name: "root", Type: "Node!", Resolve: Each(func(interface{}, Args) (interface{}, error) {
+			return node(1), nil
+		})},
+	}}
+	return NewSchema(query, nil, []*Object{nodeType}, nil)
+}
+
+// levels returns a selection set on Node whose fields nest n levels deep
+func levels(n int) string {
+	return strings.Repeat("{ children ", n-1) + "{ id }" + strings.Repeat(" }", n-1)
+}
+
+func TestExecuteBatchesLevels(t *testing.T) {
+	var childCalls, parentFetches int
+	schema := testSchema(&childCalls, &parentFetches)
+
+	resp := schema.Execute(Request{Query: `{
+		root {
+			children {
+				children {
+					children { parent { parent { id } } }
+				}
+			}
+		}
+	}`})
+	if len(resp.Errors) > 0 {
+		t.Fatalf("errors: %v", resp.Errors[0])
+	}
+	if childCalls != 3 {
+		t.Errorf("children resolved %d times, want once per level: 3", childCalls)
+	}
+	// The 8 nodes at the bottom have 4 parents, which have 2 parents
+	if parentFetches != 2 {
+		t.Errorf("parents fetched %d times, want once per level: 2", parentFetches)
+	}
+}
+
+func TestExecuteDepthLimit(t *testing.T) {
+	var childCalls, parentFetches int
+	schema := testSchema(&childCalls, &parentFetches)
+
+	tests := []struct {
+		name  string
+		query string
+		ok    bool
+	}{
+		{"at the limit", "{ root " + levels(MaxDepth-1) + " }", true},
+		{"over the limit", "{ root " + levels(MaxDepth) + " }", false},
+		{"over the limit through a fragment", "{ root { ...deep } } fragment deep on Node " + levels(MaxDepth), false},
+		{"over the limit through an inline fragment", "{ root { ... on Node " + levels(MaxDepth) + " } }", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			childCalls = 0
+			resp := schema.Execute(Request{Query: tt.query})
+			if tt.ok {
+				if len(resp.Errors) > 0 {
+					t.Fatalf("errors: %v", resp.Errors[0])
+				}
+				return
+			}
+			if resp.Data != nil || len(resp.Errors) != 1 || !strings.Contains(resp.Errors[0].Message, "too deep") {
+				t.Fatalf("response = %+v, want a depth error and no data", resp)
+			}
+			if childCalls != 0 {
+				t.Errorf("children resolved %d times before the query was rejected", childCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package graphql
+
+// Loader loads values by ID in batches and caches them for the rest of the
+// request, in the style of a dataloader. Resolvers that see many parents at
+// once pass all their IDs to LoadMany, which fetches the ones not yet cached
+// with a single call.
+type Loader struct {
+	fetch func(ids []int) (map[int]interface{}, error)
+	cache map[int]interface{}
+}
+
+// NewLoader returns a loader that fetches with fn. fn returns the values it
+// found by ID; IDs it leaves out load as nil.
+func NewLoader(fn func(ids []int) (map[int]interface{}, error)) *Loader {
+	return &Loader{fetch: fn, cache: map[int]interface{}{}}
+}
+
+// LoadMany returns the values for ids, in the same order
+func (l *Loader) LoadMany(ids []int) ([]interface{}, error) {
+	var missing []int
+	seen := map[int]bool{}
+	for _, id := range ids {
+		if _, cached := l.cache[id]; !cached && !seen[id] {
+			missing = append(missing, id)
+			seen[id] = true
+		}
+	}
+	if len(missing) > 0 {
+		found, err := l.fetch(missing)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range missing {
+			l.cache[id] = found[id]
+		}
+	}
+
+	values := make([]interface{}, len(ids))
+	for i, id := range ids {
+		values[i] = l.cache[id]
+	}
+	return values, nil
+}
+
+// Load returns the value for a single ID
+func (l *Loader) Load(id int) (interface{}, error) {
+	values, err := l.LoadMany([]int{id})
+	if err != nil {
+		return nil, err
+	}
+	return values[0], nil
+}
+
+// Prime caches a value that was loaded some other way
+func (l *Loader) Prime(id int, value interface{}) {
+	l.cache[id] = value
+}
+
+// Clear empties the cache, e.g. after a mutation
+func (l *Loader) Clear() {
+	l.cache = map[int]interface{}{}
+}
@@ -0,0 +1,575 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// SyntaxError is a problem parsing a document
+type SyntaxError struct {
+	Message  string
+	Location Location
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("Syntax Error: %s (line %d, column %d)", e.Message, e.Location.Line, e.Location.Column)
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunctuator
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind     tokenKind
+	value    string
+	location Location
+}
+
+// describe names a token for error messages
+func (t token) describe() string {
+	switch t.kind {
+	case tokenEOF:
+		return "<EOF>"
+	case tokenName:
+		return fmt.Sprintf("Name %q", t.value)
+	case tokenInt, tokenFloat:
+		return fmt.Sprintf("number %s", t.value)
+	case tokenString:
+		return fmt.Sprintf("string %q", t.value)
+	}
+	return fmt.Sprintf("%q", t.value)
+}
+
+// lexer splits a document into tokens, skipping whitespace, commas and
+// comments
+type lexer struct {
+	source    string
+	pos       int
+	line      int
+	lineStart int
+}
+
+func (l *lexer) location() Location {
+	return Location{Line: l.line, Column: l.pos - l.lineStart + 1}
+}
+
+func (l *lexer) fail(location Location, format string, args ...interface{}) {
+	panic(&SyntaxError{Message: fmt.Sprintf(format, args...), Location: location})
+}
+
+func (l *lexer) newline() {
+	l.line++
+	l.lineStart = l.pos
+}
+
+func (l *lexer) skipIgnored() {
+	for l.pos < len(l.source) {
+		switch ch := l.source[l.pos]; ch {
+		case ' ', '\t', ',', '\r':
+			l.pos++
+		case '\n':
+			l.pos++
+			l.newline()
+		case '#':
+			for l.pos < len(l.source) && l.source[l.pos] != '\n' {
+				l.pos++
+			}
+		default:
+			if strings.HasPrefix(l.source[l.pos:], "\uFEFF") {
+				l.pos += len("\uFEFF")
+				continue
+			}
+			return
+		}
+	}
+}
+
+func (l *lexer) next() token {
+	l.skipIgnored()
+	location := l.location()
+	if l.pos >= len(l.source) {
+		return token{kind: tokenEOF, location: location}
+	}
+
+	ch := l.source[l.pos]
+	switch {
+	case strings.HasPrefix(l.source[l.pos:], "..."):
+		l.pos += 3
+		return token{kind: tokenPunctuator, value: "...", location: location}
+	case strings.IndexByte("!$&()[]{}:=@|", ch) >= 0:
+		l.pos++
+		return token{kind: tokenPunctuator, value: string(ch), location: location}
+	case isNameStart(ch):
+		start := l.pos
+		for l.pos < len(l.source) && (isNameStart(l.source[l.pos]) || isDigit(l.source[l.pos])) {
+			l.pos++
+		}
+		return token{kind: tokenName, value: l.source[start:l.pos], location: location}
+	case ch == '-' || isDigit(ch):
+		return l.number(location)
+	case ch == '"':
+		if strings.HasPrefix(l.source[l.pos:], `"""`) {
+			return l.blockString(location)
+		}
+		return l.string(location)
+	}
+	r, _ := utf8.DecodeRuneInString(l.source[l.pos:])
+	l.fail(location, "Unexpected character %q", r)
+	return token{}
+}
+
+func (l *lexer) number(location Location) token {
+	start := l.pos
+	kind := tokenInt
+	if l.source[l.pos] == '-' {
+		l.pos++
+	}
+	l.digits(location)
+	if l.pos < len(l.source) && l.source[l.pos] == '.' {
+		kind = tokenFloat
+		l.pos++
+		l.digits(location)
+	}
+	if l.pos < len(l.source) && (l.source[l.pos] == 'e' || l.source[l.pos] == 'E') {
+		kind = tokenFloat
+		l.pos++
+		if l.pos < len(l.source) && (l.source[l.pos] == '+' || l.source[l.pos] == '-') {
+			l.pos++
+		}
+		l.digits(location)
+	}
+	if l.pos < len(l.source) && (isNameStart(l.source[l.pos]) || l.source[l.pos] == '.') {
+		l.fail(l.location(), "Invalid number, unexpected %q", l.source[l.pos])
+	}
+	return token{kind: kind, value: l.source[start:l.pos], location: location}
+}
+
+func (l *lexer) digits(location Location) {
+	start := l.pos
+	for l.pos < len(l.source) && isDigit(l.source[l.pos]) {
+		l.pos++
+	}
+	if l.pos == start {
+		l.fail(location, "Invalid number, expected digit")
+	}
+}
+
+func (l *lexer) string(location Location) token {
+	l.pos++
+	var value strings.Builder
+	for l.pos < len(l.source) {
+		ch := l.source[l.pos]
+		switch {
+		case ch == '"':
+			l.pos++
+			return token{kind: tokenString, value: value.String(), location: location}
+		case ch == '\n' || ch == '\r':
+			l.fail(location, "Unterminated string")
+		case ch == '\\' && l.pos+1 < len(l.source):
+			escape := l.source[l.pos+1]
+			l.pos += 2
+			switch escape {
+			case '"', '\\', '/':
+				value.WriteByte(escape)
+			case 'b':
+				value.WriteByte('\b')
+			case 'f':
+				value.WriteByte('\f')
+			case 'n':
+				value.WriteByte('\n')
+			case 'r':
+				value.WriteByte('\r')
+			case 't':
+				value.WriteByte('\t')
+			case 'u':
+				if l.pos+4 > len(l.source) {
+					l.fail(location, "Invalid Unicode escape sequence")
+				}
+				code, err := strconv.ParseUint(l.source[l.pos:l.pos+4], 16, 32)
+				if err != nil {
+					l.fail(location, "Invalid Unicode escape sequence")
+				}
+				value.WriteRune(rune(code))
+				l.pos += 4
+			default:
+				l.fail(location, "Invalid character escape sequence \\%c", escape)
+			}
+		default:
+			value.WriteByte(ch)
+			l.pos++
+		}
+	}
+	l.fail(location, "Unterminated string")
+	return token{}
+}
+
+// blockString reads a """block string""", removing the indentation common
+// to its lines and the blank lines around it
+func (l *lexer) blockString(location Location) token {
+	l.pos += 3
+	var raw strings.Builder
+	for l.pos < len(l.source) {
+		switch {
+		case strings.HasPrefix(l.source[l.pos:], `\"""`):
+			raw.WriteString(`"""`)
+			l.pos += 4
+		case strings.HasPrefix(l.source[l.pos:], `"""`):
+			l.pos += 3
+			return token{kind: tokenString, value: dedent(raw.String()), location: location}
+		default:
+			if l.source[l.pos] == '\n' {
+				l.pos++
+				l.newline()
+				raw.WriteByte('\n')
+				continue
+			}
+			raw.WriteByte(l.source[l.pos])
+			l.pos++
+		}
+	}
+	l.fail(location, "Unterminated string")
+	return token{}
+}
+
+func dedent(raw string) string {
+	lines := strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+	indent := -1
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed != "" && (indent < 0 || len(line)-len(trimmed) < indent) {
+			indent = len(line) - len(trimmed)
+		}
+	}
+	for i := 1; i < len(lines) && indent > 0; i++ {
+		if len(lines[i]) >= indent {
+			lines[i] = lines[i][indent:]
+		} else {
+			lines[i] = ""
+		}
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isNameStart(ch byte) bool {
+	return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+// MaxNesting is how deeply selection sets, list and object values and list
+// types may nest in a document. The parser is recursive, so deeper documents
+// are rejected before they can exhaust the stack.
+const MaxNesting = 64
+
+// parser builds a Document from tokens. Errors are raised as *SyntaxError
+// panics and recovered in Parse.
+type parser struct {
+	lexer *lexer
+	token token
+	depth int
+}
+
+// Parse parses a GraphQL request document
+func Parse(source string) (doc *Document, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			syntaxErr, ok := r.(*SyntaxError)
+			if !ok {
+				panic(r)
+			}
+			doc, err = nil, syntaxErr
+		}
+	}()
+
+	p := &parser{lexer: &lexer{source: source, line: 1}}
+	p.advance()
+	return p.document(), nil
+}
+
+func (p *parser) advance() token {
+	current := p.token
+	p.token = p.lexer.next()
+	return current
+}
+
+func (p *parser) fail(format string, args ...interface{}) {
+	p.lexer.fail(p.token.location, format, args...)
+}
+
+// at reports whether the current token is the given punctuator
+func (p *parser) at(punctuator string) bool {
+	return p.token.kind == tokenPunctuator && p.token.value == punctuator
+}
+
+// atName reports whether the current token is the given name
+func (p *parser) atName(name string) bool {
+	return p.token.kind == tokenName && p.token.value == name
+}
+
+// nest enters a nested selection set, value or type; the caller must call
+// unnest when it leaves it
+func (p *parser) nest() {
+	if p.depth++; p.depth > MaxNesting {
+		p.fail("Document is nested more than %d levels deep", MaxNesting)
+	}
+}
+
+func (p *parser) unnest() {
+	p.depth--
+}
+
+func (p *parser) expect(punctuator string) {
+	if !p.at(punctuator) {
+		p.fail("Expected %q, found %s", punctuator, p.token.describe())
+	}
+	p.advance()
+}
+
+func (p *parser) name() string {
+	if p.token.kind != tokenName {
+		p.fail("Expected Name, found %s", p.token.describe())
+	}
+	return p.advance().value
+}
+
+func (p *parser) document() *Document {
+	doc := &Document{Fragments: map[string]*Fragment{}}
+	if p.token.kind == tokenEOF {
+		p.fail("Unexpected <EOF>")
+	}
+	for p.token.kind != tokenEOF {
+		switch {
+		case p.at("{"):
+			doc.Operations = append(doc.Operations, &Operation{Type: "query", Location: p.token.location, SelectionSet: p.selectionSet()})
+		case p.atName("query"), p.atName("mutation"), p.atName("subscription"):
+			doc.Operations = append(doc.Operations, p.operation())
+		case p.atName("fragment"):
+			fragment := p.fragment()
+			if _, dup := doc.Fragments[fragment.Name]; dup {
+				p.lexer.fail(fragment.Location, "There can be only one fragment named %q", fragment.Name)
+			}
+			doc.Fragments[fragment.Name] = fragment
+		default:
+			p.fail("Unexpected %s", p.token.describe())
+		}
+	}
+	return doc
+}
+
+func (p *parser) operation() *Operation {
+	op := &Operation{Location: p.token.location, Type: p.advance().value}
+	if p.token.kind == tokenName {
+		op.Name = p.advance().value
+	}
+	if p.at("(") {
+		p.advance()
+		for !p.at(")") {
+			op.Variables = append(op.Variables, p.variableDefinition())
+		}
+		p.advance()
+	}
+	p.directives()
+	op.SelectionSet = p.selectionSet()
+	return op
+}
+
+func (p *parser) variableDefinition() *VariableDefinition {
+	p.expect("$")
+	def := &VariableDefinition{Name: p.name()}
+	p.expect(":")
+	def.Type = p.typeReference()
+	if p.at("=") {
+		p.advance()
+		def.Default, def.HasDefault = p.value(true), true
+	}
+	p.directives()
+	return def
+}
+
+func (p *parser) typeReference() string {
+	var typ string
+	if p.at("[") {
+		p.nest()
+		p.advance()
+		typ = "[" + p.typeReference() + "]"
+		p.expect("]")
+		p.unnest()
+	} else {
+		typ = p.name()
+	}
+	if p.at("!") {
+		p.advance()
+		typ += "!"
+	}
+	return typ
+}
+
+func (p *parser) fragment() *Fragment {
+	location := p.advance().location
+	fragment := &Fragment{Location: location, Name: p.name()}
+	if fragment.Name == "on" {
+		p.lexer.fail(location, `Unexpected Name "on"`)
+	}
+	if !p.atName("on") {
+		p.fail(`Expected "on", found %s`, p.token.describe())
+	}
+	p.advance()
+	fragment.TypeCondition = p.name()
+	p.directives()
+	fragment.SelectionSet = p.selectionSet()
+	return fragment
+}
+
+func (p *parser) selectionSet() []Selection {
+	p.nest()
+	defer p.unnest()
+	p.expect("{")
+	var selections []Selection
+	for !p.at("}") {
+		selections = append(selections, p.selection())
+	}
+	if len(selections) == 0 {
+		p.fail("Expected Name, found %s", p.token.describe())
+	}
+	p.advance()
+	return selections
+}
+
+func (p *parser) selection() Selection {
+	location := p.token.location
+	if !p.at("...") {
+		return p.field()
+	}
+
+	p.advance()
+	if p.token.kind == tokenName && !p.atName("on") {
+		return &FragmentSpread{Location: location, Name: p.advance().value, Directives: p.directives()}
+	}
+	inline := &InlineFragment{Location: location}
+	if p.atName("on") {
+		p.advance()
+		inline.TypeCondition = p.name()
+	}
+	inline.Directives = p.directives()
+	inline.SelectionSet = p.selectionSet()
+	return inline
+}
+
+func (p *parser) field() *Field {
+	field := &Field{Location: p.token.location, Name: p.name()}
+	if p.at(":") {
+		p.advance()
+		field.Alias, field.Name = field.Name, p.name()
+	}
+	field.Arguments = p.arguments(false)
+	field.Directives = p.directives()
+	if p.at("{") {
+		field.SelectionSet = p.selectionSet()
+	}
+	return field
+}
+
+func (p *parser) arguments(constant bool) []*Argument {
+	if !p.at("(") {
+		return nil
+	}
+	p.advance()
+	var arguments []*Argument
+	for !p.at(")") {
+		argument := &Argument{Name: p.name()}
+		p.expect(":")
+		argument.Value = p.value(constant)
+		arguments = append(arguments, argument)
+	}
+	if len(arguments) == 0 {
+		p.fail("Expected Name, found %s", p.token.describe())
+	}
+	p.advance()
+	return arguments
+}
+
+func (p *parser) directives() []*Directive {
+	var directives []*Directive
+	for p.at("@") {
+		p.advance()
+		directives = append(directives, &Directive{Name: p.name(), Arguments: p.arguments(false)})
+	}
+	return directives
+}
+
+// value parses a value; constant values, such as variable defaults, cannot
+// refer to variables
+func (p *parser) value(constant bool) interface{} {
+	tok := p.token
+	switch {
+	case p.at("$") && !constant:
+		p.advance()
+		return Variable(p.name())
+	case p.at("["):
+		p.nest()
+		defer p.unnest()
+		p.advance()
+		list := []interface{}{}
+		for !p.at("]") {
+			list = append(list, p.value(constant))
+		}
+		p.advance()
+		return list
+	case p.at("{"):
+		p.nest()
+		defer p.unnest()
+		p.advance()
+		object := map[string]interface{}{}
+		for !p.at("}") {
+			name := p.name()
+			p.expect(":")
+			object[name] = p.value(constant)
+		}
+		p.advance()
+		return object
+	case tok.kind == tokenInt:
+		p.advance()
+		n, err := strconv.Atoi(tok.value)
+		if err != nil {
+			p.lexer.fail(tok.location, "Int cannot represent %s", tok.value)
+		}
+		return n
+	case tok.kind == tokenFloat:
+		p.advance()
+		f, _ := strconv.ParseFloat(tok.value, 64)
+		return f
+	case tok.kind == tokenString:
+		p.advance()
+		return tok.value
+	case tok.kind == tokenName:
+		p.advance()
+		switch tok.value {
+		case "true":
+			return true
+		case "false":
+			return false
+		case "null":
+			return nil
+		}
+		return EnumValue(tok.value)
+	}
+	p.fail("Unexpected %s", tok.describe())
+	return nil
+}
@@ -0,0 +1,101 @@
+package graphql
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	doc, err := Parse(`
+		query Topics($subject: ID!, $limit: Int = 10) {
+			subject(id: $subject) {
+				name
+				recent: topics(first: $limit, filter: {tags: ["a", "b"]}) { ...topicFields }
+			}
+		}
+		fragment topicFields on Topic { id isCompleted }
+	`)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(doc.Operations) != 1 || doc.Fragments["topicFields"] == nil {
+		t.Fatalf("got %d operations and fragments %v", len(doc.Operations), doc.Fragments)
+	}
+	op := doc.Operations[0]
+	if op.Type != "query" || op.Name != "Topics" || len(op.Variables) != 2 {
+		t.Fatalf("operation = %s %s with %d variables", op.Type, op.Name, len(op.Variables))
+	}
+	if v := op.Variables[1]; v.Type != "Int" || !v.HasDefault || v.Default != 10 {
+		t.Errorf("variable $limit = %+v", v)
+	}
+	subject := op.SelectionSet[0].(*Field)
+	topics := subject.SelectionSet[1].(*Field)
+	if topics.Alias != "recent" || topics.Name != "topics" || len(topics.Arguments) != 2 {
+		t.Errorf("aliased field = %+v", topics)
+	}
+	if spread, ok := topics.SelectionSet[0].(*FragmentSpread); !ok || spread.Name != "topicFields" {
+		t.Errorf("selection = %#v, want spread of topicFields", topics.SelectionSet[0])
+	}
+}
+
+func TestParseNesting(t *testing.T) {
+	// nested wraps inner in n pairs of open and close
+	nested := func(n int, open, inner, close string) string {
+		return strings.Repeat(open, n) + inner + strings.Repeat(close, n)
+	}
+
+	tests := []struct {
+		name   string
+		source string
+		ok     bool
+	}{
+		{"selection sets at the limit", nested(MaxNesting, "{a", "", "}"), true},
+		{"selection sets over the limit", nested(MaxNesting+1, "{a", "", "}"), false},
+		{"list values at the limit", "{a(x: " + nested(MaxNesting-1, "[", "1", "]") + ")}", true},
+		{"list values over the limit", "{a(x: " + nested(MaxNesting, "[", "1", "]") + ")}", false},
+		{"object values over the limit", "{a(x: " + nested(MaxNesting, "{y: ", "1", "}") + ")}", false},
+		{"list types over the limit", "query($x: " + nested(MaxNesting+1, "[", "Int", "]") + ") {a}", false},
+		{"unterminated deep document", strings.Repeat("{a", 1_000_000), false},
+		{"deep list value", "{a(x: " + strings.Repeat("[", 1_000_000) + ")}", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.source)
+			if tt.ok {
+				if err != nil {
+					t.Fatalf("Parse: %v", err)
+				}
+				return
+			}
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) || !strings.Contains(syntaxErr.Message, "nested more than") {
+				t.Fatalf("Parse error = %v, want nesting error", err)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		location Location
+	}{
+		{"unclosed selection set", "{\n  a", Location{Line: 2, Column: 4}},
+		{"missing argument value", "{ a(x: ) }", Location{Line: 1, Column: 8}},
+		{"unknown character", "{ a % }", Location{Line: 1, Column: 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.source)
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("Parse error = %v, want a SyntaxError", err)
+			}
+			if syntaxErr.Location != tt.location {
+				t.Errorf("location = %+v, want %+v", syntaxErr.Location, tt.location)
+			}
+		})
+	}
+}
@@ -0,0 +1,482 @@
+// Package graphql is a small GraphQL server: a parser for request documents
+// and an executor over a schema of object types whose fields are resolved in
+// batches. There is no introspection; Schema.SDL prints the schema instead.
+//
+// A field's resolver is called once per level of the response with every
+// parent object at that level, so a field such as Subject.topics can load
+// the topics of all the subjects in the list with a single query. Loader
+// adds a per-request cache on top for lookups by ID.
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Built-in scalars. IDs are the numeric row IDs: they are read as ints and
+// written as strings. JSON passes any value through unchanged.
+var scalars = map[string]bool{"ID": true, "Int": true, "Float": true, "String": true, "Boolean": true, "JSON": true}
+
+// Schema is a set of object and input types with Query and Mutation roots
+type Schema struct {
+	Query    *Object
+	Mutation *Object
+	// FormatError turns a resolver's error into the message and extensions
+	// of the response error. Without it the error's text is used.
+	FormatError func(err error) (string, map[string]interface{})
+
+	objects map[string]*Object
+	inputs  map[string]*Input
+	order   []string
+}
+
+// Object is an output type
+type Object struct {
+	Name        string
+	Description string
+	Fields      []*FieldDef
+
+	fields map[string]*FieldDef
+}
+
+// FieldDef defines a field of an object. Type is written as in a schema,
+// e.g. "[Topic!]!". A field without a resolver reads the matching property
+// of its parent, see Property.
+type FieldDef struct {
+	Name        string
+	Type        string
+	Description string
+	Args        []*Arg
+	Resolve     Resolver
+}
+
+// Arg is an argument of a field or a field of an input type. A nil Default
+// means none.
+type Arg struct {
+	Name        string
+	Type        string
+	Description string
+	Default     interface{}
+}
+
+// Input is an input object type
+type Input struct {
+	Name        string
+	Description string
+	Fields      []*Arg
+}
+
+// Resolver resolves a field for every parent at one level of the response
+// at once. It returns one value per parent, in the same order.
+type Resolver func(parents []interface{}, args Args) ([]interface{}, error)
+
+// Each adapts a function that resolves a field for a single parent
+func Each(fn func(parent interface{}, args Args) (interface{}, error)) Resolver {
+	return func(parents []interface{}, args Args) ([]interface{}, error) {
+		values := make([]interface{}, len(parents))
+		for i, parent := range parents {
+			value, err := fn(parent, args)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+		return values, nil
+	}
+}
+
+// NewSchema builds a schema from its roots and the other types it uses. It
+// panics if a field refers to a type that is not defined, as that is a
+// programming error.
+func NewSchema(query, mutation *Object, objects []*Object, inputs []*Input) *Schema {
+	s := &Schema{Query: query, Mutation: mutation, objects: map[string]*Object{}, inputs: map[string]*Input{}}
+	for _, object := range append([]*Object{query, mutation}, objects...) {
+		if object == nil {
+			continue
+		}
+		object.fields = map[string]*FieldDef{}
+		for _, field := range object.Fields {
+			object.fields[field.Name] = field
+			if field.Resolve == nil {
+				field.Resolve = Property(field.Name)
+			}
+		}
+		s.objects[object.Name] = object
+		s.order = append(s.order, object.Name)
+	}
+	for _, input := range inputs {
+		s.inputs[input.Name] = input
+		s.order = append(s.order, input.Name)
+	}
+
+	for _, object := range s.objects {
+		for _, field := range object.Fields {
+			s.mustExist(field.Type, false)
+			for _, arg := range field.Args {
+				s.mustExist(arg.Type, true)
+			}
+		}
+	}
+	for _, input := range s.inputs {
+		for _, field := range input.Fields {
+			s.mustExist(field.Type, true)
+		}
+	}
+	return s
+}
+
+func (s *Schema) mustExist(typ string, input bool) {
+	name := namedType(typ)
+	if scalars[name] || (input && s.inputs[name] != nil) || (!input && s.objects[name] != nil) {
+		return
+	}
+	panic(fmt.Sprintf("graphql: unknown type %q", typ))
+}
+
+// namedType strips the list and non-null wrappers from a type
+func namedType(typ string) string {
+	return strings.Trim(typ, "[]!")
+}
+
+// unwrapList returns the item type of a list type
+func unwrapList(typ string) (string, bool) {
+	typ = strings.TrimSuffix(typ, "!")
+	if strings.HasPrefix(typ, "[") {
+		return typ[1 : len(typ)-1], true
+	}
+	return typ, false
+}
+
+// Property resolves a field from its parent's struct field or map entry of
+// the same name. The camelCase field name matches a snake_case json tag, so
+// subjectId reads the field tagged json:"subject_id".
+func Property(name string) Resolver {
+	key := snakeCase(name)
+	return Each(func(parent interface{}, _ Args) (interface{}, error) {
+		v := reflect.ValueOf(parent)
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return nil, nil
+			}
+			v = v.Elem()
+		}
+		switch v.Kind() {
+		case reflect.Map:
+			if value := v.MapIndex(reflect.ValueOf(key)); value.IsValid() {
+				return value.Interface(), nil
+			}
+			return nil, nil
+		case reflect.Struct:
+			if value, ok := structField(v, key); ok {
+				return value.Interface(), nil
+			}
+		}
+		return nil, fmt.Errorf("graphql: %s has no property %q", v.Type(), key)
+	})
+}
+
+// structField finds a field by json name, including in embedded structs
+func structField(v reflect.Value, key string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			if value, ok := structField(v.Field(i), key); ok {
+				return value, true
+			}
+			continue
+		}
+		if name == key || (name == "" && strings.EqualFold(field.Name, strings.ReplaceAll(key, "_", ""))) {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// snakeCase converts a GraphQL name to the API's JSON naming, e.g.
+// subjectId to subject_id
+func snakeCase(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// CamelCase converts a JSON name to GraphQL naming, e.g. subject_id to subjectId
+func CamelCase(name string) string {
+	parts := strings.Split(name, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// Args are a field's arguments, coerced to their declared types: ID and Int
+// arguments are ints, Float arguments float64, lists []interface{} and input
+// objects map[string]interface{}. An argument that was not given and has no
+// default is absent; one given as null is present with a nil value.
+type Args map[string]interface{}
+
+// Int returns an ID or Int argument and whether it was given
+func (a Args) Int(name string) (int, bool) {
+	n, ok := a[name].(int)
+	return n, ok
+}
+
+// String returns a String argument, or "" if it was not given
+func (a Args) String(name string) string {
+	s, _ := a[name].(string)
+	return s
+}
+
+// Bool returns a Boolean argument and whether it was given
+func (a Args) Bool(name string) (bool, bool) {
+	b, ok := a[name].(bool)
+	return b, ok
+}
+
+// JSON encodes an input object argument with the API's snake_case member
+// names, so it can be decoded into a model struct or used as a merge patch.
+// Members that were not given are left out and null members are kept.
+func (a Args) JSON(name string) ([]byte, error) {
+	return json.Marshal(snakeKeys(a[name]))
+}
+
+func snakeKeys(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, member := range v {
+			converted[snakeCase(key)] = snakeKeys(member)
+		}
+		return converted
+	case []interface{}:
+		converted := make([]interface{}, len(v))
+		for i, item := range v {
+			converted[i] = snakeKeys(item)
+		}
+		return converted
+	}
+	return value
+}
+
+// coerce checks a value against a type and converts it to the form resolvers
+// receive, as described on Args
+func (s *Schema) coerce(value interface{}, typ string) (interface{}, error) {
+	if strings.HasSuffix(typ, "!") {
+		if value == nil {
+			return nil, fmt.Errorf("Expected value of type %q, found null", typ)
+		}
+		typ = strings.TrimSuffix(typ, "!")
+	}
+	if value == nil {
+		return nil, nil
+	}
+
+	if item, isList := unwrapList(typ); isList {
+		list, ok := value.([]interface{})
+		if !ok {
+			list = []interface{}{value}
+		}
+		coerced := make([]interface{}, len(list))
+		for i, element := range list {
+			var err error
+			if coerced[i], err = s.coerce(element, item); err != nil {
+				return nil, err
+			}
+		}
+		return coerced, nil
+	}
+
+	if input := s.inputs[typ]; input != nil {
+		return s.coerceInput(value, input)
+	}
+	return coerceScalar(value, typ)
+}
+
+func (s *Schema) coerceInput(value interface{}, input *Input) (interface{}, error) {
+	members, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Expected type %q to be an object", input.Name)
+	}
+	fields := map[string]*Arg{}
+	for _, field := range input.Fields {
+		fields[field.Name] = field
+	}
+
+	coerced := map[string]interface{}{}
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		field := fields[name]
+		if field == nil {
+			return nil, fmt.Errorf("Field %q is not defined by type %q", name, input.Name)
+		}
+		member, err := s.coerce(members[name], field.Type)
+		if err != nil {
+			return nil, fmt.Errorf("In field %q: %s", name, err)
+		}
+		coerced[name] = member
+	}
+	for _, field := range input.Fields {
+		if _, given := coerced[field.Name]; given {
+			continue
+		}
+		if field.Default != nil {
+			coerced[field.Name] = field.Default
+		} else if strings.HasSuffix(field.Type, "!") {
+			return nil, fmt.Errorf("Field %q of required type %q was not provided", field.Name, field.Type)
+		}
+	}
+	return coerced, nil
+}
+
+func coerceScalar(value interface{}, typ string) (interface{}, error) {
+	if _, isEnum := value.(EnumValue); isEnum {
+		return nil, fmt.Errorf("%s cannot represent enum value %s", typ, value)
+	}
+
+	switch typ {
+	case "ID":
+		if s, ok := value.(string); ok {
+			if n, err := strconv.Atoi(s); err == nil {
+				return n, nil
+			}
+		} else if n, ok := integer(value); ok {
+			return n, nil
+		}
+		return nil, fmt.Errorf("ID cannot represent %s", describe(value))
+	case "Int":
+		if n, ok := integer(value); ok {
+			return n, nil
+		}
+		return nil, fmt.Errorf("Int cannot represent non-integer value %s", describe(value))
+	case "Float":
+		switch v := value.(type) {
+		case int:
+			return float64(v), nil
+		case float64:
+			return v, nil
+		}
+		return nil, fmt.Errorf("Float cannot represent non-numeric value %s", describe(value))
+	case "String":
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+		return nil, fmt.Errorf("String cannot represent a non string value %s", describe(value))
+	case "Boolean":
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+		return nil, fmt.Errorf("Boolean cannot represent a non boolean value %s", describe(value))
+	}
+	return value, nil
+}
+
+// integer accepts an int, or a float64 with an integral value as decoded
+// from JSON variables
+func integer(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) <= math.MaxInt32 {
+			return int(v), true
+		}
+	}
+	return 0, false
+}
+
+func describe(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// serialize writes a resolved scalar value for the response
+func serialize(value interface{}, typ string) interface{} {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+		value = v.Interface()
+	}
+	if typ == "ID" {
+		return fmt.Sprint(value)
+	}
+	return value
+}
+
+// SDL prints the schema in the GraphQL schema definition language
+func (s *Schema) SDL() string {
+	var b strings.Builder
+	b.WriteString("scalar JSON\n")
+	for _, name := range s.order {
+		b.WriteByte('\n')
+		if object := s.objects[name]; object != nil {
+			writeDescription(&b, "", object.Description)
+			fmt.Fprintf(&b, "type %s {\n", object.Name)
+			for _, field := range object.Fields {
+				writeDescription(&b, "  ", field.Description)
+				fmt.Fprintf(&b, "  %s%s: %s\n", field.Name, sdlArgs(field.Args), field.Type)
+			}
+		} else {
+			input := s.inputs[name]
+			writeDescription(&b, "", input.Description)
+			fmt.Fprintf(&b, "input %s {\n", input.Name)
+			for _, field := range input.Fields {
+				writeDescription(&b, "  ", field.Description)
+				fmt.Fprintf(&b, "  %s\n", sdlArg(field))
+			}
+		}
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
+
+func writeDescription(b *strings.Builder, indent, description string) {
+	if description != "" {
+		fmt.Fprintf(b, "%s%s\n", indent, strconv.Quote(description))
+	}
+}
+
+func sdlArgs(args []*Arg) string {
+	if len(args) == 0 {
+		return ""
+	}
+	written := make([]string, len(args))
+	for i, arg := range args {
+		written[i] = sdlArg(arg)
+	}
+	return "(" + strings.Join(written, ", ") + ")"
+}
+
+func sdlArg(arg *Arg) string {
+	if arg.Default != nil {
+		return fmt.Sprintf("%s: %s = %s", arg.Name, arg.Type, describe(arg.Default))
+	}
+	return fmt.Sprintf("%s: %s", arg.Name, arg.Type)
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"exam-prep/audit"
+	"exam-prep/database"
+	"exam-prep/models"
+	"exam-prep/utils"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// entityTables maps audited entity types to their tables
+var entityTables = map[string]string{
+	"subject":        "subjects",
+	"topic":          "topics",
+	"note":           "notes",
+	"study_plan":     "study_plan",
+	"plan_rebalance": "plan_rebalances",
+	"plan_template":  "plan_templates",
+}
+
+// snapshot loads the current state of an entity for the audit log
+func snapshot(c *gin.Context, entityType string, id int) json.RawMessage {
+	data, err := database.Snapshot(c.Request.Context(), entityTables[entityType], id)
+	if err != nil {
+		slog.Warn("Failed to snapshot entity", "entity_type", entityType, "entity_id", id, "error", err)
+	}
+	return data
+}
+
+// recordActivity writes an audit entry, queues webhook deliveries and
+// publishes a domain event for a mutation that has just succeeded. before is
+// the entity's state prior to the change; the state after the change is
+// loaded here unless the entity was deleted. Failures are logged rather than
+// returned so auditing never breaks the request itself.
+func recordActivity(c *gin.Context, entityType string, id int, action string, before json.RawMessage) {
+	var after json.RawMessage
+	if action != audit.Delete {
+		after = snapshot(c, entityType, id)
+	}
+	audit.Record(c.Request.Context(), utils.Actor(c), entityType, id, action, before, after)
+}
+
+// recordBulkActivity records a bulk change to the study plan as a single
+// audit entry and event, whatever the number of entries it touched. The
+// entry's after data is the bulk result, listing the affected entries.
+func recordBulkActivity(c *gin.Context, action string, result *models.BulkResult) {
+	after, _ := json.Marshal(result)
+	audit.Record(c.Request.Context(), utils.Actor(c), "plan_bulk", 0, action, nil, after)
+}
+
+// GetActivity returns the activity log, newest first. It can be filtered by
+// entity_type, entity_id, action, actor and a from/to date range.
+func GetActivity(c *gin.Context) {
+	query := `
+		SELECT id, actor, entity_type, entity_id, action, before_data, after_data, created_at
+		FROM activity_log
+		WHERE 1 = 1`
+	args := []interface{}{}
+
+	// addFilter appends a condition whose %d is replaced by the argument's placeholder number
+	addFilter := func(clause string, value interface{}) {
+		args = append(args, value)
+		query += " AND " + fmt.Sprintf(clause, len(args))
+	}
+
+	if entityType := c.Query("entity_type"); entityType != "" {
+		addFilter("entity_type = $%d", entityType)
+	}
+	if entityID := c.Query("entity_id"); entityID != "" {
+		id, err := strconv.Atoi(entityID)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid entity_id")
+			return
+		}
+		addFilter("entity_id = $%d", id)
+	}
+	if action := c.Query("action"); action != "" {
+		addFilter("action = $%d", action)
+	}
+	if actor := c.Query("actor"); actor != "" {
+		addFilter("actor = $%d", actor)
+	}
+	if from := c.Query("from"); from != "" {
+		addFilter("created_at >= CAST($%d AS DATE)", from)
+	}
+	if to := c.Query("to"); to != "" {
+		addFilter("created_at < CAST($%d AS DATE) + 1", to)
+	}
+
+	limit := 50
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 500 {
+			utils.ErrorResponse(c, http.StatusBadRequest, "limit must be between 1 and 500")
+			return
+		}
+		limit = parsed
+	}
+	args = append(args, limit)
+	query += " ORDER BY created_at DESC, id DESC LIMIT $" + strconv.Itoa(len(args))
+
+	activities, err := queryActivity(c.Request.Context(), query, args...)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Activity retrieved", activities)
+}
+
+// queryActivity runs an activity_log query and scans the results
+func queryActivity(ctx context.Context, query string, args ...interface{}) ([]models.Activity, error) {
+	rows, err := database.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var activities []models.Activity
+	for rows.Next() {
+		var a models.Activity
+		var before, after []byte
+		err := rows.Scan(&a.ID, &a.Actor, &a.EntityType, &a.EntityID, &a.Action, &before, &after, &a.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		a.Before = before
+		a.After = after
+		activities = append(activities, a)
+	}
+	return activities, rows.Err()
+}
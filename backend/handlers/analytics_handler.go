@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"exam-prep/database"
+	"exam-prep/models"
+	"exam-prep/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxAnalyticsDays bounds the range of a single analytics request
+const maxAnalyticsDays = 366
+
+// ratioSQL computes completed/planned as a fraction, 0 when nothing was planned
+const ratioSQL = `CASE WHEN SUM(sp.hours_planned) > 0 THEN ROUND(SUM(sp.hours_completed) / SUM(sp.hours_planned), 3) ELSE 0 END`
+
+// planRangeSQL selects live study plan rows in [$1, $2], optionally for one subject ($3 = 0 for all)
+const planRangeSQL = `
+	FROM study_plan sp
+	JOIN subjects s ON sp.subject_id = s.id
+	WHERE sp.deleted_at IS NULL AND s.deleted_at IS NULL
+	  AND sp.study_date BETWEEN $1 AND $2
+	  AND ($3 = 0 OR sp.subject_id = $3)`
+
+// GetTimeAnalytics returns planned vs completed hours between from and to,
+// grouped by subject, ISO week and weekday, plus a per-day heatmap series.
+// The range defaults to the 30 days ending today.
+func GetTimeAnalytics(c *gin.Context) {
+	now := utils.Now(c)
+	from := now.AddDate(0, 0, -29).Format("2006-01-02")
+	to := now.Format("2006-01-02")
+	if value := c.Query("from"); value != "" {
+		from = value
+	}
+	if value := c.Query("to"); value != "" {
+		to = value
+	}
+
+	if msg := validateDateRange(from, to); msg != "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, msg)
+		return
+	}
+	fromDate, _ := time.Parse("2006-01-02", from)
+	toDate, _ := time.Parse("2006-01-02", to)
+	if utils.DaysBetween(fromDate, toDate) >= maxAnalyticsDays {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Date range cannot exceed 366 days")
+		return
+	}
+
+	subjectID := 0
+	if value := c.Query("subject_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid subject_id")
+			return
+		}
+		subjectID = id
+	}
+
+	analytics := models.TimeAnalytics{From: from, To: to}
+	args := []interface{}{from, to, subjectID}
+
+	queries := []struct {
+		target *[]models.TimeBucket
+		query  string
+	}{
+		{&analytics.BySubject, `
+			SELECT s.id::TEXT, s.name, COALESCE(SUM(sp.hours_planned), 0), COALESCE(SUM(sp.hours_completed), 0), ` + ratioSQL +
+			planRangeSQL + `
+			GROUP BY s.id, s.name
+			ORDER BY s.id`},
+		{&analytics.ByWeek, `
+			SELECT TO_CHAR(sp.study_date, 'IYYY-"W"IW'), TO_CHAR(MIN(sp.study_date), 'YYYY-MM-DD'),
+				   SUM(sp.hours_planned), SUM(sp.hours_completed), ` + ratioSQL +
+			planRangeSQL + `
+			GROUP BY 1
+			ORDER BY 1`},
+		{&analytics.ByWeekday, `
+			SELECT EXTRACT(ISODOW FROM sp.study_date)::TEXT, TRIM(TO_CHAR(MIN(sp.study_date), 'Day')),
+				   SUM(sp.hours_planned), SUM(sp.hours_completed), ` + ratioSQL +
+			planRangeSQL + `
+			GROUP BY 1
+			ORDER BY 1`},
+		{&analytics.Heatmap, `
+			SELECT TO_CHAR(d.day, 'YYYY-MM-DD'), TO_CHAR(d.day, 'Dy'),
+				   COALESCE(SUM(sp.hours_planned), 0), COALESCE(SUM(sp.hours_completed), 0),
+				   CASE WHEN SUM(sp.hours_planned) > 0 THEN ROUND(SUM(sp.hours_completed) / SUM(sp.hours_planned), 3) ELSE 0 END
+			FROM GENERATE_SERIES($1::DATE, $2::DATE, INTERVAL '1 day') AS d(day)
+			LEFT JOIN study_plan sp ON sp.study_date = d.day::DATE AND sp.deleted_at IS NULL AND ($3 = 0 OR sp.subject_id = $3)
+				AND EXISTS (SELECT 1 FROM subjects s WHERE s.id = sp.subject_id AND s.deleted_at IS NULL)
+			GROUP BY d.day
+			ORDER BY d.day`},
+	}
+
+	for _, q := range queries {
+		buckets, err := queryTimeBuckets(c.Request.Context(), q.query, args...)
+		if err != nil {
+			utils.Fail(c, err)
+			return
+		}
+		*q.target = buckets
+	}
+
+	analytics.Totals = models.TimeBucket{Key: "total", Label: "Total"}
+	err := database.DB.QueryRowContext(c.Request.Context(), `
+		SELECT COALESCE(SUM(sp.hours_planned), 0), COALESCE(SUM(sp.hours_completed), 0), `+ratioSQL+
+		planRangeSQL, args...,
+	).Scan(&analytics.Totals.HoursPlanned, &analytics.Totals.HoursCompleted, &analytics.Totals.CompletionRatio)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Time analytics retrieved", analytics)
+}
+
+// queryTimeBuckets scans rows of key, label, planned, completed, ratio
+func queryTimeBuckets(ctx context.Context, query string, args ...interface{}) ([]models.TimeBucket, error) {
+	rows, err := database.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	buckets := []models.TimeBucket{}
+	for rows.Next() {
+		var b models.TimeBucket
+		var ratio sql.NullFloat64
+		if err := rows.Scan(&b.Key, &b.Label, &b.HoursPlanned, &b.HoursCompleted, &ratio); err != nil {
+			return nil, err
+		}
+		b.CompletionRatio = ratio.Float64
+		buckets = append(buckets, b)
+	}
+	return buckets, rows.Err()
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"database/sql"
+	"exam-prep/audit"
+	"exam-prep/models"
+	"exam-prep/planner"
+	"exam-prep/store"
+	"exam-prep/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RunBatch runs an ordered list of creates, updates and deletes on subjects,
+// topics, notes and study plan entries in one transaction. Either every
+// operation is applied or, on the first failure, none is; the error names
+// the failing operation. Operations can refer to IDs created earlier in the
+// batch with "$ref".
+func RunBatch(c *gin.Context) {
+	var input models.BatchInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	examDate, _ := utils.ExamDate()
+	var results []models.BatchResult
+	var changes []store.Change
+	err := inTx(c.Request.Context(), func(tx *sql.Tx) (err error) {
+		results, changes, err = store.RunBatch(c.Request.Context(), tx, input.Operations, planner.DailyCap(), examDate)
+		return err
+	})
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	for _, change := range changes {
+		recordActivity(c, change.Resource, change.ID, batchActions[change.Method], change.Before)
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Batch completed", models.BatchResponse{Results: results})
+}
+
+// batchActions maps batch methods to activity log actions
+var batchActions = map[string]string{
+	store.MethodCreate: audit.Create,
+	store.MethodUpdate: audit.Update,
+	store.MethodDelete: audit.Delete,
+}
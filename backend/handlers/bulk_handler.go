@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"errors"
+	"exam-prep/audit"
+	"exam-prep/models"
+	"exam-prep/planner"
+	"exam-prep/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ShiftStudyPlans moves all entries in a date range by a number of days
+func ShiftStudyPlans(c *gin.Context) {
+	var input models.ShiftStudyPlanInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	if msg := validateDateRange(input.From, input.To); msg != "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, msg)
+		return
+	}
+
+	result, err := planner.Shift(c.Request.Context(), input.From, input.To, input.Days)
+	respondBulk(c, result, err, audit.Update, "Study plans shifted")
+}
+
+// CopyStudyPlanWeek copies one week's entries onto another week
+func CopyStudyPlanWeek(c *gin.Context) {
+	var input models.CopyWeekInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	_, errSource := time.Parse("2006-01-02", input.SourceWeekStart)
+	_, errTarget := time.Parse("2006-01-02", input.TargetWeekStart)
+	if errSource != nil || errTarget != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Week start dates must be in YYYY-MM-DD format")
+		return
+	}
+
+	examDate, _ := utils.ExamDate()
+	result, err := planner.CopyWeek(c.Request.Context(), input.SourceWeekStart, input.TargetWeekStart, planner.DailyCap(), examDate)
+	respondBulk(c, result, err, audit.Create, "Study plan week copied")
+}
+
+// DeleteStudyPlanRange moves all entries in a date range to the trash
+func DeleteStudyPlanRange(c *gin.Context) {
+	var input models.DateRangeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	if msg := validateDateRange(input.From, input.To); msg != "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, msg)
+		return
+	}
+
+	result, err := planner.DeleteRange(c.Request.Context(), input.From, input.To)
+	respondBulk(c, result, err, audit.Delete, "Study plans moved to trash")
+}
+
+// BatchCreateStudyPlans creates several entries in one transaction
+func BatchCreateStudyPlans(c *gin.Context) {
+	var input models.BatchCreateStudyPlanInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	examDate, _ := utils.ExamDate()
+	result, err := planner.BatchCreate(c.Request.Context(), input.Entries, planner.DailyCap(), examDate)
+	if errors.Is(err, planner.ErrSubjectNotFound) {
+		utils.ErrorResponse(c, http.StatusNotFound, "Subject not found")
+		return
+	}
+	respondBulk(c, result, err, audit.Create, "Study plans created")
+}
+
+// respondBulk records the operation in the activity log and sends the
+// summary. Entries that failed the plan checks are reported with their issues.
+func respondBulk(c *gin.Context, result *models.BulkResult, err error, action, message string) {
+	var issuesErr *planner.IssuesError
+	if errors.As(err, &issuesErr) {
+		utils.Fail(c, &utils.AppError{
+			Status:  http.StatusUnprocessableEntity,
+			Code:    utils.CodeValidationFailed,
+			Message: issuesErr.Error(),
+			Data:    issuesErr.Issues,
+		})
+		return
+	}
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	recordBulkActivity(c, action, result)
+	utils.SuccessResponse(c, http.StatusOK, message, result)
+}
+
+// validateDateRange checks that from and to are dates and in order
+func validateDateRange(from, to string) string {
+	fromDate, errFrom := time.Parse("2006-01-02", from)
+	toDate, errTo := time.Parse("2006-01-02", to)
+	if errFrom != nil || errTo != nil {
+		return "from and to must be in YYYY-MM-DD format"
+	}
+	if toDate.Before(fromDate) {
+		return "to must not be before from"
+	}
+	return ""
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"errors"
+	"exam-prep/audit"
+	"exam-prep/models"
+	"exam-prep/planner"
+	"exam-prep/utils"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetTopicDependencies returns the prerequisites of a topic
+func GetTopicDependencies(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid topic ID")
+		return
+	}
+
+	prerequisites, err := planner.Prerequisites(c.Request.Context(), id)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Prerequisites retrieved", prerequisites)
+}
+
+// AddTopicDependency makes another topic of the same subject a prerequisite.
+// Links that would create a cycle are rejected with the cycle in the response.
+func AddTopicDependency(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid topic ID")
+		return
+	}
+
+	var input models.CreateTopicDependencyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	dependency, err := planner.AddDependency(c.Request.Context(), id, input.PrerequisiteID)
+	var cycle *planner.CycleError
+	switch {
+	case errors.As(err, &cycle):
+		utils.ErrorResponseWithData(c, http.StatusConflict, cycle.Error(), gin.H{"cycle": cycle.Path})
+		return
+	case errors.Is(err, planner.ErrTopicNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, "Topic not found")
+		return
+	case errors.Is(err, planner.ErrCrossSubject):
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		utils.Fail(c, err)
+		return
+	}
+
+	if dependency == nil {
+		utils.SuccessResponse(c, http.StatusOK, "Prerequisite already exists", nil)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusCreated, "Prerequisite added", dependency)
+}
+
+// RemoveTopicDependency removes a prerequisite from a topic
+func RemoveTopicDependency(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid topic ID")
+		return
+	}
+	prerequisiteID, err := strconv.Atoi(c.Param("prerequisiteId"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid prerequisite ID")
+		return
+	}
+
+	removed, err := planner.RemoveDependency(c.Request.Context(), id, prerequisiteID)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	if !removed {
+		utils.ErrorResponse(c, http.StatusNotFound, "Prerequisite not found")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Prerequisite removed", nil)
+}
+
+// GetTopicOrder returns a subject's topics in dependency-aware study order
+func GetTopicOrder(c *gin.Context) {
+	subjectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid subject ID")
+		return
+	}
+
+	order, err := planner.TopicOrder(c.Request.Context(), subjectID)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Topic order retrieved", order)
+}
+
+// GenerateStudyPlan schedules a subject's incomplete topics in dependency
+// order between the start date and the subject's exam
+func GenerateStudyPlan(c *gin.Context) {
+	var input models.GenerateStudyPlanInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	from := utils.Now(c)
+	if input.From != "" {
+		parsed, err := time.Parse("2006-01-02", input.From)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "from must be in YYYY-MM-DD format")
+			return
+		}
+		from = parsed
+	}
+
+	if input.HoursPerTopic == 0 {
+		input.HoursPerTopic = 1
+	}
+	if input.HoursPerDay == 0 {
+		input.HoursPerDay = 2
+	}
+	if input.HoursPerTopic < 0 || input.HoursPerDay < 0 || input.HoursPerTopic > input.HoursPerDay {
+		utils.ErrorResponse(c, http.StatusBadRequest, "hours_per_topic must be positive and no more than hours_per_day")
+		return
+	}
+	dailyCap := planner.DailyCap()
+	if input.HoursPerDay > dailyCap {
+		utils.ErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("hours_per_day cannot exceed the daily cap of %.1f", dailyCap))
+		return
+	}
+
+	examDate, _ := utils.ExamDate()
+	result, err := planner.Generate(c.Request.Context(), input.SubjectID, from, input.HoursPerTopic, input.HoursPerDay, dailyCap, examDate)
+	if errors.Is(err, planner.ErrSubjectNotFound) {
+		utils.ErrorResponse(c, http.StatusNotFound, "Subject not found")
+		return
+	}
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	recordBulkActivity(c, audit.Create, &result.BulkResult)
+	utils.SuccessResponse(c, http.StatusOK, "Study plan generated", result)
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"exam-prep/audit"
+	"exam-prep/database"
+	"exam-prep/events"
+	"exam-prep/utils"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// keepAliveInterval keeps idle SSE connections open through proxies
+const keepAliveInterval = 25 * time.Second
+
+// replayLimit is how many activity log entries a reconnecting client can
+// catch up on
+const replayLimit = 1000
+
+// replayEvents loads the events after lastID that match the filter from the
+// activity log, oldest first. The filter needs the entries' data, so it is
+// applied after the limit: when more than replayLimit entries follow lastID,
+// a single reset event at the latest entry is returned instead, and the
+// client resyncs rather than silently missing events.
+func replayEvents(ctx context.Context, filter events.Filter, lastID int64) ([]events.Event, error) {
+	activities, err := queryActivity(ctx, `
+		SELECT id, actor, entity_type, entity_id, action, before_data, after_data, created_at
+		FROM activity_log
+		WHERE id > $1
+		ORDER BY id
+		LIMIT $2`, lastID, replayLimit+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(activities) > replayLimit {
+		var latest int64
+		err := database.DB.QueryRowContext(ctx, "SELECT MAX(id) FROM activity_log").Scan(&latest)
+		if err != nil {
+			return nil, err
+		}
+		return []events.Event{{ID: latest, Type: events.TypeReset, Actor: "system", Time: time.Now()}}, nil
+	}
+
+	var replay []events.Event
+	for _, a := range activities {
+		if e := audit.Event(a); filter.Match(e) {
+			replay = append(replay, e)
+		}
+	}
+	return replay, nil
+}
+
+// StreamEvents streams domain events as Server-Sent Events. Events can be
+// filtered with subject_id, actor and a comma separated types list. Clients
+// that reconnect with Last-Event-ID (or last_event_id) receive the events
+// they missed, replayed from the activity log, even across server restarts;
+// those that missed too many receive a reset event and must reload instead.
+func StreamEvents(c *gin.Context) {
+	var filter events.Filter
+
+	if value := c.Query("subject_id"); value != "" {
+		subjectID, err := strconv.Atoi(value)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid subject_id")
+			return
+		}
+		filter.SubjectID = subjectID
+	}
+	filter.Actor = c.Query("actor")
+	if value := c.Query("types"); value != "" {
+		filter.Types = map[string]bool{}
+		for _, t := range strings.Split(value, ",") {
+			filter.Types[strings.TrimSpace(t)] = true
+		}
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	var lastID int64
+	if lastEventID != "" {
+		parsed, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid Last-Event-ID")
+			return
+		}
+		lastID = parsed
+	}
+
+	// Subscribe before loading the replay so nothing falls between the two;
+	// events in both are sent once, from the replay
+	sub := events.Subscribe(filter)
+	defer events.Unsubscribe(sub)
+
+	var replay []events.Event
+	if lastID > 0 {
+		var err error
+		replay, err = replayEvents(c.Request.Context(), filter, lastID)
+		if err != nil {
+			utils.Fail(c, err)
+			return
+		}
+	}
+	replayedTo := lastID
+	for _, e := range replay {
+		replayedTo = max(replayedTo, e.ID)
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	for _, e := range replay {
+		writeEvent(c.Writer, e)
+	}
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case e, ok := <-sub.C:
+			if !ok {
+				return false
+			}
+			if e.ID > replayedTo {
+				writeEvent(w, e)
+			}
+		case <-keepAlive.C:
+			io.WriteString(w, ": keep-alive\n\n")
+		}
+		return true
+	})
+}
+
+// writeEvent writes a single SSE frame. An event without an ID is sent
+// without an id field, so the client keeps its last event ID.
+func writeEvent(w io.Writer, e events.Event) {
+	data, _ := json.Marshal(e)
+	if e.ID != 0 {
+		fmt.Fprintf(w, "id: %d\n", e.ID)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data)
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"encoding/json"
+	"exam-prep/graphql"
+	"exam-prep/telemetry"
+	"exam-prep/utils"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxGraphQLBody caps the size of a POSTed GraphQL request
+const maxGraphQLBody = 1 << 20
+
+// GraphQL runs a GraphQL query or mutation over subjects, topics, notes,
+// study plan entries and the dashboard. POST takes a JSON body with query,
+// variables and operationName; GET takes the same as URL parameters and only
+// runs queries. The response is a standard GraphQL response rather than the
+// API envelope, and prerequisite warnings go in extensions.warnings.
+func GraphQL(c *gin.Context) {
+	var req graphql.Request
+	if c.Request.Method == http.MethodGet {
+		req.Query, req.OperationName, req.ReadOnly = c.Query("query"), c.Query("operationName"), true
+		if variables := c.Query("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				graphQLRequestError(c, http.StatusBadRequest, utils.CodeBadRequest, "variables must be a JSON object")
+				return
+			}
+		}
+	} else {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxGraphQLBody)
+		if err := c.ShouldBindJSON(&req); err != nil {
+			appErr := utils.AsAppError(err)
+			graphQLRequestError(c, appErr.Status, appErr.Code, appErr.Message)
+			return
+		}
+	}
+	if req.Query == "" {
+		graphQLRequestError(c, http.StatusBadRequest, utils.CodeBadRequest, "query is required")
+		return
+	}
+
+	resolver := newGraphQLResolver(c)
+	response := resolver.schema().Execute(req)
+	if len(resolver.warnings) > 0 {
+		response.Extensions = map[string]interface{}{"warnings": resolver.warnings}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetGraphQLSchema returns the GraphQL schema in SDL, in place of introspection
+func GetGraphQLSchema(c *gin.Context) {
+	c.String(http.StatusOK, newGraphQLResolver(c).schema().SDL())
+}
+
+// graphQLRequestError responds to a request that is not a GraphQL request
+func graphQLRequestError(c *gin.Context, status int, code, message string) {
+	c.JSON(status, graphql.Response{Errors: []*graphql.Error{{
+		Message: message,
+		Extensions: map[string]interface{}{
+			"code":      code,
+			"requestId": telemetry.RequestID(c.Request.Context()),
+		},
+	}}})
+}
+
+// formatError reports a resolver's error with the same code, field details
+// and request ID as the REST API, naming fields the GraphQL way
+func (r *graphQLResolver) formatError(err error) (string, map[string]interface{}) {
+	ctx := r.c.Request.Context()
+	appErr := utils.AsAppError(err)
+	if appErr.Status >= http.StatusInternalServerError {
+		slog.ErrorContext(ctx, "GraphQL resolver failed", "error", err)
+		telemetry.SetError(ctx, err)
+	}
+
+	extensions := map[string]interface{}{"code": appErr.Code, "requestId": telemetry.RequestID(ctx)}
+	if len(appErr.Fields) > 0 {
+		details := make([]utils.FieldError, len(appErr.Fields))
+		for i, field := range appErr.Fields {
+			details[i] = field
+			details[i].Field = graphql.CamelCase(field.Field)
+		}
+		extensions["details"] = details
+	}
+	if appErr.Data != nil {
+		extensions["data"] = appErr.Data
+	}
+	return appErr.Message, extensions
+}
@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"context"
+	"exam-prep/database"
+	"exam-prep/graphql"
+	"exam-prep/models"
+	"exam-prep/store"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// graphQLLoaders batch the GraphQL schema's lookups. Each relation is loaded
+// for every parent at one level with a single ANY($1) query and cached for
+// the rest of the request.
+type graphQLLoaders struct {
+	subjects        *graphql.Loader
+	topics          *graphql.Loader
+	notes           *graphql.Loader
+	plans           *graphql.Loader
+	topicsBySubject *graphql.Loader
+	notesBySubject  *graphql.Loader
+	notesByTopic    *graphql.Loader
+	plansBySubject  *graphql.Loader
+}
+
+func newGraphQLLoaders(ctx context.Context) *graphQLLoaders {
+	return &graphQLLoaders{
+		subjects: graphql.NewLoader(func(ids []int) (map[int]interface{}, error) {
+			subjects, err := querySubjects(ctx, "WHERE id = ANY($1) AND deleted_at IS NULL", pq.Array(ids))
+			found := map[int]interface{}{}
+			for _, s := range subjects {
+				found[s.ID] = s
+			}
+			return found, err
+		}),
+		topics: graphql.NewLoader(func(ids []int) (map[int]interface{}, error) {
+			topics, err := queryTopics(ctx, "WHERE id = ANY($1) AND deleted_at IS NULL", pq.Array(ids))
+			found := map[int]interface{}{}
+			for _, t := range topics {
+				found[t.ID] = t
+			}
+			return found, err
+		}),
+		notes: graphql.NewLoader(func(ids []int) (map[int]interface{}, error) {
+			notes, err := queryNotes(ctx, "WHERE id = ANY($1) AND deleted_at IS NULL", pq.Array(ids))
+			found := map[int]interface{}{}
+			for _, n := range notes {
+				found[n.ID] = n
+			}
+			return found, err
+		}),
+		plans: graphql.NewLoader(func(ids []int) (map[int]interface{}, error) {
+			plans, err := queryStudyPlans(ctx, "WHERE sp.id = ANY($1) AND sp.deleted_at IS NULL", pq.Array(ids))
+			found := map[int]interface{}{}
+			for _, sp := range plans {
+				found[sp.ID] = sp
+			}
+			return found, err
+		}),
+		topicsBySubject: graphql.NewLoader(func(ids []int) (map[int]interface{}, error) {
+			topics, err := queryTopics(ctx, "WHERE subject_id = ANY($1) AND deleted_at IS NULL ORDER BY id", pq.Array(ids))
+			found := map[int]interface{}{}
+			for _, id := range ids {
+				found[id] = []models.Topic{}
+			}
+			for _, t := range topics {
+				found[t.SubjectID] = append(found[t.SubjectID].([]models.Topic), t)
+			}
+			return found, err
+		}),
+		notesBySubject: graphql.NewLoader(func(ids []int) (map[int]interface{}, error) {
+			notes, err := queryNotes(ctx, "WHERE subject_id = ANY($1) AND deleted_at IS NULL ORDER BY updated_at DESC", pq.Array(ids))
+			found := map[int]interface{}{}
+			for _, id := range ids {
+				found[id] = []models.Note{}
+			}
+			for _, n := range notes {
+				found[n.SubjectID] = append(found[n.SubjectID].([]models.Note), n)
+			}
+			return found, err
+		}),
+		notesByTopic: graphql.NewLoader(func(ids []int) (map[int]interface{}, error) {
+			notes, err := queryNotes(ctx, "WHERE topic_id = ANY($1) AND deleted_at IS NULL ORDER BY updated_at DESC", pq.Array(ids))
+			found := map[int]interface{}{}
+			for _, id := range ids {
+				found[id] = []models.Note{}
+			}
+			for _, n := range notes {
+				found[*n.TopicID] = append(found[*n.TopicID].([]models.Note), n)
+			}
+			return found, err
+		}),
+		plansBySubject: graphql.NewLoader(func(ids []int) (map[int]interface{}, error) {
+			plans, err := queryStudyPlans(ctx, "WHERE sp.subject_id = ANY($1) AND sp.deleted_at IS NULL ORDER BY sp.study_date, sp.start_time NULLS LAST, sp.id", pq.Array(ids))
+			found := map[int]interface{}{}
+			for _, id := range ids {
+				found[id] = []models.StudyPlan{}
+			}
+			for _, sp := range plans {
+				found[sp.SubjectID] = append(found[sp.SubjectID].([]models.StudyPlan), sp)
+			}
+			return found, err
+		}),
+	}
+}
+
+// clear drops everything loaded so far, so a mutation's result is read fresh
+func (l *graphQLLoaders) clear() {
+	for _, loader := range []*graphql.Loader{
+		l.subjects, l.topics, l.notes, l.plans,
+		l.topicsBySubject, l.notesBySubject, l.notesByTopic, l.plansBySubject,
+	} {
+		loader.Clear()
+	}
+}
+
+// byResource returns the loader of a store resource by ID
+func (l *graphQLLoaders) byResource(resource string) *graphql.Loader {
+	return map[string]*graphql.Loader{
+		store.Subject:   l.subjects,
+		store.Topic:     l.topics,
+		store.Note:      l.notes,
+		store.StudyPlan: l.plans,
+	}[resource]
+}
+
+// The query helpers below are shared by GraphQL and /api/v2. They return
+// empty slices rather than nil, and timestamps in UTC to the second.
+
+// querySubjects loads the subjects matching a WHERE clause and ordering
+func querySubjects(ctx context.Context, where string, args ...interface{}) ([]models.Subject, error) {
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT id, name, COALESCE(description, ''), color, TO_CHAR(exam_date, 'YYYY-MM-DD'), created_at
+		FROM subjects `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subjects := []models.Subject{}
+	for rows.Next() {
+		var s models.Subject
+		if err := rows.Scan(&s.ID, &s.Name, &s.Description, &s.Color, &s.ExamDate, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		s.CreatedAt = utcSecond(s.CreatedAt)
+		subjects = append(subjects, s)
+	}
+	return subjects, rows.Err()
+}
+
+// queryTopics loads the topics matching a WHERE clause and ordering
+func queryTopics(ctx context.Context, where string, args ...interface{}) ([]models.Topic, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT id, subject_id, name, is_completed, is_weak, confidence, created_at FROM topics "+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	topics := []models.Topic{}
+	for rows.Next() {
+		var t models.Topic
+		if err := rows.Scan(&t.ID, &t.SubjectID, &t.Name, &t.IsCompleted, &t.IsWeak, &t.Confidence, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		t.CreatedAt = utcSecond(t.CreatedAt)
+		topics = append(topics, t)
+	}
+	return topics, rows.Err()
+}
+
+// queryNotes loads the notes matching a WHERE clause and ordering
+func queryNotes(ctx context.Context, where string, args ...interface{}) ([]models.Note, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT id, subject_id, topic_id, title, COALESCE(content, ''), created_at, updated_at FROM notes "+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notes := []models.Note{}
+	for rows.Next() {
+		var n models.Note
+		if err := rows.Scan(&n.ID, &n.SubjectID, &n.TopicID, &n.Title, &n.Content, &n.CreatedAt, &n.UpdatedAt); err != nil {
+			return nil, err
+		}
+		n.CreatedAt, n.UpdatedAt = utcSecond(n.CreatedAt), utcSecond(n.UpdatedAt)
+		notes = append(notes, n)
+	}
+	return notes, rows.Err()
+}
+
+// queryStudyPlans loads the study plan entries (aliased sp) matching a WHERE
+// clause and ordering
+func queryStudyPlans(ctx context.Context, where string, args ...interface{}) ([]models.StudyPlan, error) {
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT sp.id, sp.subject_id, s.name, s.color, sp.study_date, TO_CHAR(sp.start_time, 'HH24:MI'), TO_CHAR(sp.end_time, 'HH24:MI'),
+			   sp.hours_planned, sp.hours_completed, COALESCE(sp.notes, ''), sp.created_at
+		FROM study_plan sp
+		JOIN subjects s ON sp.subject_id = s.id
+		`+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	plans := []models.StudyPlan{}
+	for rows.Next() {
+		var sp models.StudyPlan
+		var studyDate time.Time
+		err := rows.Scan(&sp.ID, &sp.SubjectID, &sp.SubjectName, &sp.SubjectColor, &studyDate, &sp.StartTime, &sp.EndTime,
+			&sp.HoursPlanned, &sp.HoursCompleted, &sp.Notes, &sp.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		sp.StudyDate = studyDate.Format("2006-01-02")
+		sp.CreatedAt = utcSecond(sp.CreatedAt)
+		plans = append(plans, sp)
+	}
+	return plans, rows.Err()
+}
+
+// utcSecond gives a timestamp the same form everywhere: UTC, whole seconds
+func utcSecond(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Second)
+}
@@ -0,0 +1,604 @@
+package handlers
+
+import (
+	"database/sql"
+	"exam-prep/audit"
+	"exam-prep/graphql"
+	"exam-prep/models"
+	"exam-prep/planner"
+	"exam-prep/store"
+	"exam-prep/utils"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// graphQLResolver holds the per-request state behind the GraphQL schema:
+// the request, for timezone and actor, and the batching loaders
+type graphQLResolver struct {
+	c         *gin.Context
+	loaders   *graphQLLoaders
+	dashboard *models.DashboardData
+	counted   bool
+	warnings  []gin.H
+}
+
+func newGraphQLResolver(c *gin.Context) *graphQLResolver {
+	return &graphQLResolver{c: c, loaders: newGraphQLLoaders(c.Request.Context())}
+}
+
+// schema builds the GraphQL schema around the resolver. Field names are the
+// camelCase forms of the REST API's JSON names and read the same models.
+func (r *graphQLResolver) schema() *graphql.Schema {
+	l := r.loaders
+	id := &graphql.Arg{Name: "id", Type: "ID!"}
+
+	subject := &graphql.Object{Name: "Subject", Fields: []*graphql.FieldDef{
+		{Name: "id", Type: "ID!"},
+		{Name: "name", Type: "String!"},
+		{Name: "description", Type: "String!"},
+		{Name: "color", Type: "String!"},
+		{Name: "examDate", Type: "String"},
+		{Name: "createdAt", Type: "String!"},
+		{Name: "totalTopics", Type: "Int!", Resolve: r.topicCount(func(models.Topic) bool { return true })},
+		{Name: "completedTopics", Type: "Int!", Resolve: r.topicCount(func(t models.Topic) bool { return t.IsCompleted })},
+		{Name: "weakTopics", Type: "Int!", Resolve: r.topicCount(func(t models.Topic) bool { return t.IsWeak })},
+		{Name: "progress", Type: "Float!", Description: "Percentage of topics completed", Resolve: r.subjectProgress},
+		{Name: "topics", Type: "[Topic!]!", Args: []*graphql.Arg{{Name: "completed", Type: "Boolean"}, {Name: "weak", Type: "Boolean"}},
+			Resolve: r.subjectTopics},
+		{Name: "notes", Type: "[Note!]!", Resolve: related(l.notesBySubject, ownID)},
+		{Name: "studyPlan", Type: "[StudyPlan!]!", Args: []*graphql.Arg{{Name: "from", Type: "String"}, {Name: "to", Type: "String"}},
+			Resolve: r.subjectStudyPlan},
+	}}
+
+	topic := &graphql.Object{Name: "Topic", Fields: []*graphql.FieldDef{
+		{Name: "id", Type: "ID!"},
+		{Name: "subjectId", Type: "ID!"},
+		{Name: "name", Type: "String!"},
+		{Name: "isCompleted", Type: "Boolean!"},
+		{Name: "isWeak", Type: "Boolean!"},
+		{Name: "confidence", Type: "Int", Description: "Latest self-assessed confidence, 1-5"},
+		{Name: "createdAt", Type: "String!"},
+		{Name: "subject", Type: "Subject!", Resolve: related(l.subjects, subjectID)},
+		{Name: "notes", Type: "[Note!]!", Resolve: related(l.notesByTopic, ownID)},
+	}}
+
+	note := &graphql.Object{Name: "Note", Fields: []*graphql.FieldDef{
+		{Name: "id", Type: "ID!"},
+		{Name: "subjectId", Type: "ID!"},
+		{Name: "topicId", Type: "ID"},
+		{Name: "title", Type: "String!"},
+		{Name: "content", Type: "String!"},
+		{Name: "createdAt", Type: "String!"},
+		{Name: "updatedAt", Type: "String!"},
+		{Name: "subject", Type: "Subject!", Resolve: related(l.subjects, subjectID)},
+		{Name: "topic", Type: "Topic", Resolve: related(l.topics, func(parent interface{}) (int, bool) {
+			n := parent.(models.Note)
+			if n.TopicID == nil {
+				return 0, false
+			}
+			return *n.TopicID, true
+		})},
+	}}
+
+	studyPlan := &graphql.Object{Name: "StudyPlan", Description: "A study plan entry", Fields: []*graphql.FieldDef{
+		{Name: "id", Type: "ID!"},
+		{Name: "subjectId", Type: "ID!"},
+		{Name: "studyDate", Type: "String!"},
+		{Name: "startTime", Type: "String"},
+		{Name: "endTime", Type: "String"},
+		{Name: "hoursPlanned", Type: "Float!"},
+		{Name: "hoursCompleted", Type: "Float!"},
+		{Name: "notes", Type: "String!"},
+		{Name: "createdAt", Type: "String!"},
+		{Name: "subject", Type: "Subject!", Resolve: related(l.subjects, subjectID)},
+	}}
+
+	dashboard := &graphql.Object{Name: "Dashboard", Fields: []*graphql.FieldDef{
+		{Name: "daysUntilExam", Type: "Int!"},
+		{Name: "examDate", Type: "String!"},
+		{Name: "timezone", Type: "String!"},
+		{Name: "totalSubjects", Type: "Int!", Resolve: r.dashboardCount("totalSubjects")},
+		{Name: "totalTopics", Type: "Int!", Resolve: r.dashboardCount("totalTopics")},
+		{Name: "completedTopics", Type: "Int!", Resolve: r.dashboardCount("completedTopics")},
+		{Name: "weakTopics", Type: "Int!", Resolve: r.dashboardCount("weakTopics")},
+		{Name: "overallProgress", Type: "Float!", Resolve: r.dashboardCount("overallProgress")},
+		{Name: "todaysPlan", Type: "[TodayPlanItem!]!", Resolve: graphql.Each(func(interface{}, graphql.Args) (interface{}, error) {
+			return todaysPlan(r.c)
+		})},
+		{Name: "subjectProgress", Type: "[SubjectProgress!]!", Resolve: graphql.Each(func(interface{}, graphql.Args) (interface{}, error) {
+			return subjectProgress(r.c.Request.Context())
+		})},
+		{Name: "recentActivity", Type: "[Activity!]!", Args: []*graphql.Arg{{Name: "limit", Type: "Int", Default: 10}},
+			Resolve: graphql.Each(func(_ interface{}, args graphql.Args) (interface{}, error) {
+				limit, _ := args.Int("limit")
+				if limit < 1 || limit > 100 {
+					return nil, utils.Invalid(utils.FieldError{Field: "limit", Rule: "range", Message: "must be between 1 and 100"})
+				}
+				return recentActivity(r.c.Request.Context(), limit)
+			})},
+	}}
+
+	todayPlanItem := &graphql.Object{Name: "TodayPlanItem", Fields: []*graphql.FieldDef{
+		{Name: "subjectId", Type: "ID!"},
+		{Name: "subjectName", Type: "String!"},
+		{Name: "subjectColor", Type: "String!"},
+		{Name: "hoursPlanned", Type: "Float!"},
+		{Name: "hoursCompleted", Type: "Float!"},
+		{Name: "subject", Type: "Subject!", Resolve: related(l.subjects, subjectID)},
+	}}
+
+	progress := &graphql.Object{Name: "SubjectProgress", Fields: []*graphql.FieldDef{
+		{Name: "id", Type: "ID!"},
+		{Name: "name", Type: "String!"},
+		{Name: "color", Type: "String!"},
+		{Name: "totalTopics", Type: "Int!"},
+		{Name: "completedTopics", Type: "Int!"},
+		{Name: "weakTopics", Type: "Int!"},
+		{Name: "progress", Type: "Float!"},
+		{Name: "subject", Type: "Subject!", Resolve: related(l.subjects, ownID)},
+	}}
+
+	activity := &graphql.Object{Name: "Activity", Fields: []*graphql.FieldDef{
+		{Name: "id", Type: "ID!"},
+		{Name: "actor", Type: "String!"},
+		{Name: "entityType", Type: "String!"},
+		{Name: "entityId", Type: "ID!"},
+		{Name: "action", Type: "String!"},
+		{Name: "before", Type: "JSON"},
+		{Name: "after", Type: "JSON"},
+		{Name: "createdAt", Type: "String!"},
+	}}
+
+	query := &graphql.Object{Name: "Query", Fields: []*graphql.FieldDef{
+		{Name: "subjects", Type: "[Subject!]!", Resolve: graphql.Each(r.subjects)},
+		{Name: "subject", Type: "Subject", Args: []*graphql.Arg{id}, Resolve: byID(l.subjects)},
+		{Name: "topics", Type: "[Topic!]!", Resolve: graphql.Each(r.topics), Args: []*graphql.Arg{
+			{Name: "subjectId", Type: "ID"}, {Name: "completed", Type: "Boolean"}, {Name: "weak", Type: "Boolean"},
+		}},
+		{Name: "topic", Type: "Topic", Args: []*graphql.Arg{id}, Resolve: byID(l.topics)},
+		{Name: "notes", Type: "[Note!]!", Resolve: graphql.Each(r.notes), Args: []*graphql.Arg{
+			{Name: "subjectId", Type: "ID"}, {Name: "topicId", Type: "ID"},
+		}},
+		{Name: "note", Type: "Note", Args: []*graphql.Arg{id}, Resolve: byID(l.notes)},
+		{Name: "studyPlan", Type: "[StudyPlan!]!", Resolve: graphql.Each(r.studyPlan), Args: []*graphql.Arg{
+			{Name: "from", Type: "String", Description: "Start date (YYYY-MM-DD)"},
+			{Name: "to", Type: "String", Description: "End date (YYYY-MM-DD)"},
+			{Name: "subjectId", Type: "ID"},
+		}},
+		{Name: "studyPlanEntry", Type: "StudyPlan", Args: []*graphql.Arg{id}, Resolve: byID(l.plans)},
+		{Name: "dashboard", Type: "Dashboard!", Resolve: graphql.Each(func(interface{}, graphql.Args) (interface{}, error) {
+			return r.dashboardData(), nil
+		})},
+	}}
+
+	input := func(name string) []*graphql.Arg { return []*graphql.Arg{{Name: "input", Type: name + "!"}} }
+	patch := func(name string) []*graphql.Arg { return []*graphql.Arg{id, {Name: "input", Type: name + "!"}} }
+	mutation := &graphql.Object{Name: "Mutation", Fields: []*graphql.FieldDef{
+		{Name: "createSubject", Type: "Subject!", Args: input("CreateSubjectInput"), Resolve: r.create(store.Subject)},
+		{Name: "updateSubject", Type: "Subject!", Args: patch("SubjectPatch"), Resolve: r.update(store.Subject)},
+		{Name: "deleteSubject", Type: "ID!", Args: []*graphql.Arg{id}, Resolve: r.delete(store.Subject),
+			Description: "Moves the subject and its topics, notes and plan entries to the trash"},
+		{Name: "createTopic", Type: "Topic!", Args: input("CreateTopicInput"), Resolve: r.create(store.Topic)},
+		{Name: "updateTopic", Type: "Topic!", Args: patch("TopicPatch"), Resolve: r.update(store.Topic)},
+		{Name: "toggleTopicComplete", Type: "Topic!", Args: []*graphql.Arg{id}, Resolve: graphql.Each(r.toggleTopicComplete)},
+		{Name: "toggleTopicWeak", Type: "Topic!", Args: []*graphql.Arg{id}, Resolve: graphql.Each(r.toggleTopicWeak)},
+		{Name: "deleteTopic", Type: "ID!", Args: []*graphql.Arg{id}, Resolve: r.delete(store.Topic)},
+		{Name: "createNote", Type: "Note!", Args: input("CreateNoteInput"), Resolve: r.create(store.Note)},
+		{Name: "updateNote", Type: "Note!", Args: patch("NotePatch"), Resolve: r.update(store.Note)},
+		{Name: "deleteNote", Type: "ID!", Args: []*graphql.Arg{id}, Resolve: r.delete(store.Note)},
+		{Name: "createStudyPlan", Type: "StudyPlan!", Args: input("CreateStudyPlanInput"), Resolve: r.create(store.StudyPlan)},
+		{Name: "updateStudyPlan", Type: "StudyPlan!", Args: patch("StudyPlanPatch"), Resolve: r.update(store.StudyPlan)},
+		{Name: "deleteStudyPlan", Type: "ID!", Args: []*graphql.Arg{id}, Resolve: r.delete(store.StudyPlan)},
+	}}
+
+	// The patch inputs follow merge patch rules: a field left out is
+	// unchanged and null clears it
+	inputs := []*graphql.Input{
+		{Name: "CreateSubjectInput", Fields: []*graphql.Arg{
+			{Name: "name", Type: "String!"}, {Name: "description", Type: "String"}, {Name: "color", Type: "String"}, {Name: "examDate", Type: "String"},
+		}},
+		{Name: "SubjectPatch", Description: "Fields left out are unchanged; null clears a field", Fields: []*graphql.Arg{
+			{Name: "name", Type: "String"}, {Name: "description", Type: "String"}, {Name: "color", Type: "String"}, {Name: "examDate", Type: "String"},
+		}},
+		{Name: "CreateTopicInput", Fields: []*graphql.Arg{
+			{Name: "subjectId", Type: "ID!"}, {Name: "name", Type: "String!"},
+		}},
+		{Name: "TopicPatch", Description: "Fields left out are unchanged", Fields: []*graphql.Arg{
+			{Name: "name", Type: "String"}, {Name: "isCompleted", Type: "Boolean"},
+		}},
+		{Name: "CreateNoteInput", Fields: []*graphql.Arg{
+			{Name: "subjectId", Type: "ID!"}, {Name: "topicId", Type: "ID"}, {Name: "title", Type: "String!"}, {Name: "content", Type: "String"},
+		}},
+		{Name: "NotePatch", Description: "Fields left out are unchanged; null clears a field", Fields: []*graphql.Arg{
+			{Name: "title", Type: "String"}, {Name: "content", Type: "String"}, {Name: "topicId", Type: "ID"},
+		}},
+		{Name: "CreateStudyPlanInput", Fields: []*graphql.Arg{
+			{Name: "subjectId", Type: "ID!"}, {Name: "studyDate", Type: "String!"}, {Name: "startTime", Type: "String"},
+			{Name: "endTime", Type: "String"}, {Name: "hoursPlanned", Type: "Float"}, {Name: "notes", Type: "String"},
+		}},
+		{Name: "StudyPlanPatch", Description: "Fields left out are unchanged; null clears a field", Fields: []*graphql.Arg{
+			{Name: "startTime", Type: "String"}, {Name: "endTime", Type: "String"}, {Name: "hoursPlanned", Type: "Float"},
+			{Name: "hoursCompleted", Type: "Float"}, {Name: "notes", Type: "String"},
+		}},
+	}
+
+	schema := graphql.NewSchema(query, mutation,
+		[]*graphql.Object{subject, topic, note, studyPlan, dashboard, todayPlanItem, progress, activity}, inputs)
+	schema.FormatError = r.formatError
+	return schema
+}
+
+// related resolves a field by loading the keys of every parent at once.
+// Parents without a key resolve to null.
+func related(loader *graphql.Loader, key func(parent interface{}) (int, bool)) graphql.Resolver {
+	return func(parents []interface{}, _ graphql.Args) ([]interface{}, error) {
+		var ids []int
+		for _, parent := range parents {
+			if id, ok := key(parent); ok {
+				ids = append(ids, id)
+			}
+		}
+		values, err := loader.LoadMany(ids)
+		if err != nil {
+			return nil, err
+		}
+
+		resolved := make([]interface{}, len(parents))
+		for i, parent := range parents {
+			if _, ok := key(parent); ok {
+				resolved[i], values = values[0], values[1:]
+			}
+		}
+		return resolved, nil
+	}
+}
+
+// ownID is the ID of a subject or topic, or the subject of a progress item
+func ownID(parent interface{}) (int, bool) {
+	switch p := parent.(type) {
+	case models.Subject:
+		return p.ID, true
+	case models.Topic:
+		return p.ID, true
+	case models.SubjectProgressItem:
+		return p.ID, true
+	}
+	return 0, false
+}
+
+// subjectID is the subject a topic, note or plan entry belongs to
+func subjectID(parent interface{}) (int, bool) {
+	switch p := parent.(type) {
+	case models.Topic:
+		return p.SubjectID, true
+	case models.Note:
+		return p.SubjectID, true
+	case models.StudyPlan:
+		return p.SubjectID, true
+	case models.TodayPlanItem:
+		return p.SubjectID, true
+	}
+	return 0, false
+}
+
+// byID resolves a single row by its id argument, or null if there is none
+func byID(loader *graphql.Loader) graphql.Resolver {
+	return graphql.Each(func(_ interface{}, args graphql.Args) (interface{}, error) {
+		id, _ := args.Int("id")
+		return loader.Load(id)
+	})
+}
+
+func (r *graphQLResolver) subjects(interface{}, graphql.Args) (interface{}, error) {
+	subjects, err := querySubjects(r.c.Request.Context(), "WHERE deleted_at IS NULL ORDER BY id")
+	for _, s := range subjects {
+		r.loaders.subjects.Prime(s.ID, s)
+	}
+	return subjects, err
+}
+
+func (r *graphQLResolver) topics(_ interface{}, args graphql.Args) (interface{}, error) {
+	where, values := "WHERE deleted_at IS NULL", []interface{}{}
+	if id, ok := args.Int("subjectId"); ok {
+		values = append(values, id)
+		where += fmt.Sprintf(" AND subject_id = $%d", len(values))
+	}
+	topics, err := queryTopics(r.c.Request.Context(), where+" ORDER BY subject_id, id", values...)
+	for _, t := range topics {
+		r.loaders.topics.Prime(t.ID, t)
+	}
+	return filterTopics(topics, args), err
+}
+
+func (r *graphQLResolver) notes(_ interface{}, args graphql.Args) (interface{}, error) {
+	where, values := "WHERE deleted_at IS NULL", []interface{}{}
+	if id, ok := args.Int("subjectId"); ok {
+		values = append(values, id)
+		where += fmt.Sprintf(" AND subject_id = $%d", len(values))
+	}
+	if id, ok := args.Int("topicId"); ok {
+		values = append(values, id)
+		where += fmt.Sprintf(" AND topic_id = $%d", len(values))
+	}
+	notes, err := queryNotes(r.c.Request.Context(), where+" ORDER BY updated_at DESC", values...)
+	for _, n := range notes {
+		r.loaders.notes.Prime(n.ID, n)
+	}
+	return notes, err
+}
+
+func (r *graphQLResolver) studyPlan(_ interface{}, args graphql.Args) (interface{}, error) {
+	if err := checkDateRange(args); err != nil {
+		return nil, err
+	}
+	where, values := "WHERE sp.deleted_at IS NULL AND s.deleted_at IS NULL", []interface{}{}
+	if from := args.String("from"); from != "" {
+		values = append(values, from)
+		where += fmt.Sprintf(" AND sp.study_date >= $%d::DATE", len(values))
+	}
+	if to := args.String("to"); to != "" {
+		values = append(values, to)
+		where += fmt.Sprintf(" AND sp.study_date <= $%d::DATE", len(values))
+	}
+	if id, ok := args.Int("subjectId"); ok {
+		values = append(values, id)
+		where += fmt.Sprintf(" AND sp.subject_id = $%d", len(values))
+	}
+	plans, err := queryStudyPlans(r.c.Request.Context(), where+" ORDER BY sp.study_date, sp.start_time NULLS LAST, sp.id", values...)
+	for _, sp := range plans {
+		r.loaders.plans.Prime(sp.ID, sp)
+	}
+	return plans, err
+}
+
+// subjectTopics loads the topics of every subject, filtered by the completed
+// and weak arguments
+func (r *graphQLResolver) subjectTopics(parents []interface{}, args graphql.Args) ([]interface{}, error) {
+	lists, err := related(r.loaders.topicsBySubject, ownID)(parents, args)
+	if err != nil {
+		return nil, err
+	}
+	for i, list := range lists {
+		lists[i] = filterTopics(list.([]models.Topic), args)
+	}
+	return lists, nil
+}
+
+func filterTopics(topics []models.Topic, args graphql.Args) []models.Topic {
+	completed, byCompleted := args.Bool("completed")
+	weak, byWeak := args.Bool("weak")
+	filtered := []models.Topic{}
+	for _, t := range topics {
+		if (!byCompleted || t.IsCompleted == completed) && (!byWeak || t.IsWeak == weak) {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered
+}
+
+// subjectStudyPlan loads the plan entries of every subject between the from
+// and to arguments
+func (r *graphQLResolver) subjectStudyPlan(parents []interface{}, args graphql.Args) ([]interface{}, error) {
+	if err := checkDateRange(args); err != nil {
+		return nil, err
+	}
+	lists, err := related(r.loaders.plansBySubject, ownID)(parents, args)
+	if err != nil {
+		return nil, err
+	}
+	from, to := args.String("from"), args.String("to")
+	for i, list := range lists {
+		filtered := []models.StudyPlan{}
+		for _, sp := range list.([]models.StudyPlan) {
+			if (from == "" || sp.StudyDate >= from) && (to == "" || sp.StudyDate <= to) {
+				filtered = append(filtered, sp)
+			}
+		}
+		lists[i] = filtered
+	}
+	return lists, nil
+}
+
+func checkDateRange(args graphql.Args) error {
+	var fields []utils.FieldError
+	for _, name := range []string{"from", "to"} {
+		if value := args.String(name); value != "" && !utils.ValidDate(value) {
+			fields = append(fields, utils.FieldError{Field: name, Rule: "date", Message: "must be a date between " + utils.MinDate + " and " + utils.MaxDate})
+		}
+	}
+	if len(fields) > 0 {
+		return utils.Invalid(fields...)
+	}
+	return nil
+}
+
+// topicCount counts the topics of every subject that match
+func (r *graphQLResolver) topicCount(match func(models.Topic) bool) graphql.Resolver {
+	return func(parents []interface{}, args graphql.Args) ([]interface{}, error) {
+		lists, err := related(r.loaders.topicsBySubject, ownID)(parents, args)
+		if err != nil {
+			return nil, err
+		}
+		for i, list := range lists {
+			count := 0
+			for _, t := range list.([]models.Topic) {
+				if match(t) {
+					count++
+				}
+			}
+			lists[i] = count
+		}
+		return lists, nil
+	}
+}
+
+func (r *graphQLResolver) subjectProgress(parents []interface{}, args graphql.Args) ([]interface{}, error) {
+	lists, err := related(r.loaders.topicsBySubject, ownID)(parents, args)
+	if err != nil {
+		return nil, err
+	}
+	for i, list := range lists {
+		topics := list.([]models.Topic)
+		progress := 0.0
+		if len(topics) > 0 {
+			progress = float64(len(filterTopics(topics, graphql.Args{"completed": true}))) / float64(len(topics)) * 100
+		}
+		lists[i] = progress
+	}
+	return lists, nil
+}
+
+// dashboardData is the dashboard for the request. The counts are left for
+// dashboardCount, so they are only queried when asked for.
+func (r *graphQLResolver) dashboardData() *models.DashboardData {
+	if r.dashboard == nil {
+		examDate, examDateStr := utils.ExamDate()
+		r.dashboard = &models.DashboardData{
+			DaysUntilExam: utils.DaysUntilExam(examDate, utils.Now(r.c)),
+			ExamDate:      examDateStr,
+			Timezone:      utils.Location(r.c).String(),
+		}
+	}
+	return r.dashboard
+}
+
+// dashboardCount resolves one of the dashboard's counts, counting them all
+// the first time one is asked for
+func (r *graphQLResolver) dashboardCount(name string) graphql.Resolver {
+	property := graphql.Property(name)
+	return func(parents []interface{}, args graphql.Args) ([]interface{}, error) {
+		if !r.counted {
+			dashboardCounts(r.c.Request.Context(), r.dashboardData())
+			r.counted = true
+		}
+		return property(parents, args)
+	}
+}
+
+// create inserts a resource from the input argument and returns it
+func (r *graphQLResolver) create(resource string) graphql.Resolver {
+	return graphql.Each(func(_ interface{}, args graphql.Args) (interface{}, error) {
+		body, err := args.JSON("input")
+		if err != nil {
+			return nil, err
+		}
+		examDate, _ := utils.ExamDate()
+		var id int
+		err = inTx(r.c.Request.Context(), func(tx *sql.Tx) (err error) {
+			id, err = store.Create(r.c.Request.Context(), tx, resource, body, planner.DailyCap(), examDate)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		recordActivity(r.c, resource, id, audit.Create, nil)
+		return r.reload(resource, id)
+	})
+}
+
+// update applies the input argument to a resource as a merge patch and
+// returns the resource
+func (r *graphQLResolver) update(resource string) graphql.Resolver {
+	return graphql.Each(func(_ interface{}, args graphql.Args) (interface{}, error) {
+		id, _ := args.Int("id")
+		patch, err := args.JSON("input")
+		if err != nil {
+			return nil, err
+		}
+
+		examDate, _ := utils.ExamDate()
+		before := snapshot(r.c, resource, id)
+		var completed bool
+		err = inTx(r.c.Request.Context(), func(tx *sql.Tx) (err error) {
+			_, completed, err = store.Patch(r.c.Request.Context(), tx, resource, id, patch, planner.DailyCap(), examDate)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		recordActivity(r.c, resource, id, audit.Update, before)
+		if completed {
+			r.warnPrerequisites(id)
+		}
+		return r.reload(resource, id)
+	})
+}
+
+// delete moves a resource to the trash and returns its ID
+func (r *graphQLResolver) delete(resource string) graphql.Resolver {
+	return graphql.Each(func(_ interface{}, args graphql.Args) (interface{}, error) {
+		id, _ := args.Int("id")
+		before := snapshot(r.c, resource, id)
+		err := inTx(r.c.Request.Context(), func(tx *sql.Tx) error {
+			return store.Delete(r.c.Request.Context(), tx, resource, id)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		recordActivity(r.c, resource, id, audit.Delete, before)
+		r.loaders.clear()
+		return id, nil
+	})
+}
+
+func (r *graphQLResolver) toggleTopicComplete(_ interface{}, args graphql.Args) (interface{}, error) {
+	id, _ := args.Int("id")
+	before := snapshot(r.c, store.Topic, id)
+	completed, err := toggleTopicComplete(r.c.Request.Context(), id)
+	if err != nil {
+		return nil, err
+	}
+
+	action := audit.Uncomplete
+	if completed {
+		action = audit.Complete
+		r.warnPrerequisites(id)
+	}
+	recordActivity(r.c, store.Topic, id, action, before)
+	return r.reload(store.Topic, id)
+}
+
+func (r *graphQLResolver) toggleTopicWeak(_ interface{}, args graphql.Args) (interface{}, error) {
+	id, _ := args.Int("id")
+	before := snapshot(r.c, store.Topic, id)
+	action, err := toggleTopicWeak(r.c, id)
+	if err != nil {
+		return nil, err
+	}
+
+	recordActivity(r.c, store.Topic, id, action, before)
+	return r.reload(store.Topic, id)
+}
+
+// reload reads a resource after a mutation. Everything loaded before is
+// dropped, since the mutation may have changed it.
+func (r *graphQLResolver) reload(resource string, id int) (interface{}, error) {
+	r.loaders.clear()
+	value, err := r.loaders.byResource(resource).Load(id)
+	if err == nil && value == nil {
+		err = utils.NotFound("Not found")
+	}
+	return value, err
+}
+
+// warnPrerequisites adds a response warning when a topic was completed
+// before its prerequisites
+func (r *graphQLResolver) warnPrerequisites(id int) {
+	warning := prerequisiteWarning(r.c, id)
+	if warning == nil {
+		return
+	}
+	var incomplete []gin.H
+	for _, t := range warning["incomplete_prerequisites"].([]models.Topic) {
+		incomplete = append(incomplete, gin.H{"id": fmt.Sprint(t.ID), "name": t.Name})
+	}
+	r.warnings = append(r.warnings, gin.H{
+		"message":                 "Topic completed, but some prerequisites are still incomplete",
+		"topicId":                 fmt.Sprint(id),
+		"incompletePrerequisites": incomplete,
+	})
+}
@@ -1,8 +1,12 @@
 package handlers
 
 import (
+	"database/sql"
+	"errors"
+	"exam-prep/audit"
 	"exam-prep/database"
 	"exam-prep/models"
+	"exam-prep/store"
 	"exam-prep/utils"
 	"net/http"
 	"strconv"
@@ -12,13 +16,14 @@ import (
 
 // GetAllNotes returns all notes
 func GetAllNotes(c *gin.Context) {
-	rows, err := database.DB.Query(`
-		SELECT n.id, n.subject_id, n.topic_id, n.title, n.content, n.created_at, n.updated_at
+	rows, err := database.DB.QueryContext(c.Request.Context(), `
+		SELECT n.id, n.subject_id, n.topic_id, n.title, COALESCE(n.content, ''), n.created_at, n.updated_at
 		FROM notes n
+		WHERE n.deleted_at IS NULL
 		ORDER BY n.updated_at DESC
 	`)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		utils.Fail(c, err)
 		return
 	}
 	defer rows.Close()
@@ -28,7 +33,7 @@ func GetAllNotes(c *gin.Context) {
 		var n models.Note
 		err := rows.Scan(&n.ID, &n.SubjectID, &n.TopicID, &n.Title, &n.Content, &n.CreatedAt, &n.UpdatedAt)
 		if err != nil {
-			utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+			utils.Fail(c, err)
 			return
 		}
 		notes = append(notes, n)
@@ -45,14 +50,14 @@ func GetNotesBySubject(c *gin.Context) {
 		return
 	}
 
-	rows, err := database.DB.Query(`
-		SELECT id, subject_id, topic_id, title, content, created_at, updated_at
+	rows, err := database.DB.QueryContext(c.Request.Context(), `
+		SELECT id, subject_id, topic_id, title, COALESCE(content, ''), created_at, updated_at
 		FROM notes
-		WHERE subject_id = $1
+		WHERE subject_id = $1 AND deleted_at IS NULL
 		ORDER BY updated_at DESC
 	`, subjectID)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		utils.Fail(c, err)
 		return
 	}
 	defer rows.Close()
@@ -62,7 +67,7 @@ func GetNotesBySubject(c *gin.Context) {
 		var n models.Note
 		err := rows.Scan(&n.ID, &n.SubjectID, &n.TopicID, &n.Title, &n.Content, &n.CreatedAt, &n.UpdatedAt)
 		if err != nil {
-			utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+			utils.Fail(c, err)
 			return
 		}
 		notes = append(notes, n)
@@ -75,21 +80,30 @@ func GetNotesBySubject(c *gin.Context) {
 func CreateNote(c *gin.Context) {
 	var input models.CreateNoteInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		utils.Fail(c, err)
+		return
+	}
+	if err := store.CheckSubject(c.Request.Context(), database.DB, input.SubjectID); err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	if err := store.CheckNoteTopic(c.Request.Context(), database.DB, input.SubjectID, input.TopicID); err != nil {
+		utils.Fail(c, err)
 		return
 	}
 
 	var id int
-	err := database.DB.QueryRow(
+	err := database.DB.QueryRowContext(c.Request.Context(),
 		"INSERT INTO notes (subject_id, topic_id, title, content) VALUES ($1, $2, $3, $4) RETURNING id",
 		input.SubjectID, input.TopicID, input.Title, input.Content,
 	).Scan(&id)
 
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		utils.Fail(c, err)
 		return
 	}
 
+	recordActivity(c, "note", id, audit.Create, nil)
 	utils.SuccessResponse(c, http.StatusCreated, "Note created", gin.H{"id": id})
 }
 
@@ -103,17 +117,33 @@ func UpdateNote(c *gin.Context) {
 
 	var input models.UpdateNoteInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		utils.Fail(c, err)
 		return
 	}
+	if input.TopicID != nil {
+		var subjectID int
+		err := database.DB.QueryRowContext(c.Request.Context(), "SELECT subject_id FROM notes WHERE id = $1 AND deleted_at IS NULL", id).Scan(&subjectID)
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.ErrorResponse(c, http.StatusNotFound, "Note not found")
+			return
+		}
+		if err == nil {
+			err = store.CheckNoteTopic(c.Request.Context(), database.DB, subjectID, input.TopicID)
+		}
+		if err != nil {
+			utils.Fail(c, err)
+			return
+		}
+	}
 
-	result, err := database.DB.Exec(
-		"UPDATE notes SET title = COALESCE(NULLIF($1, ''), title), content = COALESCE($2, content), topic_id = $3, updated_at = CURRENT_TIMESTAMP WHERE id = $4",
+	before := snapshot(c, "note", id)
+	result, err := database.DB.ExecContext(c.Request.Context(),
+		"UPDATE notes SET title = COALESCE(NULLIF($1, ''), title), content = COALESCE($2, content), topic_id = $3, updated_at = CURRENT_TIMESTAMP WHERE id = $4 AND deleted_at IS NULL",
 		input.Title, input.Content, input.TopicID, id,
 	)
 
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		utils.Fail(c, err)
 		return
 	}
 
@@ -123,10 +153,11 @@ func UpdateNote(c *gin.Context) {
 		return
 	}
 
+	recordActivity(c, "note", id, audit.Update, before)
 	utils.SuccessResponse(c, http.StatusOK, "Note updated", nil)
 }
 
-// DeleteNote deletes a note
+// DeleteNote moves a note to the trash
 func DeleteNote(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
@@ -134,9 +165,10 @@ func DeleteNote(c *gin.Context) {
 		return
 	}
 
-	result, err := database.DB.Exec("DELETE FROM notes WHERE id = $1", id)
+	before := snapshot(c, "note", id)
+	result, err := database.DB.ExecContext(c.Request.Context(), "UPDATE notes SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NULL", id)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		utils.Fail(c, err)
 		return
 	}
 
@@ -146,5 +178,6 @@ func DeleteNote(c *gin.Context) {
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "Note deleted", nil)
+	recordActivity(c, "note", id, audit.Delete, before)
+	utils.SuccessResponse(c, http.StatusOK, "Note moved to trash", nil)
 }
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"exam-prep/audit"
+	"exam-prep/database"
+	"exam-prep/planner"
+	"exam-prep/store"
+	"exam-prep/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The Patch handlers apply a JSON merge patch (RFC 7396) to a resource.
+// Unlike the PUT handlers, a member that is absent is left alone, a null
+// member clears the field and an empty string is stored as given. They
+// respond with the patched fields.
+
+// PatchSubject applies a merge patch to a subject
+func PatchSubject(c *gin.Context) {
+	id, patch, ok := patchRequest(c, "Invalid subject ID")
+	if !ok {
+		return
+	}
+
+	before := snapshot(c, "subject", id)
+	var subject interface{}
+	err := inTx(c.Request.Context(), func(tx *sql.Tx) (err error) {
+		subject, err = store.PatchSubject(c.Request.Context(), tx, id, patch)
+		return err
+	})
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	recordActivity(c, "subject", id, audit.Update, before)
+	utils.SuccessResponse(c, http.StatusOK, "Subject updated", subject)
+}
+
+// PatchTopic applies a merge patch to a topic
+func PatchTopic(c *gin.Context) {
+	id, patch, ok := patchRequest(c, "Invalid topic ID")
+	if !ok {
+		return
+	}
+
+	before := snapshot(c, "topic", id)
+	var topic interface{}
+	var completed bool
+	err := inTx(c.Request.Context(), func(tx *sql.Tx) (err error) {
+		topic, completed, err = store.PatchTopic(c.Request.Context(), tx, id, patch)
+		return err
+	})
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	recordActivity(c, "topic", id, audit.Update, before)
+
+	if completed {
+		if warning := prerequisiteWarning(c, id); warning != nil {
+			warning["topic"] = topic
+			utils.SuccessResponse(c, http.StatusOK, "Topic updated, but some prerequisites are still incomplete", warning)
+			return
+		}
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Topic updated", topic)
+}
+
+// PatchNote applies a merge patch to a note
+func PatchNote(c *gin.Context) {
+	id, patch, ok := patchRequest(c, "Invalid note ID")
+	if !ok {
+		return
+	}
+
+	before := snapshot(c, "note", id)
+	var note interface{}
+	err := inTx(c.Request.Context(), func(tx *sql.Tx) (err error) {
+		note, err = store.PatchNote(c.Request.Context(), tx, id, patch)
+		return err
+	})
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	recordActivity(c, "note", id, audit.Update, before)
+	utils.SuccessResponse(c, http.StatusOK, "Note updated", note)
+}
+
+// PatchStudyPlan applies a merge patch to a study plan entry. Changes to the
+// hours or time slot go through the same plan checks as UpdateStudyPlan.
+func PatchStudyPlan(c *gin.Context) {
+	id, patch, ok := patchRequest(c, "Invalid study plan ID")
+	if !ok {
+		return
+	}
+
+	examDate, _ := utils.ExamDate()
+	before := snapshot(c, "study_plan", id)
+	var entry interface{}
+	err := inTx(c.Request.Context(), func(tx *sql.Tx) (err error) {
+		entry, err = store.PatchStudyPlan(c.Request.Context(), tx, id, patch, planner.DailyCap(), examDate)
+		return err
+	})
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	recordActivity(c, "study_plan", id, audit.Update, before)
+	utils.SuccessResponse(c, http.StatusOK, "Study plan updated", entry)
+}
+
+// patchRequest reads the ID and merge patch of a PATCH request, responding
+// with the error if either is invalid
+func patchRequest(c *gin.Context, invalidID string) (int, []byte, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, invalidID)
+		return 0, nil, false
+	}
+	patch, err := utils.ReadMergePatch(c)
+	if err != nil {
+		utils.Fail(c, err)
+		return 0, nil, false
+	}
+	return id, patch, true
+}
+
+// inTx runs fn in a transaction, committing only if it succeeds
+func inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
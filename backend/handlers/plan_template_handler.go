@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"exam-prep/audit"
+	"exam-prep/models"
+	"exam-prep/planner"
+	"exam-prep/utils"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetAllPlanTemplates returns all recurring plan templates
+func GetAllPlanTemplates(c *gin.Context) {
+	templates, err := planner.ListTemplates(c.Request.Context())
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Plan templates retrieved", templates)
+}
+
+// GetPlanTemplate returns a single plan template
+func GetPlanTemplate(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid plan template ID")
+		return
+	}
+
+	template, err := planner.GetTemplate(c.Request.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Plan template not found")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Plan template retrieved", template)
+}
+
+// CreatePlanTemplate creates a recurring plan template and materializes its
+// upcoming occurrences up to the end date or the exam date
+func CreatePlanTemplate(c *gin.Context) {
+	var input models.CreatePlanTemplateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	if _, err := planner.ParseRRule(input.RRule); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if _, err := time.Parse("2006-01-02", input.StartDate); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "start_date must be in YYYY-MM-DD format")
+		return
+	}
+	if input.HoursPlanned == 0 {
+		input.HoursPlanned = 1.0
+	}
+
+	examDate, _ := utils.ExamDate()
+	id, created, err := planner.CreateTemplate(c.Request.Context(), input, utils.Now(c), examDate)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	recordActivity(c, "plan_template", id, audit.Create, nil)
+	utils.SuccessResponse(c, http.StatusCreated, "Plan template created", gin.H{"id": id, "created_entries": created})
+}
+
+// UpdatePlanTemplate edits a template. The scope field must say whether the
+// change applies to the single occurrence on date ("instance") or to every
+// occurrence from date onwards ("future").
+func UpdatePlanTemplate(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid plan template ID")
+		return
+	}
+
+	var input models.UpdatePlanTemplateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	if _, err := time.Parse("2006-01-02", input.Date); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "date must be in YYYY-MM-DD format")
+		return
+	}
+
+	before := snapshot(c, "plan_template", id)
+	if before == nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Plan template not found")
+		return
+	}
+
+	switch input.Scope {
+	case planner.ScopeInstance:
+		if input.RRule != "" || input.EndDate != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "rrule and end_date can only be changed with scope future")
+			return
+		}
+		planID, err := planner.EditInstance(c.Request.Context(), id, input.Date, input.HoursPlanned, input.Notes)
+		if errors.Is(err, planner.ErrNotOccurrence) {
+			utils.ErrorResponse(c, http.StatusBadRequest, input.Date+" is not an occurrence of this template")
+			return
+		}
+		if err != nil {
+			utils.Fail(c, err)
+			return
+		}
+		recordActivity(c, "plan_template", id, audit.Update, before)
+		utils.SuccessResponse(c, http.StatusOK, "Plan template instance updated", gin.H{"study_plan_id": planID})
+
+	case planner.ScopeFuture:
+		if input.RRule != "" {
+			if _, err := planner.ParseRRule(input.RRule); err != nil {
+				utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+		examDate, _ := utils.ExamDate()
+		templateID, err := planner.EditFuture(c.Request.Context(), id, input, utils.Now(c), examDate)
+		if err != nil {
+			utils.Fail(c, err)
+			return
+		}
+		recordActivity(c, "plan_template", id, audit.Update, before)
+		if templateID != id {
+			recordActivity(c, "plan_template", templateID, audit.Create, nil)
+		}
+		utils.SuccessResponse(c, http.StatusOK, "Plan template updated", gin.H{"template_id": templateID})
+
+	default:
+		utils.ErrorResponse(c, http.StatusBadRequest, "scope must be instance or future")
+	}
+}
+
+// maxExpandDays is how far ahead of today a template can be expanded
+const maxExpandDays = 366
+
+// ExpandPlanTemplate materializes a template's occurrences from today up to
+// the requested date, which may be at most maxExpandDays ahead
+func ExpandPlanTemplate(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid plan template ID")
+		return
+	}
+
+	var input models.ExpandPlanTemplateInput
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		utils.Fail(c, err)
+		return
+	}
+
+	until, _ := utils.ExamDate()
+	if input.Until != "" {
+		until, err = time.Parse("2006-01-02", input.Until)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "until must be in YYYY-MM-DD format")
+			return
+		}
+		if utils.DaysBetween(utils.Now(c), until) > maxExpandDays {
+			utils.ErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("until must be at most %d days from today", maxExpandDays))
+			return
+		}
+	}
+
+	before := snapshot(c, "plan_template", id)
+	created, err := planner.Expand(c.Request.Context(), id, utils.Now(c), until)
+	if errors.Is(err, sql.ErrNoRows) {
+		utils.ErrorResponse(c, http.StatusNotFound, "Plan template not found")
+		return
+	}
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	if created > 0 {
+		recordActivity(c, "plan_template", id, audit.Update, before)
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Plan template expanded", gin.H{"created_entries": created})
+}
+
+// DeletePlanTemplate deletes a template and trashes its upcoming untouched entries
+func DeletePlanTemplate(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid plan template ID")
+		return
+	}
+
+	before := snapshot(c, "plan_template", id)
+	found, err := planner.DeleteTemplate(c.Request.Context(), id, utils.Now(c))
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	if !found {
+		utils.ErrorResponse(c, http.StatusNotFound, "Plan template not found")
+		return
+	}
+
+	recordActivity(c, "plan_template", id, audit.Delete, before)
+	utils.SuccessResponse(c, http.StatusOK, "Plan template deleted", nil)
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"exam-prep/audit"
+	"exam-prep/models"
+	"exam-prep/planner"
+	"exam-prep/utils"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RebalanceStudyPlan carries missed study hours forward into upcoming days
+func RebalanceStudyPlan(c *gin.Context) {
+	var input models.RebalanceInput
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		utils.Fail(c, err)
+		return
+	}
+
+	dailyCap := planner.DailyCap()
+	if input.DailyCap != nil {
+		if *input.DailyCap <= 0 || *input.DailyCap > 24 {
+			utils.ErrorResponse(c, http.StatusBadRequest, "daily_cap must be between 0 and 24")
+			return
+		}
+		dailyCap = *input.DailyCap
+	}
+
+	examDate, _ := utils.ExamDate()
+	rebalance, err := planner.Rebalance(c.Request.Context(), utils.Actor(c), dailyCap, utils.Now(c), examDate, input.DryRun)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	if input.DryRun {
+		utils.SuccessResponse(c, http.StatusOK, "Rebalance preview", rebalance)
+		return
+	}
+
+	recordActivity(c, "plan_rebalance", rebalance.ID, audit.Create, nil)
+	utils.SuccessResponse(c, http.StatusOK, "Study plan rebalanced", rebalance)
+}
+
+// GetRebalances returns recent rebalance runs and the hours each one moved
+func GetRebalances(c *gin.Context) {
+	rebalances, err := planner.ListRebalances(c.Request.Context(), 20)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Rebalances retrieved", rebalances)
+}
+
+// RevertRebalance undoes the moves made by a rebalance run
+func RevertRebalance(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid rebalance ID")
+		return
+	}
+
+	err = planner.Revert(c.Request.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		utils.ErrorResponse(c, http.StatusNotFound, "Rebalance not found")
+		return
+	}
+	if errors.Is(err, planner.ErrAlreadyReverted) {
+		utils.ErrorResponse(c, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	recordActivity(c, "plan_rebalance", id, audit.Revert, nil)
+	utils.SuccessResponse(c, http.StatusOK, "Rebalance reverted", nil)
+}
@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"exam-prep/database"
+	"exam-prep/models"
+	"exam-prep/notify"
+	"exam-prep/reminders"
+	"exam-prep/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// GetReminders returns the current user's reminder rules
+func GetReminders(c *gin.Context) {
+	rows, err := database.DB.QueryContext(c.Request.Context(), `
+		SELECT id, user_name, rule_type, channel, COALESCE(target, ''), send_hour, thresholds, enabled,
+			   TO_CHAR(last_sent_on, 'YYYY-MM-DD'), created_at
+		FROM reminder_rules
+		WHERE user_name = $1
+		ORDER BY id
+	`, utils.Actor(c))
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	defer rows.Close()
+
+	var rules []models.ReminderRule
+	for rows.Next() {
+		var r models.ReminderRule
+		err := rows.Scan(&r.ID, &r.UserName, &r.RuleType, &r.Channel, &r.Target, &r.SendHour,
+			pq.Array(&r.Thresholds), &r.Enabled, &r.LastSentOn, &r.CreatedAt)
+		if err != nil {
+			utils.Fail(c, err)
+			return
+		}
+		rules = append(rules, r)
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Reminders retrieved", rules)
+}
+
+// CreateReminder creates a reminder rule for the current user
+func CreateReminder(c *gin.Context) {
+	var input models.CreateReminderRuleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	if !reminders.ValidRuleType(input.RuleType) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "rule_type must be one of daily_digest, missed_plan, exam_countdown, weak_topics")
+		return
+	}
+	if msg := validateReminderChannel(input.Channel, input.Target); msg != "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, msg)
+		return
+	}
+
+	sendHour := reminders.DefaultSendHours[input.RuleType]
+	if input.SendHour != nil {
+		sendHour = *input.SendHour
+	}
+	if sendHour < 0 || sendHour > 23 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "send_hour must be between 0 and 23")
+		return
+	}
+	if input.Thresholds == nil {
+		input.Thresholds = []int64{}
+	}
+
+	var id int
+	err := database.DB.QueryRowContext(c.Request.Context(),
+		"INSERT INTO reminder_rules (user_name, rule_type, channel, target, send_hour, thresholds) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id",
+		utils.Actor(c), input.RuleType, input.Channel, input.Target, sendHour, pq.Array(input.Thresholds),
+	).Scan(&id)
+
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Reminder created", gin.H{"id": id})
+}
+
+// UpdateReminder updates one of the current user's reminder rules
+func UpdateReminder(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid reminder ID")
+		return
+	}
+
+	var input models.UpdateReminderRuleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	if input.SendHour != nil && (*input.SendHour < 0 || *input.SendHour > 23) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "send_hour must be between 0 and 23")
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	defer tx.Rollback()
+
+	// The channel and target are checked as they will be stored, so changing
+	// either one alone cannot leave an email rule without an address
+	var channel, target string
+	err = tx.QueryRowContext(ctx,
+		"SELECT channel, COALESCE(target, '') FROM reminder_rules WHERE id = $1 AND user_name = $2 FOR UPDATE",
+		id, utils.Actor(c),
+	).Scan(&channel, &target)
+	if errors.Is(err, sql.ErrNoRows) {
+		utils.ErrorResponse(c, http.StatusNotFound, "Reminder not found")
+		return
+	}
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	if input.Channel != "" {
+		channel = input.Channel
+	}
+	if input.Target != "" {
+		target = input.Target
+	}
+	if msg := validateReminderChannel(channel, target); msg != "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, msg)
+		return
+	}
+
+	var thresholds interface{}
+	if input.Thresholds != nil {
+		thresholds = pq.Array(input.Thresholds)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE reminder_rules
+		SET channel = $1, target = $2,
+			send_hour = COALESCE($3, send_hour), thresholds = COALESCE($4, thresholds), enabled = COALESCE($5, enabled)
+		WHERE id = $6
+	`, channel, target, input.SendHour, thresholds, input.Enabled, id)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Reminder updated", nil)
+}
+
+// DeleteReminder deletes one of the current user's reminder rules
+func DeleteReminder(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid reminder ID")
+		return
+	}
+
+	result, err := database.DB.ExecContext(c.Request.Context(), "DELETE FROM reminder_rules WHERE id = $1 AND user_name = $2", id, utils.Actor(c))
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		utils.ErrorResponse(c, http.StatusNotFound, "Reminder not found")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Reminder deleted", nil)
+}
+
+// GetNotifications returns the current user's in-app inbox, newest first.
+// Pass unread=true to only get unread messages.
+func GetNotifications(c *gin.Context) {
+	query := `
+		SELECT id, user_name, kind, title, COALESCE(body, ''), is_read, created_at
+		FROM notifications
+		WHERE user_name = $1`
+	if c.Query("unread") == "true" {
+		query += " AND NOT is_read"
+	}
+	query += " ORDER BY created_at DESC, id DESC LIMIT 100"
+
+	rows, err := database.DB.QueryContext(c.Request.Context(), query, utils.Actor(c))
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	defer rows.Close()
+
+	var notifications []models.Notification
+	for rows.Next() {
+		var n models.Notification
+		err := rows.Scan(&n.ID, &n.UserName, &n.Kind, &n.Title, &n.Body, &n.IsRead, &n.CreatedAt)
+		if err != nil {
+			utils.Fail(c, err)
+			return
+		}
+		notifications = append(notifications, n)
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Notifications retrieved", notifications)
+}
+
+// MarkNotificationRead marks a notification in the current user's inbox as read
+func MarkNotificationRead(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid notification ID")
+		return
+	}
+
+	result, err := database.DB.ExecContext(c.Request.Context(), "UPDATE notifications SET is_read = TRUE WHERE id = $1 AND user_name = $2", id, utils.Actor(c))
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		utils.ErrorResponse(c, http.StatusNotFound, "Notification not found")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Notification marked as read", nil)
+}
+
+// validateReminderChannel checks the channel name and that email and
+// webhook channels have somewhere to deliver to
+func validateReminderChannel(channel, target string) string {
+	if !notify.ValidChannel(channel) {
+		return "channel must be one of email, webhook, inbox"
+	}
+	if channel == notify.ChannelEmail && target == "" {
+		return "target email address is required for the email channel"
+	}
+	if channel == notify.ChannelWebhook && !validWebhookURL(target) {
+		return "target must be an absolute http or https URL for the webhook channel"
+	}
+	return ""
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"exam-prep/models"
+	"exam-prep/reports"
+	"exam-prep/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetWeeklyReport renders the progress report for the current week, or for
+// the week containing ?week=YYYY-MM-DD, as html (default), md or pdf
+func GetWeeklyReport(c *gin.Context) {
+	format := c.DefaultQuery("format", reports.FormatHTML)
+	if !reports.ValidFormat(format) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "format must be one of html, md, pdf")
+		return
+	}
+
+	now := utils.Now(c)
+	if value := c.Query("week"); value != "" {
+		day, err := time.ParseInLocation("2006-01-02", value, utils.Location(c))
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid week, use YYYY-MM-DD")
+			return
+		}
+		// Past weeks are reported as of their last day
+		if _, end := reports.WeekBounds(day); utils.DaysBetween(now, end) < 0 {
+			now = time.Date(end.Year(), end.Month(), end.Day(), 23, 59, 59, 0, day.Location())
+		}
+	}
+
+	report, err := reports.BuildWeekly(c.Request.Context(), now)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	writeReport(c, report, format)
+}
+
+// GetReports lists the weekly reports saved by the report job
+func GetReports(c *gin.Context) {
+	stored, err := reports.List(c.Request.Context(), 52)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Reports retrieved", stored)
+}
+
+// GetReport returns a saved weekly report as JSON, or rendered when
+// ?format=html|md|pdf is given
+func GetReport(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+
+	format := c.Query("format")
+	if format != "" && !reports.ValidFormat(format) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "format must be one of html, md, pdf")
+		return
+	}
+
+	stored, err := reports.Get(c.Request.Context(), id)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	if stored == nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Report not found")
+		return
+	}
+
+	if format == "" {
+		utils.SuccessResponse(c, http.StatusOK, "Report retrieved", stored)
+		return
+	}
+	writeReport(c, stored.Report, format)
+}
+
+// writeReport renders a report and writes it as the response body
+func writeReport(c *gin.Context, report *models.WeeklyReport, format string) {
+	body, contentType, err := reports.Render(report, format)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	if format == reports.FormatPDF {
+		c.Header("Content-Disposition", `inline; filename="weekly-report-`+report.WeekStart+`.pdf"`)
+	}
+	c.Data(http.StatusOK, contentType, body)
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"exam-prep/database"
+	"exam-prep/models"
+	"exam-prep/utils"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultLocale is used for users who have not chosen one
+const defaultLocale = "en"
+
+// localePattern matches BCP 47 style tags such as "en", "en-US" or "so-SO"
+var localePattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// GetSettings returns the current user's settings. Users without saved
+// settings get the server defaults.
+func GetSettings(c *gin.Context) {
+	settings := models.UserSettings{UserName: utils.Actor(c)}
+	err := database.DB.QueryRowContext(c.Request.Context(),
+		"SELECT timezone, locale, updated_at FROM user_settings WHERE user_name = $1",
+		settings.UserName,
+	).Scan(&settings.Timezone, &settings.Locale, &settings.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		settings.Timezone = utils.DefaultLocation().String()
+		settings.Locale = defaultLocale
+	} else if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Settings retrieved", settings)
+}
+
+// UpdateSettings saves the current user's timezone and locale
+func UpdateSettings(c *gin.Context) {
+	var input models.UpdateUserSettingsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	if input.Timezone != "" {
+		if _, err := time.LoadLocation(input.Timezone); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Unknown timezone "+input.Timezone)
+			return
+		}
+	}
+	if input.Locale != "" && !localePattern.MatchString(input.Locale) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid locale "+input.Locale)
+		return
+	}
+
+	_, err := database.DB.ExecContext(c.Request.Context(), `
+		INSERT INTO user_settings (user_name, timezone, locale)
+		VALUES ($1, COALESCE(NULLIF($2, ''), $4), COALESCE(NULLIF($3, ''), $5))
+		ON CONFLICT (user_name) DO UPDATE
+		SET timezone = COALESCE(NULLIF($2, ''), user_settings.timezone),
+			locale = COALESCE(NULLIF($3, ''), user_settings.locale),
+			updated_at = CURRENT_TIMESTAMP
+	`, utils.Actor(c), input.Timezone, input.Locale, utils.DefaultLocation().String(), defaultLocale)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Settings updated", nil)
+}
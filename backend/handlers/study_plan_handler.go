@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"exam-prep/audit"
 	"exam-prep/database"
 	"exam-prep/models"
+	"exam-prep/planner"
+	"exam-prep/store"
 	"exam-prep/utils"
 	"net/http"
 	"strconv"
@@ -13,14 +16,16 @@ import (
 
 // GetAllStudyPlans returns all study plans
 func GetAllStudyPlans(c *gin.Context) {
-	rows, err := database.DB.Query(`
-		SELECT sp.id, sp.subject_id, s.name, s.color, sp.study_date, sp.hours_planned, sp.hours_completed, COALESCE(sp.notes, ''), sp.created_at
+	rows, err := database.DB.QueryContext(c.Request.Context(), `
+		SELECT sp.id, sp.subject_id, s.name, s.color, sp.study_date, TO_CHAR(sp.start_time, 'HH24:MI'), TO_CHAR(sp.end_time, 'HH24:MI'),
+			   sp.hours_planned, sp.hours_completed, COALESCE(sp.notes, ''), sp.created_at
 		FROM study_plan sp
 		JOIN subjects s ON sp.subject_id = s.id
-		ORDER BY sp.study_date DESC
+		WHERE sp.deleted_at IS NULL
+		ORDER BY sp.study_date DESC, sp.start_time NULLS LAST
 	`)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		utils.Fail(c, err)
 		return
 	}
 	defer rows.Close()
@@ -29,9 +34,9 @@ func GetAllStudyPlans(c *gin.Context) {
 	for rows.Next() {
 		var sp models.StudyPlan
 		var studyDate time.Time
-		err := rows.Scan(&sp.ID, &sp.SubjectID, &sp.SubjectName, &sp.SubjectColor, &studyDate, &sp.HoursPlanned, &sp.HoursCompleted, &sp.Notes, &sp.CreatedAt)
+		err := rows.Scan(&sp.ID, &sp.SubjectID, &sp.SubjectName, &sp.SubjectColor, &studyDate, &sp.StartTime, &sp.EndTime, &sp.HoursPlanned, &sp.HoursCompleted, &sp.Notes, &sp.CreatedAt)
 		if err != nil {
-			utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+			utils.Fail(c, err)
 			return
 		}
 		sp.StudyDate = studyDate.Format("2006-01-02")
@@ -41,19 +46,21 @@ func GetAllStudyPlans(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "Study plans retrieved", plans)
 }
 
-// GetTodayStudyPlan returns today's study plan
+// GetTodayStudyPlan returns today's study plan, where today is the calendar
+// day in the request's timezone
 func GetTodayStudyPlan(c *gin.Context) {
-	today := time.Now().Format("2006-01-02")
+	today := utils.Today(c)
 
-	rows, err := database.DB.Query(`
-		SELECT sp.id, sp.subject_id, s.name, s.color, sp.study_date, sp.hours_planned, sp.hours_completed, COALESCE(sp.notes, ''), sp.created_at
+	rows, err := database.DB.QueryContext(c.Request.Context(), `
+		SELECT sp.id, sp.subject_id, s.name, s.color, sp.study_date, TO_CHAR(sp.start_time, 'HH24:MI'), TO_CHAR(sp.end_time, 'HH24:MI'),
+			   sp.hours_planned, sp.hours_completed, COALESCE(sp.notes, ''), sp.created_at
 		FROM study_plan sp
 		JOIN subjects s ON sp.subject_id = s.id
-		WHERE sp.study_date = $1
-		ORDER BY sp.id
+		WHERE sp.study_date = $1 AND sp.deleted_at IS NULL
+		ORDER BY sp.start_time NULLS LAST, sp.id
 	`, today)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		utils.Fail(c, err)
 		return
 	}
 	defer rows.Close()
@@ -62,9 +69,9 @@ func GetTodayStudyPlan(c *gin.Context) {
 	for rows.Next() {
 		var sp models.StudyPlan
 		var studyDate time.Time
-		err := rows.Scan(&sp.ID, &sp.SubjectID, &sp.SubjectName, &sp.SubjectColor, &studyDate, &sp.HoursPlanned, &sp.HoursCompleted, &sp.Notes, &sp.CreatedAt)
+		err := rows.Scan(&sp.ID, &sp.SubjectID, &sp.SubjectName, &sp.SubjectColor, &studyDate, &sp.StartTime, &sp.EndTime, &sp.HoursPlanned, &sp.HoursCompleted, &sp.Notes, &sp.CreatedAt)
 		if err != nil {
-			utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+			utils.Fail(c, err)
 			return
 		}
 		sp.StudyDate = studyDate.Format("2006-01-02")
@@ -78,25 +85,50 @@ func GetTodayStudyPlan(c *gin.Context) {
 func CreateStudyPlan(c *gin.Context) {
 	var input models.CreateStudyPlanInput
 	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	if err := store.CheckSubject(c.Request.Context(), database.DB, input.SubjectID); err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	slotHours, err := planner.SlotHours(input.StartTime, input.EndTime)
+	if err != nil {
 		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	if input.HoursPlanned == 0 {
 		input.HoursPlanned = 1.0
+		if slotHours > 0 {
+			input.HoursPlanned = slotHours
+		}
+	}
+
+	entry := planner.Entry{
+		SubjectID: input.SubjectID,
+		StudyDate: input.StudyDate,
+		StartTime: input.StartTime,
+		EndTime:   input.EndTime,
+		Hours:     input.HoursPlanned,
+	}
+	if !checkPlanEntry(c, entry) {
+		return
 	}
 
 	var id int
-	err := database.DB.QueryRow(
-		"INSERT INTO study_plan (subject_id, study_date, hours_planned, notes) VALUES ($1, $2, $3, $4) RETURNING id",
-		input.SubjectID, input.StudyDate, input.HoursPlanned, input.Notes,
+	err = database.DB.QueryRowContext(c.Request.Context(),
+		"INSERT INTO study_plan (subject_id, study_date, start_time, end_time, hours_planned, notes) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id",
+		input.SubjectID, input.StudyDate, input.StartTime, input.EndTime, input.HoursPlanned, input.Notes,
 	).Scan(&id)
 
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		utils.Fail(c, err)
 		return
 	}
 
+	recordActivity(c, "study_plan", id, audit.Create, nil)
 	utils.SuccessResponse(c, http.StatusCreated, "Study plan created", gin.H{"id": id})
 }
 
@@ -110,7 +142,7 @@ func UpdateStudyPlan(c *gin.Context) {
 
 	var input models.UpdateStudyPlanInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		utils.Fail(c, err)
 		return
 	}
 
@@ -134,20 +166,62 @@ func UpdateStudyPlan(c *gin.Context) {
 		args = append(args, input.Notes)
 		argIndex++
 	}
+	if input.StartTime != nil {
+		query += "start_time = NULLIF($" + strconv.Itoa(argIndex) + ", '')::TIME, "
+		args = append(args, *input.StartTime)
+		argIndex++
+	}
+	if input.EndTime != nil {
+		query += "end_time = NULLIF($" + strconv.Itoa(argIndex) + ", '')::TIME, "
+		args = append(args, *input.EndTime)
+		argIndex++
+	}
 
 	if len(args) == 0 {
 		utils.ErrorResponse(c, http.StatusBadRequest, "No fields to update")
 		return
 	}
 
+	if input.HoursPlanned != nil || input.StartTime != nil || input.EndTime != nil {
+		// Validate the entry as it will look after the update
+		entry := planner.Entry{ID: id}
+		var studyDate time.Time
+		err := database.DB.QueryRowContext(c.Request.Context(), `
+			SELECT subject_id, study_date, TO_CHAR(start_time, 'HH24:MI'), TO_CHAR(end_time, 'HH24:MI'), hours_planned
+			FROM study_plan WHERE id = $1 AND deleted_at IS NULL
+		`, id).Scan(&entry.SubjectID, &studyDate, &entry.StartTime, &entry.EndTime, &entry.Hours)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusNotFound, "Study plan not found")
+			return
+		}
+		entry.StudyDate = studyDate.Format("2006-01-02")
+		if input.HoursPlanned != nil {
+			entry.Hours = *input.HoursPlanned
+		}
+		if input.StartTime != nil {
+			entry.StartTime = emptyToNil(*input.StartTime)
+		}
+		if input.EndTime != nil {
+			entry.EndTime = emptyToNil(*input.EndTime)
+		}
+		if _, err := planner.SlotHours(entry.StartTime, entry.EndTime); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		if !checkPlanEntry(c, entry) {
+			return
+		}
+	}
+
 	// Remove trailing comma and space
 	query = query[:len(query)-2]
-	query += " WHERE id = $" + strconv.Itoa(argIndex)
+	query += " WHERE id = $" + strconv.Itoa(argIndex) + " AND deleted_at IS NULL"
 	args = append(args, id)
 
-	result, err := database.DB.Exec(query, args...)
+	before := snapshot(c, "study_plan", id)
+	result, err := database.DB.ExecContext(c.Request.Context(), query, args...)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		utils.Fail(c, err)
 		return
 	}
 
@@ -157,10 +231,11 @@ func UpdateStudyPlan(c *gin.Context) {
 		return
 	}
 
+	recordActivity(c, "study_plan", id, audit.Update, before)
 	utils.SuccessResponse(c, http.StatusOK, "Study plan updated", nil)
 }
 
-// DeleteStudyPlan deletes a study plan
+// DeleteStudyPlan moves a study plan to the trash
 func DeleteStudyPlan(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
@@ -168,9 +243,10 @@ func DeleteStudyPlan(c *gin.Context) {
 		return
 	}
 
-	result, err := database.DB.Exec("DELETE FROM study_plan WHERE id = $1", id)
+	before := snapshot(c, "study_plan", id)
+	result, err := database.DB.ExecContext(c.Request.Context(), "UPDATE study_plan SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NULL", id)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		utils.Fail(c, err)
 		return
 	}
 
@@ -180,5 +256,95 @@ func DeleteStudyPlan(c *gin.Context) {
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "Study plan deleted", nil)
+	recordActivity(c, "study_plan", id, audit.Delete, before)
+	utils.SuccessResponse(c, http.StatusOK, "Study plan moved to trash", nil)
+}
+
+// GetStudyPlanIssues reports problems across the whole study plan
+func GetStudyPlanIssues(c *gin.Context) {
+	examDate, _ := utils.ExamDate()
+	issues, err := planner.FindIssues(c.Request.Context(), planner.DailyCap(), utils.Now(c), examDate)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Study plan issues retrieved", issues)
+}
+
+// checkPlanEntry runs the plan validation for an entry about to be saved and
+// responds with the issues found. It reports whether the entry may be saved.
+func checkPlanEntry(c *gin.Context, entry planner.Entry) bool {
+	examDate, _ := utils.ExamDate()
+	issues, err := planner.CheckEntry(c.Request.Context(), entry, planner.DailyCap(), examDate)
+	if err != nil {
+		utils.Fail(c, err)
+		return false
+	}
+
+	if len(issues) > 0 {
+		utils.ErrorResponseWithData(c, http.StatusUnprocessableEntity, issues[0].Message, issues)
+		return false
+	}
+	return true
+}
+
+// GetStudyPlanDay returns one day's plan as a timeline: timed sessions in
+// start order, then sessions without a time slot. The date defaults to today
+// in the request's timezone.
+func GetStudyPlanDay(c *gin.Context) {
+	date := c.Query("date")
+	if date == "" {
+		date = utils.Today(c)
+	} else if _, err := time.Parse("2006-01-02", date); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "date must be in YYYY-MM-DD format")
+		return
+	}
+
+	rows, err := database.DB.QueryContext(c.Request.Context(), `
+		SELECT sp.id, sp.subject_id, s.name, s.color, sp.study_date, TO_CHAR(sp.start_time, 'HH24:MI'), TO_CHAR(sp.end_time, 'HH24:MI'),
+			   sp.hours_planned, sp.hours_completed, COALESCE(sp.notes, ''), sp.created_at
+		FROM study_plan sp
+		JOIN subjects s ON sp.subject_id = s.id
+		WHERE sp.study_date = $1 AND sp.deleted_at IS NULL AND s.deleted_at IS NULL
+		ORDER BY sp.start_time NULLS LAST, sp.id
+	`, date)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	defer rows.Close()
+
+	day := models.DayView{
+		Date:        date,
+		Timezone:    utils.Location(c).String(),
+		Slots:       []models.StudyPlan{},
+		Unscheduled: []models.StudyPlan{},
+	}
+	for rows.Next() {
+		var sp models.StudyPlan
+		var studyDate time.Time
+		err := rows.Scan(&sp.ID, &sp.SubjectID, &sp.SubjectName, &sp.SubjectColor, &studyDate, &sp.StartTime, &sp.EndTime, &sp.HoursPlanned, &sp.HoursCompleted, &sp.Notes, &sp.CreatedAt)
+		if err != nil {
+			utils.Fail(c, err)
+			return
+		}
+		sp.StudyDate = studyDate.Format("2006-01-02")
+		day.TotalHours += sp.HoursPlanned
+		if sp.StartTime != nil {
+			day.Slots = append(day.Slots, sp)
+		} else {
+			day.Unscheduled = append(day.Unscheduled, sp)
+		}
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Study plan day retrieved", day)
+}
+
+// emptyToNil turns an empty string into nil
+func emptyToNil(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
 }
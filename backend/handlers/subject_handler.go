@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"exam-prep/audit"
 	"exam-prep/database"
 	"exam-prep/models"
 	"exam-prep/utils"
@@ -12,18 +13,19 @@ import (
 
 // GetAllSubjects returns all subjects with their progress
 func GetAllSubjects(c *gin.Context) {
-	rows, err := database.DB.Query(`
-		SELECT s.id, s.name, s.description, s.color, s.created_at,
+	rows, err := database.DB.QueryContext(c.Request.Context(), `
+		SELECT s.id, s.name, COALESCE(s.description, ''), s.color, TO_CHAR(s.exam_date, 'YYYY-MM-DD'), s.created_at,
 			   COALESCE(COUNT(t.id), 0) as total_topics,
 			   COALESCE(SUM(CASE WHEN t.is_completed THEN 1 ELSE 0 END), 0) as completed_topics,
 			   COALESCE(SUM(CASE WHEN t.is_weak THEN 1 ELSE 0 END), 0) as weak_topics
 		FROM subjects s
-		LEFT JOIN topics t ON s.id = t.subject_id
+		LEFT JOIN topics t ON s.id = t.subject_id AND t.deleted_at IS NULL
+		WHERE s.deleted_at IS NULL
 		GROUP BY s.id
 		ORDER BY s.id
 	`)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		utils.Fail(c, err)
 		return
 	}
 	defer rows.Close()
@@ -31,10 +33,10 @@ func GetAllSubjects(c *gin.Context) {
 	var subjects []models.SubjectWithProgress
 	for rows.Next() {
 		var s models.SubjectWithProgress
-		err := rows.Scan(&s.ID, &s.Name, &s.Description, &s.Color, &s.CreatedAt,
+		err := rows.Scan(&s.ID, &s.Name, &s.Description, &s.Color, &s.ExamDate, &s.CreatedAt,
 			&s.TotalTopics, &s.CompletedTopics, &s.WeakTopics)
 		if err != nil {
-			utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+			utils.Fail(c, err)
 			return
 		}
 		if s.TotalTopics > 0 {
@@ -55,16 +57,16 @@ func GetSubject(c *gin.Context) {
 	}
 
 	var s models.SubjectWithProgress
-	err = database.DB.QueryRow(`
-		SELECT s.id, s.name, s.description, s.color, s.created_at,
+	err = database.DB.QueryRowContext(c.Request.Context(), `
+		SELECT s.id, s.name, COALESCE(s.description, ''), s.color, TO_CHAR(s.exam_date, 'YYYY-MM-DD'), s.created_at,
 			   COALESCE(COUNT(t.id), 0) as total_topics,
 			   COALESCE(SUM(CASE WHEN t.is_completed THEN 1 ELSE 0 END), 0) as completed_topics,
 			   COALESCE(SUM(CASE WHEN t.is_weak THEN 1 ELSE 0 END), 0) as weak_topics
 		FROM subjects s
-		LEFT JOIN topics t ON s.id = t.subject_id
-		WHERE s.id = $1
+		LEFT JOIN topics t ON s.id = t.subject_id AND t.deleted_at IS NULL
+		WHERE s.id = $1 AND s.deleted_at IS NULL
 		GROUP BY s.id
-	`, id).Scan(&s.ID, &s.Name, &s.Description, &s.Color, &s.CreatedAt,
+	`, id).Scan(&s.ID, &s.Name, &s.Description, &s.Color, &s.ExamDate, &s.CreatedAt,
 		&s.TotalTopics, &s.CompletedTopics, &s.WeakTopics)
 
 	if err != nil {
@@ -83,7 +85,7 @@ func GetSubject(c *gin.Context) {
 func CreateSubject(c *gin.Context) {
 	var input models.CreateSubjectInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		utils.Fail(c, err)
 		return
 	}
 
@@ -92,16 +94,17 @@ func CreateSubject(c *gin.Context) {
 	}
 
 	var id int
-	err := database.DB.QueryRow(
-		"INSERT INTO subjects (name, description, color) VALUES ($1, $2, $3) RETURNING id",
-		input.Name, input.Description, input.Color,
+	err := database.DB.QueryRowContext(c.Request.Context(),
+		"INSERT INTO subjects (name, description, color, exam_date) VALUES ($1, $2, $3, $4) RETURNING id",
+		input.Name, input.Description, input.Color, input.ExamDate,
 	).Scan(&id)
 
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		utils.Fail(c, err)
 		return
 	}
 
+	recordActivity(c, "subject", id, audit.Create, nil)
 	utils.SuccessResponse(c, http.StatusCreated, "Subject created", gin.H{"id": id})
 }
 
@@ -115,17 +118,18 @@ func UpdateSubject(c *gin.Context) {
 
 	var input models.UpdateSubjectInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		utils.Fail(c, err)
 		return
 	}
 
-	result, err := database.DB.Exec(
-		"UPDATE subjects SET name = COALESCE(NULLIF($1, ''), name), description = COALESCE(NULLIF($2, ''), description), color = COALESCE(NULLIF($3, ''), color) WHERE id = $4",
-		input.Name, input.Description, input.Color, id,
+	before := snapshot(c, "subject", id)
+	result, err := database.DB.ExecContext(c.Request.Context(),
+		"UPDATE subjects SET name = COALESCE(NULLIF($1, ''), name), description = COALESCE(NULLIF($2, ''), description), color = COALESCE(NULLIF($3, ''), color), exam_date = COALESCE(NULLIF($4, '')::DATE, exam_date) WHERE id = $5 AND deleted_at IS NULL",
+		input.Name, input.Description, input.Color, input.ExamDate, id,
 	)
 
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		utils.Fail(c, err)
 		return
 	}
 
@@ -135,10 +139,11 @@ func UpdateSubject(c *gin.Context) {
 		return
 	}
 
+	recordActivity(c, "subject", id, audit.Update, before)
 	utils.SuccessResponse(c, http.StatusOK, "Subject updated", nil)
 }
 
-// DeleteSubject deletes a subject
+// DeleteSubject moves a subject and everything under it to the trash
 func DeleteSubject(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
@@ -146,17 +151,18 @@ func DeleteSubject(c *gin.Context) {
 		return
 	}
 
-	result, err := database.DB.Exec("DELETE FROM subjects WHERE id = $1", id)
+	before := snapshot(c, "subject", id)
+	found, err := database.SoftDeleteSubject(c.Request.Context(), id)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		utils.Fail(c, err)
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
+	if !found {
 		utils.ErrorResponse(c, http.StatusNotFound, "Subject not found")
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "Subject deleted", nil)
+	recordActivity(c, "subject", id, audit.Delete, before)
+	utils.SuccessResponse(c, http.StatusOK, "Subject moved to trash", nil)
 }
@@ -1,9 +1,16 @@
 package handlers
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"exam-prep/audit"
 	"exam-prep/database"
 	"exam-prep/models"
+	"exam-prep/planner"
+	"exam-prep/store"
 	"exam-prep/utils"
+	"log/slog"
 	"net/http"
 	"strconv"
 
@@ -18,12 +25,12 @@ func GetTopicsBySubject(c *gin.Context) {
 		return
 	}
 
-	rows, err := database.DB.Query(
-		"SELECT id, subject_id, name, is_completed, is_weak, created_at FROM topics WHERE subject_id = $1 ORDER BY id",
+	rows, err := database.DB.QueryContext(c.Request.Context(),
+		"SELECT id, subject_id, name, is_completed, is_weak, confidence, created_at FROM topics WHERE subject_id = $1 AND deleted_at IS NULL ORDER BY id",
 		subjectID,
 	)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		utils.Fail(c, err)
 		return
 	}
 	defer rows.Close()
@@ -31,9 +38,9 @@ func GetTopicsBySubject(c *gin.Context) {
 	var topics []models.Topic
 	for rows.Next() {
 		var t models.Topic
-		err := rows.Scan(&t.ID, &t.SubjectID, &t.Name, &t.IsCompleted, &t.IsWeak, &t.CreatedAt)
+		err := rows.Scan(&t.ID, &t.SubjectID, &t.Name, &t.IsCompleted, &t.IsWeak, &t.Confidence, &t.CreatedAt)
 		if err != nil {
-			utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+			utils.Fail(c, err)
 			return
 		}
 		topics = append(topics, t)
@@ -46,21 +53,26 @@ func GetTopicsBySubject(c *gin.Context) {
 func CreateTopic(c *gin.Context) {
 	var input models.CreateTopicInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		utils.Fail(c, err)
+		return
+	}
+	if err := store.CheckSubject(c.Request.Context(), database.DB, input.SubjectID); err != nil {
+		utils.Fail(c, err)
 		return
 	}
 
 	var id int
-	err := database.DB.QueryRow(
+	err := database.DB.QueryRowContext(c.Request.Context(),
 		"INSERT INTO topics (subject_id, name) VALUES ($1, $2) RETURNING id",
 		input.SubjectID, input.Name,
 	).Scan(&id)
 
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		utils.Fail(c, err)
 		return
 	}
 
+	recordActivity(c, "topic", id, audit.Create, nil)
 	utils.SuccessResponse(c, http.StatusCreated, "Topic created", gin.H{"id": id})
 }
 
@@ -74,7 +86,11 @@ func UpdateTopic(c *gin.Context) {
 
 	var input models.UpdateTopicInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		utils.Fail(c, err)
+		return
+	}
+	if input.Name == "" && input.IsCompleted == nil {
+		utils.Fail(c, utils.InvalidRequest("Nothing to update: give name or is_completed"))
 		return
 	}
 
@@ -93,20 +109,16 @@ func UpdateTopic(c *gin.Context) {
 		args = append(args, *input.IsCompleted)
 		argIndex++
 	}
-	if input.IsWeak != nil {
-		query += "is_weak = $" + strconv.Itoa(argIndex) + ", "
-		args = append(args, *input.IsWeak)
-		argIndex++
-	}
 
 	// Remove trailing comma and space
 	query = query[:len(query)-2]
-	query += " WHERE id = $" + strconv.Itoa(argIndex)
+	query += " WHERE id = $" + strconv.Itoa(argIndex) + " AND deleted_at IS NULL"
 	args = append(args, id)
 
-	result, err := database.DB.Exec(query, args...)
+	before := snapshot(c, "topic", id)
+	result, err := database.DB.ExecContext(c.Request.Context(), query, args...)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		utils.Fail(c, err)
 		return
 	}
 
@@ -116,6 +128,14 @@ func UpdateTopic(c *gin.Context) {
 		return
 	}
 
+	recordActivity(c, "topic", id, audit.Update, before)
+
+	if input.IsCompleted != nil && *input.IsCompleted {
+		if warning := prerequisiteWarning(c, id); warning != nil {
+			utils.SuccessResponse(c, http.StatusOK, "Topic updated, but some prerequisites are still incomplete", warning)
+			return
+		}
+	}
 	utils.SuccessResponse(c, http.StatusOK, "Topic updated", nil)
 }
 
@@ -127,24 +147,42 @@ func ToggleTopicComplete(c *gin.Context) {
 		return
 	}
 
-	result, err := database.DB.Exec(
-		"UPDATE topics SET is_completed = NOT is_completed WHERE id = $1", id,
-	)
+	before := snapshot(c, "topic", id)
+	value, err := toggleTopicComplete(c.Request.Context(), id)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		utils.Fail(c, err)
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		utils.ErrorResponse(c, http.StatusNotFound, "Topic not found")
-		return
+	action := audit.Uncomplete
+	if value {
+		action = audit.Complete
 	}
+	recordActivity(c, "topic", id, action, before)
 
+	if value {
+		if warning := prerequisiteWarning(c, id); warning != nil {
+			utils.SuccessResponse(c, http.StatusOK, "Topic completed, but some prerequisites are still incomplete", warning)
+			return
+		}
+	}
 	utils.SuccessResponse(c, http.StatusOK, "Topic completion toggled", nil)
 }
 
-// ToggleTopicWeak toggles the weak status of a topic
+// toggleTopicComplete flips a topic's completion and returns the new value
+func toggleTopicComplete(ctx context.Context, id int) (bool, error) {
+	var value bool
+	err := database.DB.QueryRowContext(ctx,
+		"UPDATE topics SET is_completed = NOT is_completed WHERE id = $1 AND deleted_at IS NULL RETURNING is_completed", id,
+	).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, utils.NotFound("Topic not found")
+	}
+	return value, err
+}
+
+// ToggleTopicWeak flips the weak status of a topic. The flip is recorded as a
+// low or high confidence review so is_weak stays derived from the history.
 func ToggleTopicWeak(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
@@ -152,24 +190,138 @@ func ToggleTopicWeak(c *gin.Context) {
 		return
 	}
 
-	result, err := database.DB.Exec(
-		"UPDATE topics SET is_weak = NOT is_weak WHERE id = $1", id,
-	)
+	before := snapshot(c, "topic", id)
+	action, err := toggleTopicWeak(c, id)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		utils.Fail(c, err)
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
+	recordActivity(c, "topic", id, action, before)
+	utils.SuccessResponse(c, http.StatusOK, "Topic weak status toggled", nil)
+}
+
+// toggleTopicWeak records the review that flips a topic's weak status and
+// returns the activity action for it
+func toggleTopicWeak(c *gin.Context, id int) (string, error) {
+	var isWeak bool
+	err := database.DB.QueryRowContext(c.Request.Context(), "SELECT is_weak FROM topics WHERE id = $1 AND deleted_at IS NULL", id).Scan(&isWeak)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", utils.NotFound("Topic not found")
+	}
+	if err != nil {
+		return "", err
+	}
+
+	action, confidence, comment := audit.MarkWeak, database.WeakConfidence, "Marked weak"
+	if isWeak {
+		action, confidence, comment = audit.UnmarkWeak, database.WeakConfidence+2, "No longer weak"
+	}
+
+	review, err := database.RecordTopicReview(c.Request.Context(), id, confidence, comment, utils.Actor(c))
+	if err != nil {
+		return "", err
+	}
+	if review == nil {
+		return "", utils.NotFound("Topic not found")
+	}
+	return action, nil
+}
+
+// ReviewTopic records a 1-5 confidence self-assessment for a topic
+func ReviewTopic(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid topic ID")
+		return
+	}
+
+	var input models.CreateTopicReviewInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	before := snapshot(c, "topic", id)
+	review, err := database.RecordTopicReview(c.Request.Context(), id, input.Confidence, input.Comment, utils.Actor(c))
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	if review == nil {
 		utils.ErrorResponse(c, http.StatusNotFound, "Topic not found")
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "Topic weak status toggled", nil)
+	recordActivity(c, "topic", id, audit.Review, before)
+	utils.SuccessResponse(c, http.StatusCreated, "Topic review recorded", review)
 }
 
-// DeleteTopic deletes a topic
+// GetTopicHistory returns a topic with its confidence review history
+func GetTopicHistory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid topic ID")
+		return
+	}
+
+	var history models.TopicHistory
+	t := &history.Topic
+	err = database.DB.QueryRowContext(c.Request.Context(),
+		"SELECT id, subject_id, name, is_completed, is_weak, confidence, created_at FROM topics WHERE id = $1 AND deleted_at IS NULL", id,
+	).Scan(&t.ID, &t.SubjectID, &t.Name, &t.IsCompleted, &t.IsWeak, &t.Confidence, &t.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		utils.ErrorResponse(c, http.StatusNotFound, "Topic not found")
+		return
+	}
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	history.Reviews, err = database.TopicReviews(c.Request.Context(), id)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Topic history retrieved", history)
+}
+
+// GetWeakestTopics ranks topics by recent confidence, weakest first. Filter
+// with ?subject_id=, cap with ?limit= and add completed topics with
+// ?include_completed=true.
+func GetWeakestTopics(c *gin.Context) {
+	subjectID := 0
+	if value := c.Query("subject_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid subject_id")
+			return
+		}
+		subjectID = id
+	}
+
+	limit := 10
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 100 {
+			utils.ErrorResponse(c, http.StatusBadRequest, "limit must be between 1 and 100")
+			return
+		}
+		limit = parsed
+	}
+
+	topics, err := database.WeakestTopics(c.Request.Context(), subjectID, limit, c.Query("include_completed") == "true")
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Weakest topics retrieved", topics)
+}
+
+// DeleteTopic moves a topic to the trash
 func DeleteTopic(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
@@ -177,9 +329,10 @@ func DeleteTopic(c *gin.Context) {
 		return
 	}
 
-	result, err := database.DB.Exec("DELETE FROM topics WHERE id = $1", id)
+	before := snapshot(c, "topic", id)
+	result, err := database.DB.ExecContext(c.Request.Context(), "UPDATE topics SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NULL", id)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		utils.Fail(c, err)
 		return
 	}
 
@@ -189,5 +342,21 @@ func DeleteTopic(c *gin.Context) {
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "Topic deleted", nil)
+	recordActivity(c, "topic", id, audit.Delete, before)
+	utils.SuccessResponse(c, http.StatusOK, "Topic moved to trash", nil)
+}
+
+// prerequisiteWarning lists the incomplete prerequisites of a topic that was
+// just completed, or returns nil when there are none. Completion is allowed
+// either way; the warning only lets the client point out what was skipped.
+func prerequisiteWarning(c *gin.Context, id int) gin.H {
+	incomplete, err := planner.IncompletePrerequisites(c.Request.Context(), id)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "Failed to check prerequisites", "topic_id", id, "error", err)
+		return nil
+	}
+	if len(incomplete) == 0 {
+		return nil
+	}
+	return gin.H{"warnings": []string{"incomplete_prerequisites"}, "incomplete_prerequisites": incomplete}
 }
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"exam-prep/audit"
+	"exam-prep/database"
+	"exam-prep/models"
+	"exam-prep/utils"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetTrash returns every soft-deleted item, most recently deleted first
+func GetTrash(c *gin.Context) {
+	rows, err := database.DB.QueryContext(c.Request.Context(), `
+		SELECT 'subject', id, NULL::INTEGER, name, deleted_at FROM subjects WHERE deleted_at IS NOT NULL
+		UNION ALL
+		SELECT 'topic', id, subject_id, name, deleted_at FROM topics WHERE deleted_at IS NOT NULL
+		UNION ALL
+		SELECT 'note', id, subject_id, title, deleted_at FROM notes WHERE deleted_at IS NOT NULL
+		UNION ALL
+		SELECT 'study_plan', id, subject_id, TO_CHAR(study_date, 'YYYY-MM-DD'), deleted_at FROM study_plan WHERE deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC
+	`)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	defer rows.Close()
+
+	var items []models.TrashItem
+	for rows.Next() {
+		var item models.TrashItem
+		err := rows.Scan(&item.Type, &item.ID, &item.SubjectID, &item.Name, &item.DeletedAt)
+		if err != nil {
+			utils.Fail(c, err)
+			return
+		}
+		items = append(items, item)
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Trash retrieved", items)
+}
+
+// RestoreSubject restores a subject and everything deleted along with it
+func RestoreSubject(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid subject ID")
+		return
+	}
+
+	found, err := database.RestoreSubject(c.Request.Context(), id)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	if !found {
+		utils.ErrorResponse(c, http.StatusNotFound, "Subject not found in trash")
+		return
+	}
+
+	recordActivity(c, "subject", id, audit.Restore, nil)
+	utils.SuccessResponse(c, http.StatusOK, "Subject restored", nil)
+}
+
+// RestoreTopic restores a topic from the trash
+func RestoreTopic(c *gin.Context) {
+	restoreChild(c, "topic", "Topic")
+}
+
+// RestoreNote restores a note from the trash
+func RestoreNote(c *gin.Context) {
+	restoreChild(c, "note", "Note")
+}
+
+// RestoreStudyPlan restores a study plan entry from the trash
+func RestoreStudyPlan(c *gin.Context) {
+	restoreChild(c, "study_plan", "Study plan")
+}
+
+// restoreChild restores a row that belongs to a subject. The subject itself
+// has to be restored first if it is still in the trash.
+func restoreChild(c *gin.Context, entityType, label string) {
+	table := entityTables[entityType]
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid "+strings.ToLower(label)+" ID")
+		return
+	}
+
+	var subjectDeleted bool
+	err = database.DB.QueryRowContext(c.Request.Context(), `
+		SELECT s.deleted_at IS NOT NULL
+		FROM `+table+` x
+		JOIN subjects s ON x.subject_id = s.id
+		WHERE x.id = $1 AND x.deleted_at IS NOT NULL
+	`, id).Scan(&subjectDeleted)
+	if errors.Is(err, sql.ErrNoRows) {
+		utils.ErrorResponse(c, http.StatusNotFound, label+" not found in trash")
+		return
+	}
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	if subjectDeleted {
+		utils.ErrorResponse(c, http.StatusConflict, "Restore the subject first")
+		return
+	}
+
+	_, err = database.DB.ExecContext(c.Request.Context(), "UPDATE "+table+" SET deleted_at = NULL WHERE id = $1", id)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	recordActivity(c, entityType, id, audit.Restore, nil)
+	utils.SuccessResponse(c, http.StatusOK, label+" restored", nil)
+}
@@ -0,0 +1,364 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"exam-prep/audit"
+	"exam-prep/database"
+	"exam-prep/models"
+	"exam-prep/planner"
+	"exam-prep/store"
+	"exam-prep/utils"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The v2 handlers serve subjects, topics, notes and study plan entries under
+// /api/v2 with one set of conventions: writes respond with the whole
+// resource as GET returns it, lists are paged and empty lists are [], and
+// timestamps are UTC to the second. Updates are JSON merge patches. /api
+// keeps its original responses for existing clients.
+
+// v2Resource describes how /api/v2 lists and loads one kind of resource
+type v2Resource struct {
+	label   string // e.g. "Subject", for messages
+	from    string // table and alias for counting
+	id      string // ID column
+	alive   string // condition that leaves out the trash
+	order   string
+	filters []v2Filter
+	load    func(ctx context.Context, where string, args ...interface{}) ([]interface{}, error)
+}
+
+// v2Filter is a list's query parameter. condition has %d where the
+// parameter's placeholder goes.
+type v2Filter struct {
+	param     string
+	condition string
+	parse     func(param, value string) (interface{}, error)
+}
+
+var v2Resources = map[string]*v2Resource{
+	store.Subject: {
+		label: "Subject",
+		from:  "subjects s",
+		id:    "s.id",
+		alive: "s.deleted_at IS NULL",
+		order: "s.id",
+		load:  loadSubjectsWithProgress,
+	},
+	store.Topic: {
+		label: "Topic",
+		from:  "topics",
+		id:    "id",
+		alive: "deleted_at IS NULL",
+		order: "subject_id, id",
+		filters: []v2Filter{
+			{"subject_id", "subject_id = $%d", v2Int},
+			{"completed", "is_completed = $%d", v2Bool},
+			{"weak", "is_weak = $%d", v2Bool},
+		},
+		load: func(ctx context.Context, where string, args ...interface{}) ([]interface{}, error) {
+			topics, err := queryTopics(ctx, where, args...)
+			items := make([]interface{}, len(topics))
+			for i := range topics {
+				items[i] = topics[i]
+			}
+			return items, err
+		},
+	},
+	store.Note: {
+		label: "Note",
+		from:  "notes",
+		id:    "id",
+		alive: "deleted_at IS NULL",
+		order: "updated_at DESC, id",
+		filters: []v2Filter{
+			{"subject_id", "subject_id = $%d", v2Int},
+			{"topic_id", "topic_id = $%d", v2Int},
+		},
+		load: func(ctx context.Context, where string, args ...interface{}) ([]interface{}, error) {
+			notes, err := queryNotes(ctx, where, args...)
+			items := make([]interface{}, len(notes))
+			for i := range notes {
+				items[i] = notes[i]
+			}
+			return items, err
+		},
+	},
+	store.StudyPlan: {
+		label: "Study plan",
+		from:  "study_plan sp",
+		id:    "sp.id",
+		alive: "sp.deleted_at IS NULL",
+		order: "sp.study_date, sp.start_time NULLS LAST, sp.id",
+		filters: []v2Filter{
+			{"subject_id", "sp.subject_id = $%d", v2Int},
+			{"from", "sp.study_date >= $%d", v2Date},
+			{"to", "sp.study_date <= $%d", v2Date},
+		},
+		load: func(ctx context.Context, where string, args ...interface{}) ([]interface{}, error) {
+			plans, err := queryStudyPlans(ctx, where, args...)
+			items := make([]interface{}, len(plans))
+			for i := range plans {
+				items[i] = plans[i]
+			}
+			return items, err
+		},
+	},
+}
+
+// ListV2 lists a page of a resource, filtered by its query parameters
+func ListV2(resource string) gin.HandlerFunc {
+	r := v2Resources[resource]
+	return func(c *gin.Context) {
+		page, perPage, err := utils.PageParams(c)
+		if err != nil {
+			utils.Fail(c, err)
+			return
+		}
+		where, args, err := r.where(c)
+		if err != nil {
+			utils.Fail(c, err)
+			return
+		}
+
+		var total int
+		if err := database.DB.QueryRowContext(c.Request.Context(), "SELECT COUNT(*) FROM "+r.from+" "+where, args...).Scan(&total); err != nil {
+			utils.Fail(c, err)
+			return
+		}
+		pagination := utils.NewPagination(page, perPage, total)
+		items, err := r.load(c.Request.Context(),
+			fmt.Sprintf("%s ORDER BY %s LIMIT $%d OFFSET $%d", where, r.order, len(args)+1, len(args)+2),
+			append(args, perPage, pagination.Offset())...,
+		)
+		if err != nil {
+			utils.Fail(c, err)
+			return
+		}
+
+		utils.PageResponse(c, r.label+" list retrieved", items, pagination)
+	}
+}
+
+// GetV2 returns one resource
+func GetV2(resource string) gin.HandlerFunc {
+	r := v2Resources[resource]
+	return func(c *gin.Context) {
+		id, ok := r.param(c)
+		if !ok {
+			return
+		}
+		item, err := r.get(c.Request.Context(), resource, id)
+		if err != nil {
+			utils.Fail(c, err)
+			return
+		}
+		utils.SuccessResponse(c, http.StatusOK, r.label+" retrieved", item)
+	}
+}
+
+// CreateV2 creates a resource and responds with it
+func CreateV2(resource string) gin.HandlerFunc {
+	r := v2Resources[resource]
+	return func(c *gin.Context) {
+		body, err := c.GetRawData()
+		if err != nil {
+			utils.Fail(c, err)
+			return
+		}
+
+		examDate, _ := utils.ExamDate()
+		var id int
+		err = inTx(c.Request.Context(), func(tx *sql.Tx) (err error) {
+			id, err = store.Create(c.Request.Context(), tx, resource, body, planner.DailyCap(), examDate)
+			return err
+		})
+		if err != nil {
+			utils.Fail(c, err)
+			return
+		}
+
+		recordActivity(c, resource, id, audit.Create, nil)
+		r.respond(c, resource, id, http.StatusCreated, r.label+" created")
+	}
+}
+
+// UpdateV2 applies a merge patch to a resource and responds with it
+func UpdateV2(resource string) gin.HandlerFunc {
+	r := v2Resources[resource]
+	return func(c *gin.Context) {
+		id, patch, ok := patchRequest(c, "Invalid "+strings.ToLower(r.label)+" ID")
+		if !ok {
+			return
+		}
+
+		examDate, _ := utils.ExamDate()
+		before := snapshot(c, resource, id)
+		var completed bool
+		err := inTx(c.Request.Context(), func(tx *sql.Tx) (err error) {
+			_, completed, err = store.Patch(c.Request.Context(), tx, resource, id, patch, planner.DailyCap(), examDate)
+			return err
+		})
+		if err != nil {
+			utils.Fail(c, err)
+			return
+		}
+
+		recordActivity(c, resource, id, audit.Update, before)
+		message := r.label + " updated"
+		if completed && prerequisiteWarning(c, id) != nil {
+			message += ", but some prerequisites are still incomplete"
+		}
+		r.respond(c, resource, id, http.StatusOK, message)
+	}
+}
+
+// DeleteV2 moves a resource to the trash and responds with it as it was
+func DeleteV2(resource string) gin.HandlerFunc {
+	r := v2Resources[resource]
+	return func(c *gin.Context) {
+		id, ok := r.param(c)
+		if !ok {
+			return
+		}
+		item, err := r.get(c.Request.Context(), resource, id)
+		if err != nil {
+			utils.Fail(c, err)
+			return
+		}
+
+		before := snapshot(c, resource, id)
+		err = inTx(c.Request.Context(), func(tx *sql.Tx) error {
+			return store.Delete(c.Request.Context(), tx, resource, id)
+		})
+		if err != nil {
+			utils.Fail(c, err)
+			return
+		}
+
+		recordActivity(c, resource, id, audit.Delete, before)
+		utils.SuccessResponse(c, http.StatusOK, r.label+" moved to trash", item)
+	}
+}
+
+// param reads the ID path parameter, responding if it is invalid
+func (r *v2Resource) param(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid "+strings.ToLower(r.label)+" ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// get loads one resource outside the trash
+func (r *v2Resource) get(ctx context.Context, resource string, id int) (interface{}, error) {
+	items, err := r.load(ctx, "WHERE "+r.alive+" AND "+r.id+" = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, store.NotFound(resource)
+	}
+	return items[0], nil
+}
+
+// respond sends a resource after writing it
+func (r *v2Resource) respond(c *gin.Context, resource string, id, status int, message string) {
+	item, err := r.get(c.Request.Context(), resource, id)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	utils.SuccessResponse(c, status, message, item)
+}
+
+// where builds a list's WHERE clause from its filters
+func (r *v2Resource) where(c *gin.Context) (string, []interface{}, error) {
+	conditions := []string{r.alive}
+	var args []interface{}
+	var fields []utils.FieldError
+	for _, f := range r.filters {
+		value := c.Query(f.param)
+		if value == "" {
+			continue
+		}
+		arg, err := f.parse(f.param, value)
+		if err != nil {
+			fields = append(fields, utils.AsAppError(err).Fields...)
+			continue
+		}
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(f.condition, len(args)))
+	}
+	if len(fields) > 0 {
+		return "", nil, utils.Invalid(fields...)
+	}
+	return "WHERE " + strings.Join(conditions, " AND "), args, nil
+}
+
+func v2Int(param, value string) (interface{}, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, utils.Invalid(utils.FieldError{Field: param, Rule: "number", Message: param + " must be a whole number"})
+	}
+	return n, nil
+}
+
+func v2Bool(param, value string) (interface{}, error) {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, utils.Invalid(utils.FieldError{Field: param, Rule: "boolean", Message: param + " must be true or false"})
+	}
+	return b, nil
+}
+
+func v2Date(param, value string) (interface{}, error) {
+	if !utils.ValidDate(value) {
+		return nil, utils.Invalid(utils.FieldError{Field: param, Rule: "date", Message: param + " must be a date (YYYY-MM-DD)"})
+	}
+	return value, nil
+}
+
+// loadSubjectsWithProgress loads subjects (aliased s) with their topic
+// counts, for a WHERE clause and ordering
+func loadSubjectsWithProgress(ctx context.Context, where string, args ...interface{}) ([]interface{}, error) {
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT s.id, s.name, COALESCE(s.description, ''), s.color, TO_CHAR(s.exam_date, 'YYYY-MM-DD'), s.created_at,
+			   c.total, c.completed, c.weak
+		FROM subjects s
+		CROSS JOIN LATERAL (
+			SELECT COUNT(*) AS total,
+				   COUNT(*) FILTER (WHERE t.is_completed) AS completed,
+				   COUNT(*) FILTER (WHERE t.is_weak) AS weak
+			FROM topics t
+			WHERE t.subject_id = s.id AND t.deleted_at IS NULL
+		) c
+		`+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subjects := []interface{}{}
+	for rows.Next() {
+		var s models.SubjectWithProgress
+		err := rows.Scan(&s.ID, &s.Name, &s.Description, &s.Color, &s.ExamDate, &s.CreatedAt,
+			&s.TotalTopics, &s.CompletedTopics, &s.WeakTopics)
+		if err != nil {
+			return nil, err
+		}
+		if s.TotalTopics > 0 {
+			s.Progress = float64(s.CompletedTopics) / float64(s.TotalTopics) * 100
+		}
+		s.CreatedAt = utcSecond(s.CreatedAt)
+		subjects = append(subjects, s)
+	}
+	return subjects, rows.Err()
+}
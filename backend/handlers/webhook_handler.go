@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"exam-prep/database"
+	"exam-prep/models"
+	"exam-prep/utils"
+	"exam-prep/webhooks"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// GetAllWebhooks returns all webhook subscriptions. Secrets are not included.
+func GetAllWebhooks(c *gin.Context) {
+	rows, err := database.DB.QueryContext(c.Request.Context(), "SELECT id, url, event_types, active, created_at FROM webhooks ORDER BY id")
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	defer rows.Close()
+
+	var hooks []models.Webhook
+	for rows.Next() {
+		var w models.Webhook
+		err := rows.Scan(&w.ID, &w.URL, pq.Array(&w.EventTypes), &w.Active, &w.CreatedAt)
+		if err != nil {
+			utils.Fail(c, err)
+			return
+		}
+		hooks = append(hooks, w)
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Webhooks retrieved", hooks)
+}
+
+// CreateWebhook creates a webhook subscription. The signing secret is
+// generated when not supplied and is only returned in this response.
+func CreateWebhook(c *gin.Context) {
+	var input models.CreateWebhookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	if !validWebhookURL(input.URL) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "url must be an absolute http or https URL")
+		return
+	}
+
+	if input.Secret == "" {
+		secret, err := webhooks.NewSecret()
+		if err != nil {
+			utils.Fail(c, err)
+			return
+		}
+		input.Secret = secret
+	}
+	if input.EventTypes == nil {
+		input.EventTypes = []string{}
+	}
+
+	var id int
+	err := database.DB.QueryRowContext(c.Request.Context(),
+		"INSERT INTO webhooks (url, secret, event_types) VALUES ($1, $2, $3) RETURNING id",
+		input.URL, input.Secret, pq.Array(input.EventTypes),
+	).Scan(&id)
+
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Webhook created", gin.H{"id": id, "secret": input.Secret})
+}
+
+// UpdateWebhook updates a webhook's URL, event types or active flag
+func UpdateWebhook(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid webhook ID")
+		return
+	}
+
+	var input models.UpdateWebhookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	if input.URL != "" && !validWebhookURL(input.URL) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "url must be an absolute http or https URL")
+		return
+	}
+
+	var eventTypes interface{}
+	if input.EventTypes != nil {
+		eventTypes = pq.Array(input.EventTypes)
+	}
+
+	result, err := database.DB.ExecContext(c.Request.Context(),
+		"UPDATE webhooks SET url = COALESCE(NULLIF($1, ''), url), event_types = COALESCE($2, event_types), active = COALESCE($3, active) WHERE id = $4",
+		input.URL, eventTypes, input.Active, id,
+	)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		utils.ErrorResponse(c, http.StatusNotFound, "Webhook not found")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Webhook updated", nil)
+}
+
+// DeleteWebhook deletes a webhook and its delivery log
+func DeleteWebhook(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid webhook ID")
+		return
+	}
+
+	result, err := database.DB.ExecContext(c.Request.Context(), "DELETE FROM webhooks WHERE id = $1", id)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		utils.ErrorResponse(c, http.StatusNotFound, "Webhook not found")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Webhook deleted", nil)
+}
+
+// GetWebhookDeliveries returns the delivery log for a webhook, newest first
+func GetWebhookDeliveries(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid webhook ID")
+		return
+	}
+
+	limit := 50
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 500 {
+			utils.ErrorResponse(c, http.StatusBadRequest, "limit must be between 1 and 500")
+			return
+		}
+		limit = parsed
+	}
+
+	deliveries, err := webhooks.ListDeliveries(c.Request.Context(), id, limit)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Webhook deliveries retrieved", deliveries)
+}
+
+// TestWebhook sends a webhook.test event right away and returns the delivery
+// result. A failed test is retried like any other delivery.
+func TestWebhook(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid webhook ID")
+		return
+	}
+
+	var exists bool
+	err = database.DB.QueryRowContext(c.Request.Context(), "SELECT EXISTS (SELECT 1 FROM webhooks WHERE id = $1)", id).Scan(&exists)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	if !exists {
+		utils.ErrorResponse(c, http.StatusNotFound, "Webhook not found")
+		return
+	}
+
+	deliveryID, err := webhooks.EnqueueTest(c.Request.Context(), id)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	delivery, err := webhooks.Deliver(c.Request.Context(), deliveryID)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Test webhook sent", delivery)
+}
+
+// validWebhookURL checks that a webhook target is an absolute http(s) URL
+func validWebhookURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,51 @@
+package jobs
+
+import (
+	"context"
+	"exam-prep/audit"
+	"exam-prep/database"
+	"exam-prep/planner"
+	"exam-prep/utils"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// StartNightlyRebalance carries missed study hours forward once a day,
+// shortly after midnight in APP_TIMEZONE. Set STUDY_PLAN_AUTO_REBALANCE=false
+// to disable it.
+func StartNightlyRebalance() {
+	if os.Getenv("STUDY_PLAN_AUTO_REBALANCE") == "false" {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(15 * time.Minute)
+		defer ticker.Stop()
+
+		lastRun := ""
+		for range ticker.C {
+			now := time.Now().In(utils.DefaultLocation())
+			today := now.Format("2006-01-02")
+			if now.Hour() != 0 || lastRun == today {
+				continue
+			}
+			lastRun = today
+
+			ctx := context.Background()
+			examDate, _ := utils.ExamDate()
+			rebalance, err := planner.Rebalance(ctx, "system", planner.DailyCap(), now, examDate, false)
+			if err != nil {
+				slog.Warn("Nightly rebalance failed", "error", err)
+				continue
+			}
+			if len(rebalance.Moves) == 0 {
+				continue
+			}
+
+			slog.Info("Nightly rebalance moved missed study time", "blocks", len(rebalance.Moves))
+			after, _ := database.Snapshot(ctx, "plan_rebalances", rebalance.ID)
+			audit.Record(ctx, "system", "plan_rebalance", rebalance.ID, audit.Create, nil, after)
+		}
+	}()
+}
@@ -0,0 +1,27 @@
+package jobs
+
+import (
+	"context"
+	"exam-prep/reminders"
+	"log/slog"
+	"time"
+)
+
+// reminderInterval is how often reminder rules are evaluated
+const reminderInterval = 5 * time.Minute
+
+// StartReminderScheduler runs a background goroutine that evaluates the
+// reminder rules and sends any that are due
+func StartReminderScheduler() {
+	go func() {
+		ticker := time.NewTicker(reminderInterval)
+		defer ticker.Stop()
+
+		for {
+			if err := reminders.RunDue(context.Background(), time.Now()); err != nil {
+				slog.Warn("Failed to run reminders", "error", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
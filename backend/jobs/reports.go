@@ -0,0 +1,77 @@
+package jobs
+
+import (
+	"context"
+	"exam-prep/notify"
+	"exam-prep/reports"
+	"exam-prep/utils"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+)
+
+// defaultReportHour is the hour on Sunday the weekly report is generated
+const defaultReportHour = 18
+
+// StartWeeklyReport saves the weekly progress report every Sunday evening in
+// APP_TIMEZONE, at WEEKLY_REPORT_HOUR (default 18). When WEEKLY_REPORT_EMAIL
+// is set the report is also emailed there over SMTP.
+func StartWeeklyReport() {
+	hour := defaultReportHour
+	if value := os.Getenv("WEEKLY_REPORT_HOUR"); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed >= 0 && parsed < 24 {
+			hour = parsed
+		}
+	}
+	recipient := os.Getenv("WEEKLY_REPORT_EMAIL")
+
+	go func() {
+		ticker := time.NewTicker(15 * time.Minute)
+		defer ticker.Stop()
+
+		lastRun := ""
+		for range ticker.C {
+			now := time.Now().In(utils.DefaultLocation())
+			today := now.Format("2006-01-02")
+			if now.Weekday() != time.Sunday || now.Hour() < hour || lastRun == today {
+				continue
+			}
+
+			ctx := context.Background()
+			report, err := reports.BuildWeekly(ctx, now)
+			if err != nil {
+				slog.Warn("Failed to build weekly report", "error", err)
+				continue
+			}
+			id, saved, err := reports.Save(ctx, report)
+			if err != nil {
+				slog.Warn("Failed to save weekly report", "error", err)
+				continue
+			}
+			lastRun = today
+			if !saved {
+				continue
+			}
+			slog.Info("Saved weekly report", "week_start", report.WeekStart)
+
+			if recipient == "" {
+				continue
+			}
+			msg := notify.Message{
+				User:      "system",
+				Recipient: recipient,
+				Kind:      "weekly_report",
+				Title:     reports.Title(report),
+				Body:      reports.Markdown(report),
+			}
+			if err := notify.NewSMTPNotifierFromEnv().Send(ctx, msg); err != nil {
+				slog.Warn("Failed to email weekly report", "error", err)
+				continue
+			}
+			if err := reports.MarkEmailed(ctx, id, recipient); err != nil {
+				slog.Warn("Failed to record weekly report email", "error", err)
+			}
+		}
+	}()
+}
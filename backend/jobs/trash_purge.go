@@ -0,0 +1,46 @@
+package jobs
+
+import (
+	"context"
+	"exam-prep/database"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+)
+
+// defaultTrashRetentionDays is used when TRASH_RETENTION_DAYS is not set
+const defaultTrashRetentionDays = 30
+
+// StartTrashPurge runs a background goroutine that permanently deletes items
+// that have been in the trash longer than TRASH_RETENTION_DAYS
+func StartTrashPurge() {
+	retention := trashRetention()
+	slog.Info("Trash retention", "retention", retention.String())
+
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+
+		for {
+			if _, err := database.PurgeTrash(context.Background(), retention); err != nil {
+				slog.Warn("Failed to purge trash", "error", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// trashRetention reads the retention period from the environment
+func trashRetention() time.Duration {
+	days := defaultTrashRetentionDays
+	if value := os.Getenv("TRASH_RETENTION_DAYS"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			slog.Warn("Invalid TRASH_RETENTION_DAYS, using the default", "value", value, "days", days)
+		} else {
+			days = parsed
+		}
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
@@ -0,0 +1,30 @@
+package jobs
+
+import (
+	"context"
+	"exam-prep/webhooks"
+	"log/slog"
+	"time"
+)
+
+// webhookPollInterval is how often the delivery queue is checked for due retries
+const webhookPollInterval = 15 * time.Second
+
+// StartWebhookDispatcher runs a worker that sends due deliveries from the
+// persistent queue, as soon as they are queued and on every poll for retries
+func StartWebhookDispatcher() {
+	go func() {
+		ticker := time.NewTicker(webhookPollInterval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := webhooks.DeliverDue(context.Background()); err != nil {
+				slog.Warn("Failed to deliver webhooks", "error", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-webhooks.Queued():
+			}
+		}
+	}()
+}
@@ -1,50 +1,94 @@
 package main
 
 import (
+	"context"
 	"exam-prep/database"
+	"exam-prep/jobs"
+	"exam-prep/middleware"
 	"exam-prep/routes"
-	"log"
+	"exam-prep/telemetry"
+	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
 
+// main runs the server and exits non-zero if it fails. The work is done in
+// run so that its deferred cleanup, flushing traces included, happens first.
 func main() {
+	if err := run(); err != nil {
+		slog.Error("Server stopped", "error", err)
+		os.Exit(1)
+	}
+}
+
+// run starts the server and serves until SIGINT or SIGTERM, then lets the
+// requests in flight finish
+func run() error {
 	// Load environment variables
 	err := godotenv.Load()
+
+	// Structured logs and traces
+	telemetry.SetupLogging()
 	if err != nil {
-		log.Println("Warning: .env file not found, using system environment variables")
+		slog.Info(".env file not found, using system environment variables")
 	}
+	flushTraces := telemetry.SetupTracing()
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		flushTraces(ctx)
+	}()
 
 	// Connect to database
 	err = database.Connect()
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		return err
 	}
 	defer database.Close()
 
 	// Initialize tables
 	err = database.InitTables()
 	if err != nil {
-		log.Fatalf("Failed to initialize tables: %v", err)
+		return err
 	}
 
 	// Seed default subjects
 	err = database.SeedSubjects()
 	if err != nil {
-		log.Printf("Warning: Failed to seed subjects: %v", err)
+		slog.Warn("Failed to seed subjects", "error", err)
 	}
 
-	// Setup Gin router
-	r := gin.Default()
+	// Start background jobs
+	jobs.StartTrashPurge()
+	jobs.StartWebhookDispatcher()
+	jobs.StartReminderScheduler()
+	jobs.StartNightlyRebalance()
+	jobs.StartWeeklyReport()
+
+	// Setup Gin router. Requests get an ID and a span, and are logged as JSON
+	// in place of gin's text logger.
+	gin.DebugPrintFunc = func(format string, values ...any) {
+		slog.Debug(strings.TrimSpace(fmt.Sprintf(format, values...)))
+	}
+	r := gin.New()
+	r.Use(middleware.RequestID(), middleware.Trace(), middleware.AccessLog(), middleware.Recovery())
 
 	// Setup CORS
 	config := cors.DefaultConfig()
 	config.AllowAllOrigins = true
-	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
+	config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", "X-User", "X-Timezone", "Last-Event-ID",
+		middleware.RequestIDHeader, "traceparent"}
+	config.ExposeHeaders = []string{middleware.RequestIDHeader}
 	r.Use(cors.New(config))
 
 	// Setup routes
@@ -57,11 +101,30 @@ func main() {
 	}
 
 	// Start server
-	log.Printf("🚀 Server starting on port %s", port)
-	log.Printf("📚 Exam Preparation System API Ready!")
-	log.Printf("📅 Exam Date: %s", os.Getenv("EXAM_DATE"))
+	slog.Info("Server starting", "port", port, "exam_date", os.Getenv("EXAM_DATE"))
+
+	srv := &http.Server{Addr: ":" + port, Handler: r}
+	failed := make(chan error, 1)
+	go func() {
+		failed <- srv.ListenAndServe()
+	}()
+
+	stop, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	select {
+	case err := <-failed:
+		return fmt.Errorf("start server: %w", err)
+	case <-stop.Done():
+	}
 
-	if err := r.Run(":" + port); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	// Event streams stay open until their clients leave, so give the rest a
+	// moment to finish and then close whatever is left
+	slog.Info("Server shutting down")
+	ctx, cancelShutdown := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelShutdown()
+	if err := srv.Shutdown(ctx); err != nil {
+		slog.Warn("Closing connections still open after shutdown timeout", "error", err)
+		srv.Close()
 	}
+	return nil
 }
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"exam-prep/utils"
+	"fmt"
+	"io"
+	"log/slog"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Recovery turns a panic in a handler into a logged 500 response
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered any) {
+		utils.Fail(c, fmt.Errorf("panic: %v", recovered))
+		slog.ErrorContext(c.Request.Context(), "Handler panicked", "stack", string(debug.Stack()))
+		c.Abort()
+	})
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"exam-prep/telemetry"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader carries a request's ID in both directions
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID gives each request an ID, keeping a well-formed X-Request-ID from
+// the client or a proxy and generating one otherwise. The ID is echoed in the
+// response header and carried in the request context for logs and error
+// responses.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			var b [16]byte
+			rand.Read(b[:])
+			id = hex.EncodeToString(b[:])
+		}
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(telemetry.WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// validRequestID accepts up to 128 letters, digits and - _ . : characters
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' || r == ':') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"context"
+	"exam-prep/database"
+	"exam-prep/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Timezone resolves the timezone for the request and stores it in the
+// context for utils.Location. An explicit X-Timezone header or tz query
+// parameter wins, then the user's saved setting, then APP_TIMEZONE.
+func Timezone() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		loc, ok := utils.RequestLocation(c)
+		if !ok {
+			loc = userLocation(c.Request.Context(), utils.Actor(c))
+		}
+		c.Set(utils.LocationKey, loc)
+		c.Next()
+	}
+}
+
+// userLocation loads a user's saved timezone, falling back to the default
+func userLocation(ctx context.Context, user string) *time.Location {
+	name, err := savedTimezone(ctx, user)
+	if err == nil && name != "" {
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc
+		}
+	}
+	return utils.DefaultLocation()
+}
+
+// savedTimezone reads the timezone name in a user's settings. Tests replace it.
+var savedTimezone = func(ctx context.Context, user string) (string, error) {
+	var name string
+	err := database.DB.QueryRowContext(ctx, "SELECT timezone FROM user_settings WHERE user_name = $1", user).Scan(&name)
+	return name, err
+}
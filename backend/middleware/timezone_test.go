@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"context"
+	"database/sql"
+	"exam-prep/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	_ "time/tzdata"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTimezone(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("APP_TIMEZONE", "Asia/Tokyo")
+
+	settings := map[string]string{
+		"ny":      "America/New_York",
+		"invalid": "Mars/Olympus_Mons",
+	}
+	original := savedTimezone
+	savedTimezone = func(ctx context.Context, user string) (string, error) {
+		name, ok := settings[user]
+		if !ok {
+			return "", sql.ErrNoRows
+		}
+		return name, nil
+	}
+	t.Cleanup(func() { savedTimezone = original })
+
+	tests := []struct {
+		name   string
+		user   string
+		header string
+		query  string
+		want   string
+	}{
+		{"header wins over setting", "ny", "Europe/London", "", "Europe/London"},
+		{"query parameter wins over setting", "ny", "", "tz=Europe/London", "Europe/London"},
+		{"header wins over query parameter", "", "Europe/London", "tz=America/Chicago", "Europe/London"},
+		{"invalid header falls back to setting", "ny", "Not/AZone", "", "America/New_York"},
+		{"setting without header", "ny", "", "", "America/New_York"},
+		{"default without setting", "someone", "", "", "Asia/Tokyo"},
+		{"default for invalid setting", "invalid", "", "", "Asia/Tokyo"},
+		{"invalid header and no setting", "", "Not/AZone", "", "Asia/Tokyo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(Timezone())
+			var got string
+			r.GET("/", func(c *gin.Context) {
+				got = utils.Location(c).String()
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil)
+			if tt.user != "" {
+				req.Header.Set("X-User", tt.user)
+			}
+			if tt.header != "" {
+				req.Header.Set("X-Timezone", tt.header)
+			}
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.want {
+				t.Errorf("location = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
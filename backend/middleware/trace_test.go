@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recorder collects the spans of every test. telemetry.Tracer delegates to
+// the first global tracer provider set, so the tests share one.
+var recorder = tracetest.NewSpanRecorder()
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	os.Exit(m.Run())
+}
+
+// endedSince returns the spans ended after the first n
+func endedSince(n int) []sdktrace.ReadOnlySpan {
+	return recorder.Ended()[n:]
+}
+
+func TestTrace(t *testing.T) {
+	before := len(recorder.Ended())
+	r := gin.New()
+	r.Use(Trace())
+	var inHandler trace.SpanContext
+	r.GET("/api/topics/:id", func(c *gin.Context) {
+		inHandler = trace.SpanContextFromContext(c.Request.Context())
+		c.Status(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/topics/7", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := endedSince(before)
+	if len(spans) != 1 {
+		t.Fatalf("%d spans ended, want 1", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "GET /api/topics/:id" || span.SpanKind() != trace.SpanKindServer {
+		t.Errorf("span = %s %s, want server span GET /api/topics/:id", span.SpanKind(), span.Name())
+	}
+	if got := span.SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("trace ID = %s, want the traceparent's", got)
+	}
+	if got := span.Parent().SpanID().String(); got != "00f067aa0ba902b7" || !span.Parent().IsRemote() {
+		t.Errorf("parent = %s, want the remote traceparent span", got)
+	}
+	if inHandler.SpanID() != span.SpanContext().SpanID() {
+		t.Errorf("handler saw span %s, want the request's span %s", inHandler.SpanID(), span.SpanContext().SpanID())
+	}
+	if span.Status().Code != codes.Unset {
+		t.Errorf("status = %v, want unset for a 204", span.Status().Code)
+	}
+}
+
+func TestTraceServerError(t *testing.T) {
+	before := len(recorder.Ended())
+	r := gin.New()
+	r.Use(Trace())
+	r.GET("/api/dashboard", func(c *gin.Context) {
+		c.Status(http.StatusServiceUnavailable)
+	})
+	r.GET("/api/subjects/:id", func(c *gin.Context) {
+		c.Status(http.StatusNotFound)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/dashboard", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/subjects/9", nil))
+
+	spans := endedSince(before)
+	if len(spans) != 2 {
+		t.Fatalf("%d spans ended, want 2", len(spans))
+	}
+	if got := spans[0].Status(); got.Code != codes.Error || got.Description != "Service Unavailable" {
+		t.Errorf("5xx status = %+v, want an error", got)
+	}
+	if got := spans[1].Status(); got.Code != codes.Unset {
+		t.Errorf("4xx status = %+v, want unset", got)
+	}
+}
@@ -0,0 +1,21 @@
+package models
+
+// TimeBucket is planned vs completed study hours for one group
+type TimeBucket struct {
+	Key             string  `json:"key"`
+	Label           string  `json:"label"`
+	HoursPlanned    float64 `json:"hours_planned"`
+	HoursCompleted  float64 `json:"hours_completed"`
+	CompletionRatio float64 `json:"completion_ratio"`
+}
+
+// TimeAnalytics aggregates study plan hours over a date range
+type TimeAnalytics struct {
+	From      string       `json:"from"`
+	To        string       `json:"to"`
+	Totals    TimeBucket   `json:"totals"`
+	BySubject []TimeBucket `json:"by_subject"`
+	ByWeek    []TimeBucket `json:"by_week"`
+	ByWeekday []TimeBucket `json:"by_weekday"`
+	Heatmap   []TimeBucket `json:"heatmap"`
+}
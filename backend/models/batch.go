@@ -0,0 +1,37 @@
+package models
+
+import "encoding/json"
+
+// BatchOperation is one step of a batch. ID names the target of an update or
+// delete and is either a number or "$ref" for an earlier operation's Ref.
+// In Body, *_id members may also hold "$ref". Body is the create input for
+// creates and a JSON merge patch for updates.
+type BatchOperation struct {
+	Ref      string          `json:"ref" binding:"omitempty,max=50"`
+	Method   string          `json:"method" binding:"required,oneof=create update delete"`
+	Resource string          `json:"resource" binding:"required,oneof=subject topic note study_plan"`
+	ID       interface{}     `json:"id"`
+	Body     json.RawMessage `json:"body"`
+}
+
+// BatchInput is the input for running several operations in one transaction
+type BatchInput struct {
+	Operations []BatchOperation `json:"operations" binding:"required,min=1,max=100,dive"`
+}
+
+// BatchResult is the outcome of one operation in a batch. Data holds the
+// patched fields of an update.
+type BatchResult struct {
+	Index    int         `json:"index"`
+	Ref      string      `json:"ref,omitempty"`
+	Method   string      `json:"method"`
+	Resource string      `json:"resource"`
+	ID       int         `json:"id"`
+	Status   int         `json:"status"`
+	Data     interface{} `json:"data,omitempty"`
+}
+
+// BatchResponse lists the results of a batch in operation order
+type BatchResponse struct {
+	Results []BatchResult `json:"results"`
+}
@@ -0,0 +1,33 @@
+package models
+
+// ShiftStudyPlanInput moves every entry dated From..To (inclusive) by Days
+type ShiftStudyPlanInput struct {
+	From string `json:"from" binding:"required,date"`
+	To   string `json:"to" binding:"required,date"`
+	Days int    `json:"days" binding:"required"`
+}
+
+// CopyWeekInput copies the seven days starting at SourceWeekStart onto the
+// seven days starting at TargetWeekStart
+type CopyWeekInput struct {
+	SourceWeekStart string `json:"source_week_start" binding:"required,date"`
+	TargetWeekStart string `json:"target_week_start" binding:"required,date"`
+}
+
+// DateRangeInput selects the entries dated From..To (inclusive)
+type DateRangeInput struct {
+	From string `json:"from" binding:"required,date"`
+	To   string `json:"to" binding:"required,date"`
+}
+
+// BatchCreateStudyPlanInput creates several study plan entries at once
+type BatchCreateStudyPlanInput struct {
+	Entries []CreateStudyPlanInput `json:"entries" binding:"required,min=1,dive"`
+}
+
+// BulkResult summarizes the rows affected by a bulk operation
+type BulkResult struct {
+	Operation string `json:"operation"`
+	Affected  int    `json:"affected"`
+	IDs       []int  `json:"ids"`
+}
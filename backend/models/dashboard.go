@@ -0,0 +1,36 @@
+package models
+
+// DashboardData holds the dashboard information
+type DashboardData struct {
+	DaysUntilExam   int                   `json:"days_until_exam"`
+	ExamDate        string                `json:"exam_date"`
+	Timezone        string                `json:"timezone"`
+	TotalSubjects   int                   `json:"total_subjects"`
+	TotalTopics     int                   `json:"total_topics"`
+	CompletedTopics int                   `json:"completed_topics"`
+	WeakTopics      int                   `json:"weak_topics"`
+	OverallProgress float64               `json:"overall_progress"`
+	TodaysPlan      []TodayPlanItem       `json:"todays_plan"`
+	SubjectProgress []SubjectProgressItem `json:"subject_progress"`
+	RecentActivity  []Activity            `json:"recent_activity"`
+}
+
+// TodayPlanItem represents a study plan item for today
+type TodayPlanItem struct {
+	SubjectID      int     `json:"subject_id"`
+	SubjectName    string  `json:"subject_name"`
+	SubjectColor   string  `json:"subject_color"`
+	HoursPlanned   float64 `json:"hours_planned"`
+	HoursCompleted float64 `json:"hours_completed"`
+}
+
+// SubjectProgressItem represents progress for a single subject
+type SubjectProgressItem struct {
+	ID              int     `json:"id"`
+	Name            string  `json:"name"`
+	Color           string  `json:"color"`
+	TotalTopics     int     `json:"total_topics"`
+	CompletedTopics int     `json:"completed_topics"`
+	WeakTopics      int     `json:"weak_topics"`
+	Progress        float64 `json:"progress"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// TopicDependency records that a topic should be studied after a prerequisite
+type TopicDependency struct {
+	TopicID        int       `json:"topic_id"`
+	PrerequisiteID int       `json:"prerequisite_id"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// CreateTopicDependencyInput is the input for adding a prerequisite to a topic
+type CreateTopicDependencyInput struct {
+	PrerequisiteID int `json:"prerequisite_id" binding:"required"`
+}
+
+// OrderedTopic is a topic's place in a subject's dependency-aware study order.
+// Level is 0 for topics with no prerequisites and one more than the deepest
+// prerequisite otherwise.
+type OrderedTopic struct {
+	Position      int    `json:"position"`
+	TopicID       int    `json:"topic_id"`
+	Name          string `json:"name"`
+	IsCompleted   bool   `json:"is_completed"`
+	Level         int    `json:"level"`
+	Prerequisites []int  `json:"prerequisites"`
+}
+
+// GenerateStudyPlanInput is the input for laying a subject's incomplete
+// topics onto the study plan in dependency order. From defaults to today,
+// HoursPerTopic to 1 and HoursPerDay to 2.
+type GenerateStudyPlanInput struct {
+	SubjectID     int     `json:"subject_id" binding:"required"`
+	From          string  `json:"from" binding:"omitempty,date"`
+	HoursPerTopic float64 `json:"hours_per_topic" binding:"gte=0,lte=24"`
+	HoursPerDay   float64 `json:"hours_per_day" binding:"gte=0,lte=24"`
+}
+
+// GeneratePlanResult lists the entries a plan generation created and the
+// topics that did not fit before the exam
+type GeneratePlanResult struct {
+	BulkResult
+	Unplaced []OrderedTopic `json:"unplaced"`
+}
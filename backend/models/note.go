@@ -17,13 +17,13 @@ type Note struct {
 type CreateNoteInput struct {
 	SubjectID int    `json:"subject_id" binding:"required"`
 	TopicID   *int   `json:"topic_id"`
-	Title     string `json:"title" binding:"required"`
+	Title     string `json:"title" binding:"required,max=200"`
 	Content   string `json:"content"`
 }
 
 // UpdateNoteInput is the input for updating a note
 type UpdateNoteInput struct {
-	Title   string `json:"title"`
+	Title   string `json:"title" binding:"max=200"`
 	Content string `json:"content"`
 	TopicID *int   `json:"topic_id"`
 }
@@ -0,0 +1,38 @@
+package models
+
+// The patch types hold the fields a JSON merge patch (RFC 7396) may change.
+// Pointer fields are nullable: a null member stores NULL. The rest must stay
+// set, so a null member fails their required rule.
+
+// SubjectPatch is the patchable part of a subject
+type SubjectPatch struct {
+	Name        string  `json:"name" binding:"required,max=100"`
+	Description *string `json:"description"`
+	Color       string  `json:"color" binding:"required,color"`
+	ExamDate    *string `json:"exam_date" binding:"omitnil,date"`
+}
+
+// TopicPatch is the patchable part of a topic. is_weak follows the topic's
+// confidence reviews, so it is not patchable.
+type TopicPatch struct {
+	Name        string `json:"name" binding:"required,max=200"`
+	IsCompleted *bool  `json:"is_completed" binding:"required"`
+}
+
+// NotePatch is the patchable part of a note. TopicID must belong to the
+// note's subject.
+type NotePatch struct {
+	Title   string  `json:"title" binding:"required,max=200"`
+	Content *string `json:"content"`
+	TopicID *int    `json:"topic_id"`
+}
+
+// StudyPlanPatch is the patchable part of a study plan entry. StartTime and
+// EndTime are set or cleared together.
+type StudyPlanPatch struct {
+	StartTime      *string  `json:"start_time" binding:"omitnil,clock"`
+	EndTime        *string  `json:"end_time" binding:"omitnil,clock"`
+	HoursPlanned   *float64 `json:"hours_planned" binding:"required,gte=0,lte=24"`
+	HoursCompleted *float64 `json:"hours_completed" binding:"required,gte=0,lte=24"`
+	Notes          *string  `json:"notes"`
+}
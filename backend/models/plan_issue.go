@@ -0,0 +1,11 @@
+package models
+
+// PlanIssue is a problem found in the study plan
+type PlanIssue struct {
+	Type      string  `json:"type"`
+	Message   string  `json:"message"`
+	Date      string  `json:"date,omitempty"`
+	SubjectID int     `json:"subject_id,omitempty"`
+	PlanIDs   []int64 `json:"plan_ids,omitempty"`
+	Hours     float64 `json:"hours,omitempty"`
+}
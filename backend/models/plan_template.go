@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// PlanTemplate is a recurring study session that expands into study_plan rows
+type PlanTemplate struct {
+	ID           int       `json:"id"`
+	SubjectID    int       `json:"subject_id"`
+	SubjectName  string    `json:"subject_name,omitempty"`
+	RRule        string    `json:"rrule"`
+	HoursPlanned float64   `json:"hours_planned"`
+	Notes        string    `json:"notes"`
+	StartDate    string    `json:"start_date"`
+	EndDate      *string   `json:"end_date"`
+	Exceptions   []string  `json:"exceptions"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// CreatePlanTemplateInput is the input for creating a plan template
+type CreatePlanTemplateInput struct {
+	SubjectID    int      `json:"subject_id" binding:"required"`
+	RRule        string   `json:"rrule" binding:"required"`
+	HoursPlanned float64  `json:"hours_planned" binding:"gte=0,lte=24"`
+	Notes        string   `json:"notes"`
+	StartDate    string   `json:"start_date" binding:"required,date"`
+	EndDate      *string  `json:"end_date" binding:"omitnil,date_or_empty"`
+	Exceptions   []string `json:"exceptions" binding:"dive,date"`
+}
+
+// UpdatePlanTemplateInput is the input for editing a plan template. Scope
+// chooses between changing only the occurrence on Date ("instance") or every
+// occurrence from Date onwards ("future").
+type UpdatePlanTemplateInput struct {
+	Scope        string   `json:"scope" binding:"required"`
+	Date         string   `json:"date" binding:"required,date"`
+	RRule        string   `json:"rrule"`
+	HoursPlanned *float64 `json:"hours_planned" binding:"omitnil,gte=0,lte=24"`
+	Notes        *string  `json:"notes"`
+	EndDate      *string  `json:"end_date" binding:"omitnil,date_or_empty"`
+}
+
+// ExpandPlanTemplateInput is the input for materializing a template's
+// occurrences. Until defaults to the template end date or the exam date, and
+// can be at most a year ahead.
+type ExpandPlanTemplateInput struct {
+	Until string `json:"until" binding:"omitempty,date"`
+}
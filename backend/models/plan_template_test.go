@@ -0,0 +1,51 @@
+package models_test
+
+import (
+	"exam-prep/models"
+	"exam-prep/utils"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+func TestUpdatePlanTemplateInputEndDate(t *testing.T) {
+	utils.SetupValidator()
+
+	tests := []struct {
+		name    string
+		body    string
+		endDate *string
+		ok      bool
+	}{
+		{"absent", `{"scope":"future","date":"2026-01-05"}`, nil, true},
+		{"set", `{"scope":"future","date":"2026-01-05","end_date":"2026-02-01"}`, ptr("2026-02-01"), true},
+		{"cleared", `{"scope":"future","date":"2026-01-05","end_date":""}`, ptr(""), true},
+		{"not a date", `{"scope":"future","date":"2026-01-05","end_date":"soon"}`, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/api/plan-templates/1", strings.NewReader(tt.body))
+			var input models.UpdatePlanTemplateInput
+			err := binding.JSON.Bind(req, &input)
+			if !tt.ok {
+				if err == nil {
+					t.Fatal("bound an invalid end_date")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Bind: %v", err)
+			}
+			if (input.EndDate == nil) != (tt.endDate == nil) || (input.EndDate != nil && *input.EndDate != *tt.endDate) {
+				t.Errorf("end_date = %v, want %v", input.EndDate, tt.endDate)
+			}
+		})
+	}
+}
+
+func ptr(s string) *string {
+	return &s
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Rebalance records one run of the missed-session rescheduler
+type Rebalance struct {
+	ID            int             `json:"id"`
+	Actor         string          `json:"actor"`
+	DailyCap      float64         `json:"daily_cap"`
+	UnplacedHours float64         `json:"unplaced_hours"`
+	CreatedAt     time.Time       `json:"created_at"`
+	RevertedAt    *time.Time      `json:"reverted_at"`
+	Moves         []RebalanceMove `json:"moves"`
+}
+
+// RebalanceMove is a block of missed hours carried from one plan entry to another
+type RebalanceMove struct {
+	ID            int     `json:"id"`
+	SourcePlanID  int     `json:"source_plan_id"`
+	TargetPlanID  int     `json:"target_plan_id"`
+	SubjectID     int     `json:"subject_id"`
+	FromDate      string  `json:"from_date"`
+	ToDate        string  `json:"to_date"`
+	Hours         float64 `json:"hours"`
+	CreatedTarget bool    `json:"created_target"`
+}
+
+// RebalanceInput is the input for running the rescheduler. DailyCap
+// overrides STUDY_DAILY_HOURS_CAP; DryRun reports the moves without saving them.
+type RebalanceInput struct {
+	DailyCap *float64 `json:"daily_cap" binding:"omitnil,gt=0,lte=24"`
+	DryRun   bool     `json:"dry_run"`
+}
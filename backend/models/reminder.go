@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// ReminderRule is a user's configured reminder
+type ReminderRule struct {
+	ID         int       `json:"id"`
+	UserName   string    `json:"user_name"`
+	RuleType   string    `json:"rule_type"`
+	Channel    string    `json:"channel"`
+	Target     string    `json:"target"`
+	SendHour   int       `json:"send_hour"`
+	Thresholds []int64   `json:"thresholds"`
+	Enabled    bool      `json:"enabled"`
+	LastSentOn *string   `json:"last_sent_on"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// CreateReminderRuleInput is the input for creating a reminder rule. Target
+// is the email address or webhook URL for those channels. SendHour defaults
+// per rule type when omitted.
+type CreateReminderRuleInput struct {
+	RuleType   string  `json:"rule_type" binding:"required"`
+	Channel    string  `json:"channel" binding:"required"`
+	Target     string  `json:"target"`
+	SendHour   *int    `json:"send_hour" binding:"omitnil,min=0,max=23"`
+	Thresholds []int64 `json:"thresholds" binding:"dive,min=0"`
+}
+
+// UpdateReminderRuleInput is the input for updating a reminder rule
+type UpdateReminderRuleInput struct {
+	Channel    string  `json:"channel"`
+	Target     string  `json:"target"`
+	SendHour   *int    `json:"send_hour" binding:"omitnil,min=0,max=23"`
+	Thresholds []int64 `json:"thresholds" binding:"dive,min=0"`
+	Enabled    *bool   `json:"enabled"`
+}
+
+// Notification is a message in a user's in-app inbox
+type Notification struct {
+	ID        int       `json:"id"`
+	UserName  string    `json:"user_name"`
+	Kind      string    `json:"kind"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	IsRead    bool      `json:"is_read"`
+	CreatedAt time.Time `json:"created_at"`
+}
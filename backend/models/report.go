@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// WeeklyReport summarises one Monday–Sunday week of study
+type WeeklyReport struct {
+	WeekStart       string               `json:"week_start"`
+	WeekEnd         string               `json:"week_end"`
+	Timezone        string               `json:"timezone"`
+	GeneratedAt     time.Time            `json:"generated_at"`
+	HoursPlanned    float64              `json:"hours_planned"`
+	HoursCompleted  float64              `json:"hours_completed"`
+	CompletionRatio float64              `json:"completion_ratio"`
+	Subjects        []ReportSubjectHours `json:"subjects"`
+	TopicsCompleted []ReportTopic        `json:"topics_completed"`
+	WeakTopics      []ReportTopic        `json:"weak_topics"`
+	Exams           []ReportExam         `json:"exams"`
+}
+
+// ReportSubjectHours is one subject's planned vs completed hours for the week
+type ReportSubjectHours struct {
+	SubjectID      int     `json:"subject_id"`
+	SubjectName    string  `json:"subject_name"`
+	HoursPlanned   float64 `json:"hours_planned"`
+	HoursCompleted float64 `json:"hours_completed"`
+}
+
+// ReportTopic is a topic listed in a report
+type ReportTopic struct {
+	TopicID     int    `json:"topic_id"`
+	TopicName   string `json:"topic_name"`
+	SubjectName string `json:"subject_name"`
+}
+
+// ReportExam is a subject's exam date and the days remaining until it
+type ReportExam struct {
+	SubjectID     int    `json:"subject_id"`
+	SubjectName   string `json:"subject_name"`
+	ExamDate      string `json:"exam_date"`
+	DaysUntilExam int    `json:"days_until_exam"`
+}
+
+// StoredReport is a weekly report saved by the scheduled report job
+type StoredReport struct {
+	ID        int           `json:"id"`
+	WeekStart string        `json:"week_start"`
+	WeekEnd   string        `json:"week_end"`
+	EmailedTo *string       `json:"emailed_to"`
+	Report    *WeeklyReport `json:"report,omitempty"`
+	CreatedAt time.Time     `json:"created_at"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// UserSettings holds a user's timezone and locale preferences
+type UserSettings struct {
+	UserName  string     `json:"user_name"`
+	Timezone  string     `json:"timezone"`
+	Locale    string     `json:"locale"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+// UpdateUserSettingsInput is the input for updating user settings
+type UpdateUserSettingsInput struct {
+	Timezone string `json:"timezone"`
+	Locale   string `json:"locale"`
+}
@@ -9,23 +9,41 @@ type StudyPlan struct {
 	SubjectName    string    `json:"subject_name,omitempty"`
 	SubjectColor   string    `json:"subject_color,omitempty"`
 	StudyDate      string    `json:"study_date"`
+	StartTime      *string   `json:"start_time"`
+	EndTime        *string   `json:"end_time"`
 	HoursPlanned   float64   `json:"hours_planned"`
 	HoursCompleted float64   `json:"hours_completed"`
 	Notes          string    `json:"notes"`
 	CreatedAt      time.Time `json:"created_at"`
 }
 
-// CreateStudyPlanInput is the input for creating a study plan
+// CreateStudyPlanInput is the input for creating a study plan. StartTime and
+// EndTime are optional HH:MM wall-clock times in the user's timezone; when
+// both are given and HoursPlanned is not, the slot length is used.
 type CreateStudyPlanInput struct {
 	SubjectID    int     `json:"subject_id" binding:"required"`
-	StudyDate    string  `json:"study_date" binding:"required"`
-	HoursPlanned float64 `json:"hours_planned"`
+	StudyDate    string  `json:"study_date" binding:"required,date"`
+	StartTime    *string `json:"start_time" binding:"omitnil,clock"`
+	EndTime      *string `json:"end_time" binding:"omitnil,clock"`
+	HoursPlanned float64 `json:"hours_planned" binding:"gte=0,lte=24"`
 	Notes        string  `json:"notes"`
 }
 
-// UpdateStudyPlanInput is the input for updating a study plan
+// UpdateStudyPlanInput is the input for updating a study plan. An empty
+// StartTime or EndTime removes the time slot.
 type UpdateStudyPlanInput struct {
-	HoursPlanned   *float64 `json:"hours_planned"`
-	HoursCompleted *float64 `json:"hours_completed"`
+	StartTime      *string  `json:"start_time" binding:"omitnil,clock"`
+	EndTime        *string  `json:"end_time" binding:"omitnil,clock"`
+	HoursPlanned   *float64 `json:"hours_planned" binding:"omitnil,gte=0,lte=24"`
+	HoursCompleted *float64 `json:"hours_completed" binding:"omitnil,gte=0,lte=24"`
 	Notes          string   `json:"notes"`
 }
+
+// DayView is the ordered timeline of a single day's study plan
+type DayView struct {
+	Date        string      `json:"date"`
+	Timezone    string      `json:"timezone"`
+	TotalHours  float64     `json:"total_hours"`
+	Slots       []StudyPlan `json:"slots"`
+	Unscheduled []StudyPlan `json:"unscheduled"`
+}
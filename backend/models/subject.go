@@ -8,6 +8,7 @@ type Subject struct {
 	Name        string    `json:"name"`
 	Description string    `json:"description"`
 	Color       string    `json:"color"`
+	ExamDate    *string   `json:"exam_date"`
 	CreatedAt   time.Time `json:"created_at"`
 }
 
@@ -22,14 +23,16 @@ type SubjectWithProgress struct {
 
 // CreateSubjectInput is the input for creating a subject
 type CreateSubjectInput struct {
-	Name        string `json:"name" binding:"required"`
-	Description string `json:"description"`
-	Color       string `json:"color"`
+	Name        string  `json:"name" binding:"required,max=100"`
+	Description string  `json:"description"`
+	Color       string  `json:"color" binding:"omitempty,color"`
+	ExamDate    *string `json:"exam_date" binding:"omitempty,date"`
 }
 
 // UpdateSubjectInput is the input for updating a subject
 type UpdateSubjectInput struct {
-	Name        string `json:"name"`
+	Name        string `json:"name" binding:"max=100"`
 	Description string `json:"description"`
-	Color       string `json:"color"`
+	Color       string `json:"color" binding:"omitempty,color"`
+	ExamDate    string `json:"exam_date" binding:"omitempty,date"`
 }
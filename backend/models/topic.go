@@ -9,18 +9,55 @@ type Topic struct {
 	Name        string    `json:"name"`
 	IsCompleted bool      `json:"is_completed"`
 	IsWeak      bool      `json:"is_weak"`
+	Confidence  *int      `json:"confidence"`
 	CreatedAt   time.Time `json:"created_at"`
 }
 
 // CreateTopicInput is the input for creating a topic
 type CreateTopicInput struct {
 	SubjectID int    `json:"subject_id" binding:"required"`
-	Name      string `json:"name" binding:"required"`
+	Name      string `json:"name" binding:"required,max=200"`
 }
 
-// UpdateTopicInput is the input for updating a topic
+// UpdateTopicInput is the input for updating a topic. is_weak follows the
+// topic's confidence reviews; change it with a review or the weak toggle.
 type UpdateTopicInput struct {
-	Name        string `json:"name"`
+	Name        string `json:"name" binding:"max=200"`
 	IsCompleted *bool  `json:"is_completed"`
-	IsWeak      *bool  `json:"is_weak"`
+}
+
+// TopicReview is one self-assessment of how confident the user is in a topic
+type TopicReview struct {
+	ID         int       `json:"id"`
+	TopicID    int       `json:"topic_id"`
+	Confidence int       `json:"confidence"`
+	Comment    *string   `json:"comment"`
+	Reviewer   string    `json:"reviewer"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// CreateTopicReviewInput is the input for recording a topic self-assessment
+type CreateTopicReviewInput struct {
+	Confidence int    `json:"confidence" binding:"required,min=1,max=5"`
+	Comment    string `json:"comment"`
+}
+
+// TopicHistory is a topic's current confidence and its review history
+type TopicHistory struct {
+	Topic   Topic         `json:"topic"`
+	Reviews []TopicReview `json:"reviews"`
+}
+
+// WeakTopic is a topic ranked by its recent confidence
+type WeakTopic struct {
+	TopicID          int        `json:"topic_id"`
+	TopicName        string     `json:"topic_name"`
+	SubjectID        int        `json:"subject_id"`
+	SubjectName      string     `json:"subject_name"`
+	IsCompleted      bool       `json:"is_completed"`
+	IsWeak           bool       `json:"is_weak"`
+	Confidence       *int       `json:"confidence"`
+	RecentConfidence *float64   `json:"recent_confidence"`
+	ReviewCount      int        `json:"review_count"`
+	LastReviewedAt   *time.Time `json:"last_reviewed_at"`
 }
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// TrashItem is a soft-deleted subject, topic, note or study plan entry
+type TrashItem struct {
+	Type      string    `json:"type"`
+	ID        int       `json:"id"`
+	SubjectID *int      `json:"subject_id"`
+	Name      string    `json:"name"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
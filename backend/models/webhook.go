@@ -0,0 +1,46 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Webhook is an outgoing webhook subscription
+type Webhook struct {
+	ID         int       `json:"id"`
+	URL        string    `json:"url"`
+	Secret     string    `json:"secret,omitempty"`
+	EventTypes []string  `json:"event_types"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// WebhookDelivery is one queued or attempted webhook delivery
+type WebhookDelivery struct {
+	ID             int             `json:"id"`
+	WebhookID      int             `json:"webhook_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	LastStatusCode *int            `json:"last_status_code"`
+	LastError      string          `json:"last_error"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+// CreateWebhookInput is the input for creating a webhook. An empty event
+// type list subscribes to every event.
+type CreateWebhookInput struct {
+	URL        string   `json:"url" binding:"required"`
+	Secret     string   `json:"secret"`
+	EventTypes []string `json:"event_types"`
+}
+
+// UpdateWebhookInput is the input for updating a webhook
+type UpdateWebhookInput struct {
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Active     *bool    `json:"active"`
+}
@@ -0,0 +1,18 @@
+package notify
+
+import (
+	"context"
+	"exam-prep/database"
+)
+
+// InboxNotifier stores messages in the in-app notifications table
+type InboxNotifier struct{}
+
+// Send adds the message to the user's inbox
+func (InboxNotifier) Send(ctx context.Context, msg Message) error {
+	_, err := database.DB.ExecContext(ctx,
+		"INSERT INTO notifications (user_name, kind, title, body) VALUES ($1, $2, $3, $4)",
+		msg.User, msg.Kind, msg.Title, msg.Body,
+	)
+	return err
+}
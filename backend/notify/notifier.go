@@ -0,0 +1,45 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+)
+
+// Channels a reminder can be delivered through
+const (
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+	ChannelInbox   = "inbox"
+)
+
+// Message is a notification addressed to a user
+type Message struct {
+	User      string `json:"user"`
+	Recipient string `json:"-"`
+	Kind      string `json:"kind"`
+	Title     string `json:"title"`
+	Body      string `json:"body"`
+}
+
+// Notifier delivers messages over one channel
+type Notifier interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// ForChannel returns the notifier for a channel name
+func ForChannel(channel string) (Notifier, error) {
+	switch channel {
+	case ChannelEmail:
+		return NewSMTPNotifierFromEnv(), nil
+	case ChannelWebhook:
+		return NewWebhookNotifier(), nil
+	case ChannelInbox:
+		return InboxNotifier{}, nil
+	}
+	return nil, fmt.Errorf("unknown notification channel %q", channel)
+}
+
+// ValidChannel reports whether a channel name is supported
+func ValidChannel(channel string) bool {
+	return channel == ChannelEmail || channel == ChannelWebhook || channel == ChannelInbox
+}
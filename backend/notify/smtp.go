@@ -0,0 +1,63 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/smtp"
+	"os"
+	"strings"
+)
+
+// SMTPNotifier sends messages as plain text email
+type SMTPNotifier struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// NewSMTPNotifierFromEnv configures an SMTPNotifier from SMTP_HOST,
+// SMTP_PORT, SMTP_USER, SMTP_PASSWORD and SMTP_FROM
+func NewSMTPNotifierFromEnv() *SMTPNotifier {
+	n := &SMTPNotifier{
+		Host:     os.Getenv("SMTP_HOST"),
+		Port:     os.Getenv("SMTP_PORT"),
+		Username: os.Getenv("SMTP_USER"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+	}
+	if n.Port == "" {
+		n.Port = "587"
+	}
+	if n.From == "" {
+		n.From = n.Username
+	}
+	return n
+}
+
+// Send emails the message to msg.Recipient
+func (n *SMTPNotifier) Send(ctx context.Context, msg Message) error {
+	if n.Host == "" {
+		return errors.New("SMTP_HOST is not configured")
+	}
+	if msg.Recipient == "" {
+		return errors.New("no email address for reminder")
+	}
+
+	var auth smtp.Auth
+	if n.Username != "" {
+		auth = smtp.PlainAuth("", n.Username, n.Password, n.Host)
+	}
+
+	body := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		n.From, msg.Recipient, headerSafe(msg.Title), msg.Body)
+
+	return smtp.SendMail(n.Host+":"+n.Port, auth, n.From, []string{msg.Recipient}, []byte(body))
+}
+
+// headerSafe strips line breaks so a value cannot inject extra mail headers
+func headerSafe(value string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+}
@@ -0,0 +1,50 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WebhookNotifier posts messages as JSON to the recipient URL
+type WebhookNotifier struct {
+	Client *http.Client
+}
+
+// NewWebhookNotifier creates a WebhookNotifier with a short timeout
+func NewWebhookNotifier() *WebhookNotifier {
+	return &WebhookNotifier{Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Send posts the message to msg.Recipient
+func (n *WebhookNotifier) Send(ctx context.Context, msg Message) error {
+	if msg.Recipient == "" {
+		return errors.New("no webhook URL for reminder")
+	}
+
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, msg.Recipient, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package planner
+
+import (
+	"context"
+	"database/sql"
+	"exam-prep/database"
+	"exam-prep/models"
+	"time"
+)
+
+// Shift moves every entry dated from..to by the given number of days.
+// As with EditInstance, shifted entries are detached from their templates
+// and their original dates become exceptions, so expanding a template
+// neither puts them back nor treats the moved entries as its own.
+func Shift(ctx context.Context, from, to string, days int) (*models.BulkResult, error) {
+	return inTx(ctx, "shift", func(tx *sql.Tx) (*sql.Rows, error) {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE plan_templates t SET exceptions = ARRAY(
+				SELECT DISTINCT e FROM UNNEST(t.exceptions || ARRAY(
+					SELECT sp.study_date FROM study_plan sp
+					WHERE sp.template_id = t.id AND sp.study_date BETWEEN $1 AND $2 AND sp.deleted_at IS NULL
+				)) e ORDER BY e
+			)
+			WHERE t.id IN (
+				SELECT template_id FROM study_plan
+				WHERE study_date BETWEEN $1 AND $2 AND deleted_at IS NULL AND template_id IS NOT NULL
+			)
+		`, from, to)
+		if err != nil {
+			return nil, err
+		}
+		return tx.QueryContext(ctx, `
+			UPDATE study_plan SET study_date = study_date + $1::INTEGER, template_id = NULL
+			WHERE study_date BETWEEN $2 AND $3 AND deleted_at IS NULL
+			RETURNING id
+		`, days, from, to)
+	})
+}
+
+// CopyWeek copies the entries of the week starting at source onto the week
+// starting at target, keeping each entry's weekday. Copies start with no
+// hours completed. Each copy is checked as if it were created on its own,
+// against the entries already there and the copies before it; if any fails,
+// nothing is copied and the error is an *IssuesError.
+func CopyWeek(ctx context.Context, source, target string, dailyCap float64, defaultExam time.Time) (*models.BulkResult, error) {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT sp.subject_id, TO_CHAR(sp.study_date + ($2::DATE - $1::DATE), 'YYYY-MM-DD'),
+		       TO_CHAR(sp.start_time, 'HH24:MI'), TO_CHAR(sp.end_time, 'HH24:MI'), sp.hours_planned, sp.notes
+		FROM study_plan sp
+		JOIN subjects s ON sp.subject_id = s.id
+		WHERE sp.study_date >= $1::DATE AND sp.study_date < $1::DATE + 7
+		  AND sp.deleted_at IS NULL AND s.deleted_at IS NULL
+		ORDER BY sp.study_date, sp.id
+	`, source, target)
+	if err != nil {
+		return nil, err
+	}
+	var copies []models.CreateStudyPlanInput
+	for rows.Next() {
+		var e models.CreateStudyPlanInput
+		var startTime, endTime, notes sql.NullString
+		if err := rows.Scan(&e.SubjectID, &e.StudyDate, &startTime, &endTime, &e.HoursPlanned, &notes); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if startTime.Valid && endTime.Valid {
+			e.StartTime, e.EndTime = &startTime.String, &endTime.String
+		}
+		e.Notes = notes.String
+		copies = append(copies, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result, err := insertChecked(ctx, tx, "copy_week", copies, dailyCap, defaultExam)
+	if err != nil {
+		return nil, err
+	}
+	return result, tx.Commit()
+}
+
+// DeleteRange moves every entry dated from..to to the trash
+func DeleteRange(ctx context.Context, from, to string) (*models.BulkResult, error) {
+	return inTx(ctx, "delete_range", func(tx *sql.Tx) (*sql.Rows, error) {
+		return tx.QueryContext(ctx, `
+			UPDATE study_plan SET deleted_at = CURRENT_TIMESTAMP
+			WHERE study_date BETWEEN $1 AND $2 AND deleted_at IS NULL
+			RETURNING id
+		`, from, to)
+	})
+}
+
+// BatchCreate inserts all entries or none of them. It fails with
+// ErrSubjectNotFound if an entry's subject is missing or in the trash, and
+// with an *IssuesError if an entry fails the plan checks against the plan
+// and the entries before it.
+func BatchCreate(ctx context.Context, entries []models.CreateStudyPlanInput, dailyCap float64, defaultExam time.Time) (*models.BulkResult, error) {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	for i, e := range entries {
+		var exists bool
+		err := tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM subjects WHERE id = $1 AND deleted_at IS NULL)", e.SubjectID).Scan(&exists)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, ErrSubjectNotFound
+		}
+
+		slotHours, err := SlotHours(e.StartTime, e.EndTime)
+		if err != nil {
+			return nil, err
+		}
+		if e.HoursPlanned == 0 {
+			entries[i].HoursPlanned = 1.0
+			if slotHours > 0 {
+				entries[i].HoursPlanned = slotHours
+			}
+		}
+	}
+
+	result, err := insertChecked(ctx, tx, "batch_create", entries, dailyCap, defaultExam)
+	if err != nil {
+		return nil, err
+	}
+	return result, tx.Commit()
+}
+
+// insertChecked runs the plan checks on each entry and inserts it, so later
+// entries are checked against the earlier ones. The issues of every entry
+// are collected into one *IssuesError.
+func insertChecked(ctx context.Context, tx *sql.Tx, operation string, entries []models.CreateStudyPlanInput, dailyCap float64, defaultExam time.Time) (*models.BulkResult, error) {
+	result := &models.BulkResult{Operation: operation, IDs: []int{}}
+	var issues []models.PlanIssue
+	for _, e := range entries {
+		found, err := CheckEntryIn(ctx, tx, Entry{
+			SubjectID: e.SubjectID,
+			StudyDate: e.StudyDate,
+			StartTime: e.StartTime,
+			EndTime:   e.EndTime,
+			Hours:     e.HoursPlanned,
+		}, dailyCap, defaultExam)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, found...)
+
+		var id int
+		err = tx.QueryRowContext(ctx,
+			"INSERT INTO study_plan (subject_id, study_date, start_time, end_time, hours_planned, notes) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id",
+			e.SubjectID, e.StudyDate, e.StartTime, e.EndTime, e.HoursPlanned, e.Notes,
+		).Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+		result.IDs = append(result.IDs, id)
+	}
+	if len(issues) > 0 {
+		return nil, &IssuesError{Issues: issues}
+	}
+	result.Affected = len(result.IDs)
+	return result, nil
+}
+
+// inTx runs a statement that returns the affected IDs inside a transaction
+// and summarizes the result
+func inTx(ctx context.Context, operation string, run func(tx *sql.Tx) (*sql.Rows, error)) (*models.BulkResult, error) {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := run(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.BulkResult{Operation: operation, IDs: []int{}}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		result.IDs = append(result.IDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	result.Affected = len(result.IDs)
+
+	return result, tx.Commit()
+}
@@ -0,0 +1,20 @@
+package planner
+
+import (
+	"os"
+	"strconv"
+)
+
+// DefaultDailyCap is the most hours planned on one day unless
+// STUDY_DAILY_HOURS_CAP says otherwise
+const DefaultDailyCap = 8.0
+
+// DailyCap returns the configured maximum number of study hours per day
+func DailyCap() float64 {
+	if value := os.Getenv("STUDY_DAILY_HOURS_CAP"); value != "" {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil && parsed > 0 && parsed <= 24 {
+			return parsed
+		}
+	}
+	return DefaultDailyCap
+}
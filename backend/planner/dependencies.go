@@ -0,0 +1,240 @@
+package planner
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"exam-prep/database"
+	"exam-prep/models"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrSubjectNotFound is returned when a subject does not exist or is in the trash
+	ErrSubjectNotFound = errors.New("subject not found")
+	// ErrTopicNotFound is returned when a topic does not exist or is in the trash
+	ErrTopicNotFound = errors.New("topic not found")
+	// ErrCrossSubject is returned when linking topics of different subjects
+	ErrCrossSubject = errors.New("a prerequisite must belong to the same subject")
+)
+
+// CycleError is returned when a new prerequisite would make a topic depend on
+// itself. Path runs from the topic through its prerequisites back to itself.
+type CycleError struct {
+	Path []int64
+}
+
+func (e *CycleError) Error() string {
+	if len(e.Path) == 0 {
+		return "dependency cycle among topics"
+	}
+	ids := make([]string, len(e.Path))
+	for i, id := range e.Path {
+		ids[i] = fmt.Sprint(id)
+	}
+	return "dependency cycle: " + strings.Join(ids, " -> ")
+}
+
+// AddDependency makes prerequisiteID a prerequisite of topicID. Both topics
+// must be live and belong to the same subject, and the link must not close a
+// cycle. It returns nil if the link already existed.
+func AddDependency(ctx context.Context, topicID, prerequisiteID int) (*models.TopicDependency, error) {
+	if topicID == prerequisiteID {
+		return nil, &CycleError{Path: []int64{int64(topicID), int64(topicID)}}
+	}
+
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Serialize writers so two concurrent links cannot form a cycle together
+	if _, err := tx.ExecContext(ctx, "LOCK TABLE topic_dependencies IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+		return nil, err
+	}
+
+	var found, subjects int
+	err = tx.QueryRowContext(ctx,
+		"SELECT COUNT(*), COUNT(DISTINCT subject_id) FROM topics WHERE id IN ($1, $2) AND deleted_at IS NULL",
+		topicID, prerequisiteID,
+	).Scan(&found, &subjects)
+	if err != nil {
+		return nil, err
+	}
+	if found < 2 {
+		return nil, ErrTopicNotFound
+	}
+	if subjects != 1 {
+		return nil, ErrCrossSubject
+	}
+
+	// Walk the prerequisite's own prerequisites looking for the topic
+	var path []int64
+	err = tx.QueryRowContext(ctx, `
+		WITH RECURSIVE chain (id, path) AS (
+			SELECT $2::INTEGER, ARRAY[$2::INTEGER]
+			UNION ALL
+			SELECT d.prerequisite_id, chain.path || d.prerequisite_id
+			FROM topic_dependencies d
+			JOIN chain ON d.topic_id = chain.id
+			WHERE NOT d.prerequisite_id = ANY(chain.path)
+		)
+		SELECT path FROM chain WHERE id = $1 LIMIT 1
+	`, topicID, prerequisiteID).Scan(pq.Array(&path))
+	if err == nil {
+		return nil, &CycleError{Path: append([]int64{int64(topicID)}, path...)}
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	dependency := &models.TopicDependency{TopicID: topicID, PrerequisiteID: prerequisiteID}
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO topic_dependencies (topic_id, prerequisite_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+		RETURNING created_at
+	`, topicID, prerequisiteID).Scan(&dependency.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return dependency, tx.Commit()
+}
+
+// RemoveDependency unlinks a prerequisite from a topic
+func RemoveDependency(ctx context.Context, topicID, prerequisiteID int) (bool, error) {
+	result, err := database.DB.ExecContext(ctx,
+		"DELETE FROM topic_dependencies WHERE topic_id = $1 AND prerequisite_id = $2",
+		topicID, prerequisiteID,
+	)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected > 0, nil
+}
+
+// Prerequisites returns the live topics that topicID directly depends on
+func Prerequisites(ctx context.Context, topicID int) ([]models.Topic, error) {
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT t.id, t.subject_id, t.name, t.is_completed, t.is_weak, t.confidence, t.created_at
+		FROM topic_dependencies d
+		JOIN topics t ON t.id = d.prerequisite_id
+		WHERE d.topic_id = $1 AND t.deleted_at IS NULL
+		ORDER BY t.id
+	`, topicID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	topics := []models.Topic{}
+	for rows.Next() {
+		var t models.Topic
+		if err := rows.Scan(&t.ID, &t.SubjectID, &t.Name, &t.IsCompleted, &t.IsWeak, &t.Confidence, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		topics = append(topics, t)
+	}
+	return topics, rows.Err()
+}
+
+// IncompletePrerequisites returns the direct prerequisites of topicID that
+// are not completed yet
+func IncompletePrerequisites(ctx context.Context, topicID int) ([]models.Topic, error) {
+	prerequisites, err := Prerequisites(ctx, topicID)
+	if err != nil {
+		return nil, err
+	}
+
+	incomplete := []models.Topic{}
+	for _, t := range prerequisites {
+		if !t.IsCompleted {
+			incomplete = append(incomplete, t)
+		}
+	}
+	return incomplete, nil
+}
+
+// TopicOrder returns a subject's live topics in a study order where every
+// topic comes after its prerequisites. Ties are broken by topic ID, so the
+// order is stable and matches creation order when there are no dependencies.
+func TopicOrder(ctx context.Context, subjectID int) ([]models.OrderedTopic, error) {
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT t.id, t.name, t.is_completed,
+			   COALESCE(ARRAY_AGG(d.prerequisite_id ORDER BY d.prerequisite_id) FILTER (WHERE p.id IS NOT NULL), '{}')
+		FROM topics t
+		LEFT JOIN topic_dependencies d ON d.topic_id = t.id
+		LEFT JOIN topics p ON p.id = d.prerequisite_id AND p.deleted_at IS NULL
+		WHERE t.subject_id = $1 AND t.deleted_at IS NULL
+		GROUP BY t.id
+		ORDER BY t.id
+	`, subjectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	topics := map[int]*models.OrderedTopic{}
+	dependents := map[int][]int{}
+	pending := map[int]int{}
+	for rows.Next() {
+		var t models.OrderedTopic
+		var prerequisites []int64
+		if err := rows.Scan(&t.TopicID, &t.Name, &t.IsCompleted, pq.Array(&prerequisites)); err != nil {
+			return nil, err
+		}
+		t.Prerequisites = []int{}
+		for _, id := range prerequisites {
+			t.Prerequisites = append(t.Prerequisites, int(id))
+			dependents[int(id)] = append(dependents[int(id)], t.TopicID)
+		}
+		pending[t.TopicID] = len(t.Prerequisites)
+		topics[t.TopicID] = &t
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var ready []int
+	for id, count := range pending {
+		if count == 0 {
+			ready = append(ready, id)
+		}
+	}
+
+	order := []models.OrderedTopic{}
+	for len(ready) > 0 {
+		sort.Ints(ready)
+		id := ready[0]
+		ready = ready[1:]
+
+		t := topics[id]
+		t.Position = len(order) + 1
+		order = append(order, *t)
+
+		for _, dependent := range dependents[id] {
+			if level := t.Level + 1; level > topics[dependent].Level {
+				topics[dependent].Level = level
+			}
+			pending[dependent]--
+			if pending[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+
+	if len(order) != len(topics) {
+		return nil, &CycleError{}
+	}
+	return order, nil
+}
@@ -0,0 +1,118 @@
+package planner
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"exam-prep/database"
+	"exam-prep/models"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Generate lays a subject's incomplete topics onto the study plan in
+// dependency order, from the given day until the day before the subject's
+// exam. Each day gets at most one entry for the subject, holding as many
+// whole topics as fit in hoursPerDay without taking the day over dailyCap.
+// Days that already have an entry for the subject are skipped. Topics that
+// do not fit before the exam are returned as unplaced.
+func Generate(ctx context.Context, subjectID int, from time.Time, hoursPerTopic, hoursPerDay, dailyCap float64, defaultExam time.Time) (*models.GeneratePlanResult, error) {
+	order, err := TopicOrder(ctx, subjectID)
+	if err != nil {
+		return nil, err
+	}
+	var topics []models.OrderedTopic
+	for _, t := range order {
+		if !t.IsCompleted {
+			topics = append(topics, t)
+		}
+	}
+
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var examDate time.Time
+	err = tx.QueryRowContext(ctx,
+		"SELECT COALESCE(exam_date, $2::DATE) FROM subjects WHERE id = $1 AND deleted_at IS NULL",
+		subjectID, defaultExam.Format("2006-01-02"),
+	).Scan(&examDate)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSubjectNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fromStr := dateOnly(from).Format("2006-01-02")
+	examStr := examDate.Format("2006-01-02")
+	load, err := loadDailyHours(ctx, tx, fromStr, examStr)
+	if err != nil {
+		return nil, err
+	}
+
+	taken := map[string]bool{}
+	rows, err := tx.QueryContext(ctx, `
+		SELECT study_date FROM study_plan
+		WHERE subject_id = $1 AND study_date >= $2 AND study_date < $3 AND deleted_at IS NULL
+	`, subjectID, fromStr, examStr)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var day time.Time
+		if err := rows.Scan(&day); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		taken[day.Format("2006-01-02")] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := &models.GeneratePlanResult{
+		BulkResult: models.BulkResult{Operation: "generate", IDs: []int{}},
+		Unplaced:   []models.OrderedTopic{},
+	}
+	for day := dateOnly(from); day.Before(dateOnly(examDate)) && len(topics) > 0; day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		if taken[date] {
+			continue
+		}
+
+		available := hoursPerDay
+		if room := dailyCap - load[date]; room < available {
+			available = room
+		}
+
+		var names []string
+		hours := 0.0
+		for len(topics) > 0 && hours+hoursPerTopic <= available+epsilon {
+			names = append(names, topics[0].Name)
+			hours += hoursPerTopic
+			topics = topics[1:]
+		}
+		if len(names) == 0 {
+			continue
+		}
+
+		var id int
+		err = tx.QueryRowContext(ctx,
+			"INSERT INTO study_plan (subject_id, study_date, hours_planned, notes) VALUES ($1, $2, $3, $4) RETURNING id",
+			subjectID, date, hours, fmt.Sprintf("Topics: %s", strings.Join(names, ", ")),
+		).Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+		result.IDs = append(result.IDs, id)
+	}
+	result.Affected = len(result.IDs)
+	result.Unplaced = append(result.Unplaced, topics...)
+
+	return result, tx.Commit()
+}
@@ -0,0 +1,330 @@
+package planner
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"exam-prep/database"
+	"exam-prep/models"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ErrAlreadyReverted is returned when reverting a rebalance twice
+var ErrAlreadyReverted = errors.New("rebalance has already been reverted")
+
+// epsilon absorbs float error when comparing hour amounts
+const epsilon = 0.05
+
+// shortfall is a past plan entry with hours that were never studied or carried over
+type shortfall struct {
+	planID    int
+	subjectID int
+	date      time.Time
+	hours     float64
+	examDate  time.Time
+}
+
+// Rebalance carries the unstudied hours of past plan entries forward into
+// the days from today until the day before the subject's exam, or before
+// defaultExam for subjects without an exam date, never filling a day beyond
+// dailyCap. Hours go onto the subject's existing entry for a day when
+// there is one, otherwise a new entry is created. Every move is recorded so
+// the run can be reviewed and reverted. With dryRun nothing is saved.
+func Rebalance(ctx context.Context, actor string, dailyCap float64, today, defaultExam time.Time, dryRun bool) (*models.Rebalance, error) {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	todayStr := today.Format("2006-01-02")
+
+	shortfalls, err := loadShortfalls(ctx, tx, todayStr, defaultExam.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+
+	lastExam := defaultExam
+	for _, s := range shortfalls {
+		if s.examDate.After(lastExam) {
+			lastExam = s.examDate
+		}
+	}
+	load, err := loadDailyHours(ctx, tx, todayStr, lastExam.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+
+	rebalance := &models.Rebalance{Actor: actor, DailyCap: dailyCap}
+	err = tx.QueryRowContext(ctx,
+		"INSERT INTO plan_rebalances (actor, daily_cap) VALUES ($1, $2) RETURNING id, created_at",
+		actor, dailyCap,
+	).Scan(&rebalance.ID, &rebalance.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	firstDay := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+
+	for _, s := range shortfalls {
+		lastDay := time.Date(s.examDate.Year(), s.examDate.Month(), s.examDate.Day(), 0, 0, 0, 0, time.UTC)
+		remaining := s.hours
+		for day := firstDay; day.Before(lastDay) && remaining > epsilon; day = day.AddDate(0, 0, 1) {
+			date := day.Format("2006-01-02")
+			hours := math.Floor(math.Min(dailyCap-load[date], remaining)*10) / 10
+			if hours < 0.1 {
+				continue
+			}
+
+			move, err := placeHours(ctx, tx, rebalance.ID, s, date, hours)
+			if err != nil {
+				return nil, err
+			}
+			rebalance.Moves = append(rebalance.Moves, *move)
+			load[date] += hours
+			remaining -= hours
+		}
+		if remaining > epsilon {
+			rebalance.UnplacedHours += remaining
+		}
+	}
+	rebalance.UnplacedHours = math.Round(rebalance.UnplacedHours*10) / 10
+
+	_, err = tx.ExecContext(ctx, "UPDATE plan_rebalances SET unplaced_hours = $1 WHERE id = $2", rebalance.UnplacedHours, rebalance.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		rebalance.ID = 0
+		return rebalance, nil
+	}
+	return rebalance, tx.Commit()
+}
+
+// loadShortfalls finds past entries with hours left to carry forward, oldest
+// first, with their subject's exam date
+func loadShortfalls(ctx context.Context, tx *sql.Tx, today, defaultExam string) ([]shortfall, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT sp.id, sp.subject_id, sp.study_date,
+			   sp.hours_planned - sp.hours_completed - COALESCE(sp.carried_over, 0),
+			   COALESCE(s.exam_date, $2::DATE)
+		FROM study_plan sp
+		JOIN subjects s ON sp.subject_id = s.id
+		WHERE sp.study_date < $1 AND sp.deleted_at IS NULL AND s.deleted_at IS NULL
+		  AND sp.hours_planned - sp.hours_completed - COALESCE(sp.carried_over, 0) > 0
+		ORDER BY sp.study_date, sp.id
+		FOR UPDATE OF sp
+	`, today, defaultExam)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var shortfalls []shortfall
+	for rows.Next() {
+		var s shortfall
+		if err := rows.Scan(&s.planID, &s.subjectID, &s.date, &s.hours, &s.examDate); err != nil {
+			return nil, err
+		}
+		shortfalls = append(shortfalls, s)
+	}
+	return shortfalls, rows.Err()
+}
+
+// loadDailyHours sums the planned hours per day in [from, to)
+func loadDailyHours(ctx context.Context, tx *sql.Tx, from, to string) (map[string]float64, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT study_date, SUM(hours_planned)
+		FROM study_plan
+		WHERE study_date >= $1 AND study_date < $2 AND deleted_at IS NULL
+		GROUP BY study_date
+	`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	load := map[string]float64{}
+	for rows.Next() {
+		var day time.Time
+		var hours float64
+		if err := rows.Scan(&day, &hours); err != nil {
+			return nil, err
+		}
+		load[day.Format("2006-01-02")] = hours
+	}
+	return load, rows.Err()
+}
+
+// placeHours adds hours for the shortfall's subject on the given day and records the move
+func placeHours(ctx context.Context, tx *sql.Tx, rebalanceID int, s shortfall, date string, hours float64) (*models.RebalanceMove, error) {
+	move := &models.RebalanceMove{
+		SourcePlanID: s.planID,
+		SubjectID:    s.subjectID,
+		FromDate:     s.date.Format("2006-01-02"),
+		ToDate:       date,
+		Hours:        hours,
+	}
+
+	err := tx.QueryRowContext(ctx, `
+		UPDATE study_plan SET hours_planned = hours_planned + $1
+		WHERE id = (
+			SELECT id FROM study_plan
+			WHERE subject_id = $2 AND study_date = $3 AND deleted_at IS NULL
+			ORDER BY id LIMIT 1
+		)
+		RETURNING id
+	`, hours, s.subjectID, date).Scan(&move.TargetPlanID)
+	if errors.Is(err, sql.ErrNoRows) {
+		move.CreatedTarget = true
+		err = tx.QueryRowContext(ctx,
+			"INSERT INTO study_plan (subject_id, study_date, hours_planned, notes) VALUES ($1, $2, $3, $4) RETURNING id",
+			s.subjectID, date, hours, fmt.Sprintf("Carried over from %s", move.FromDate),
+		).Scan(&move.TargetPlanID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE study_plan SET carried_over = COALESCE(carried_over, 0) + $1 WHERE id = $2", hours, s.planID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.QueryRowContext(ctx,
+		"INSERT INTO plan_rebalance_moves (rebalance_id, source_plan_id, target_plan_id, hours, created_target) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+		rebalanceID, move.SourcePlanID, move.TargetPlanID, move.Hours, move.CreatedTarget,
+	).Scan(&move.ID)
+	if err != nil {
+		return nil, err
+	}
+	return move, nil
+}
+
+// Revert undoes a rebalance: carried hours are taken back off their target
+// entries, entries the rebalance created are trashed once empty, and the
+// source entries become eligible for carrying forward again
+func Revert(ctx context.Context, id int) error {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var revertedAt *time.Time
+	err = tx.QueryRowContext(ctx, "SELECT reverted_at FROM plan_rebalances WHERE id = $1 FOR UPDATE", id).Scan(&revertedAt)
+	if err != nil {
+		return err
+	}
+	if revertedAt != nil {
+		return ErrAlreadyReverted
+	}
+
+	moves, err := loadMoves(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range moves {
+		_, err = tx.ExecContext(ctx, "UPDATE study_plan SET hours_planned = GREATEST(hours_planned - $1, 0) WHERE id = $2", m.Hours, m.TargetPlanID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE study_plan SET carried_over = GREATEST(carried_over - $1, 0) WHERE id = $2", m.Hours, m.SourcePlanID)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Entries created by the rebalance that are now empty go to the trash,
+	// which keeps the move history intact
+	for _, m := range moves {
+		if !m.CreatedTarget {
+			continue
+		}
+		_, err = tx.ExecContext(ctx,
+			"UPDATE study_plan SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1 AND hours_planned = 0 AND hours_completed = 0 AND deleted_at IS NULL",
+			m.TargetPlanID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err = tx.ExecContext(ctx, "UPDATE plan_rebalances SET reverted_at = CURRENT_TIMESTAMP WHERE id = $1", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListRebalances returns recent rebalance runs with their moves, newest first
+func ListRebalances(ctx context.Context, limit int) ([]models.Rebalance, error) {
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT id, actor, daily_cap, unplaced_hours, created_at, reverted_at
+		FROM plan_rebalances
+		ORDER BY created_at DESC, id DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var rebalances []models.Rebalance
+	for rows.Next() {
+		var r models.Rebalance
+		if err := rows.Scan(&r.ID, &r.Actor, &r.DailyCap, &r.UnplacedHours, &r.CreatedAt, &r.RevertedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		rebalances = append(rebalances, r)
+	}
+	rows.Close()
+
+	for i := range rebalances {
+		moves, err := loadMoves(ctx, database.DB, rebalances[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		rebalances[i].Moves = moves
+	}
+	return rebalances, nil
+}
+
+// querier is implemented by *sql.DB and *sql.Tx
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// loadMoves returns the moves recorded for a rebalance. Moves whose plan
+// entries were purged from the trash are dropped by the foreign keys.
+func loadMoves(ctx context.Context, q querier, rebalanceID int) ([]models.RebalanceMove, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT m.id, m.source_plan_id, m.target_plan_id, src.subject_id, src.study_date, dst.study_date, m.hours, m.created_target
+		FROM plan_rebalance_moves m
+		JOIN study_plan src ON m.source_plan_id = src.id
+		JOIN study_plan dst ON m.target_plan_id = dst.id
+		WHERE m.rebalance_id = $1
+		ORDER BY m.id
+	`, rebalanceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var moves []models.RebalanceMove
+	for rows.Next() {
+		var m models.RebalanceMove
+		var from, to time.Time
+		err := rows.Scan(&m.ID, &m.SourcePlanID, &m.TargetPlanID, &m.SubjectID, &from, &to, &m.Hours, &m.CreatedTarget)
+		if err != nil {
+			return nil, err
+		}
+		m.FromDate = from.Format("2006-01-02")
+		m.ToDate = to.Format("2006-01-02")
+		moves = append(moves, m)
+	}
+	return moves, rows.Err()
+}
@@ -0,0 +1,145 @@
+package planner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RRule is the subset of an RFC 5545 recurrence rule used by plan templates:
+// FREQ=DAILY|WEEKLY with optional INTERVAL, BYDAY, COUNT and UNTIL
+type RRule struct {
+	Freq     string
+	Interval int
+	ByDay    []time.Weekday
+	Count    int
+	Until    *time.Time
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// ParseRRule parses a rule such as "FREQ=WEEKLY;BYDAY=MO,WE". A leading
+// "RRULE:" prefix is accepted.
+func ParseRRule(value string) (*RRule, error) {
+	rule := &RRule{Interval: 1}
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+
+	for _, part := range strings.Split(value, ";") {
+		if part == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rrule part %q", part)
+		}
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Freq = strings.ToUpper(val)
+			if rule.Freq != "DAILY" && rule.Freq != "WEEKLY" {
+				return nil, fmt.Errorf("unsupported FREQ %q, use DAILY or WEEKLY", val)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid INTERVAL %q", val)
+			}
+			rule.Interval = n
+		case "BYDAY":
+			for _, day := range strings.Split(val, ",") {
+				weekday, ok := weekdays[strings.ToUpper(strings.TrimSpace(day))]
+				if !ok {
+					return nil, fmt.Errorf("invalid BYDAY value %q", day)
+				}
+				rule.ByDay = append(rule.ByDay, weekday)
+			}
+		case "COUNT":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid COUNT %q", val)
+			}
+			rule.Count = n
+		case "UNTIL":
+			until, err := parseUntil(val)
+			if err != nil {
+				return nil, err
+			}
+			rule.Until = &until
+		default:
+			return nil, fmt.Errorf("unsupported rrule part %q", key)
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, fmt.Errorf("rrule needs a FREQ")
+	}
+	return rule, nil
+}
+
+// parseUntil accepts UNTIL as a date (20260211) or date-time (20260211T000000Z)
+func parseUntil(value string) (time.Time, error) {
+	if len(value) >= 8 {
+		if t, err := time.Parse("20060102", value[:8]); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid UNTIL %q", value)
+}
+
+// Occurrences returns the dates the rule produces from start up to and
+// including end. Dates are midnight UTC.
+func (r *RRule) Occurrences(start, end time.Time) []time.Time {
+	start = dateOnly(start)
+	end = dateOnly(end)
+	if r.Until != nil && r.Until.Before(end) {
+		end = dateOnly(*r.Until)
+	}
+
+	byDay := map[time.Weekday]bool{}
+	for _, d := range r.ByDay {
+		byDay[d] = true
+	}
+	if r.Freq == "WEEKLY" && len(byDay) == 0 {
+		byDay[start.Weekday()] = true
+	}
+
+	// Weeks are counted from the Monday of the start week for INTERVAL
+	weekStart := start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+
+	var dates []time.Time
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		daysSince := int(day.Sub(start).Hours() / 24)
+
+		switch r.Freq {
+		case "DAILY":
+			if daysSince%r.Interval != 0 || (len(byDay) > 0 && !byDay[day.Weekday()]) {
+				continue
+			}
+		case "WEEKLY":
+			week := int(day.Sub(weekStart).Hours()/24) / 7
+			if week%r.Interval != 0 || !byDay[day.Weekday()] {
+				continue
+			}
+		}
+
+		dates = append(dates, day)
+		if r.Count > 0 && len(dates) == r.Count {
+			break
+		}
+	}
+	return dates
+}
+
+// dateOnly truncates a time to midnight UTC on the same calendar day
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
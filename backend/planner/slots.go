@@ -0,0 +1,51 @@
+package planner
+
+import (
+	"fmt"
+	"time"
+)
+
+// Entry describes a study plan entry about to be saved. ID is the entry
+// being updated, or 0 for a new one.
+type Entry struct {
+	ID        int
+	SubjectID int
+	StudyDate string
+	StartTime *string
+	EndTime   *string
+	Hours     float64
+}
+
+// ParseClock parses an HH:MM (or HH:MM:SS) time of day
+func ParseClock(value string) (time.Time, error) {
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, use HH:MM", value)
+}
+
+// SlotHours checks a start/end pair and returns the slot length in hours.
+// Either both times or neither must be given; end must be after start.
+func SlotHours(start, end *string) (float64, error) {
+	if start == nil && end == nil {
+		return 0, nil
+	}
+	if start == nil || end == nil {
+		return 0, fmt.Errorf("start_time and end_time must be given together")
+	}
+
+	from, err := ParseClock(*start)
+	if err != nil {
+		return 0, err
+	}
+	to, err := ParseClock(*end)
+	if err != nil {
+		return 0, err
+	}
+	if !to.After(from) {
+		return 0, fmt.Errorf("end_time must be after start_time")
+	}
+	return to.Sub(from).Hours(), nil
+}
@@ -0,0 +1,383 @@
+package planner
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"exam-prep/database"
+	"exam-prep/models"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Template edit scopes
+const (
+	ScopeInstance = "instance"
+	ScopeFuture   = "future"
+)
+
+// ErrNotOccurrence is returned when an instance edit names a date the
+// template does not produce
+var ErrNotOccurrence = errors.New("date is not an occurrence of the template")
+
+// templateColumns is the column list scanned by scanTemplate
+const templateColumns = `t.id, t.subject_id, s.name, t.rrule, t.hours_planned, COALESCE(t.notes, ''),
+	TO_CHAR(t.start_date, 'YYYY-MM-DD'), TO_CHAR(t.end_date, 'YYYY-MM-DD'),
+	ARRAY(SELECT TO_CHAR(e, 'YYYY-MM-DD') FROM UNNEST(t.exceptions) e ORDER BY e), t.created_at`
+
+// scanner is implemented by *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTemplate scans a row selected with templateColumns
+func scanTemplate(row scanner) (*models.PlanTemplate, error) {
+	var t models.PlanTemplate
+	err := row.Scan(&t.ID, &t.SubjectID, &t.SubjectName, &t.RRule, &t.HoursPlanned, &t.Notes,
+		&t.StartDate, &t.EndDate, pq.Array(&t.Exceptions), &t.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// ListTemplates returns all plan templates
+func ListTemplates(ctx context.Context) ([]models.PlanTemplate, error) {
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT `+templateColumns+`
+		FROM plan_templates t
+		JOIN subjects s ON t.subject_id = s.id
+		WHERE s.deleted_at IS NULL
+		ORDER BY t.id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var templates []models.PlanTemplate
+	for rows.Next() {
+		t, err := scanTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, *t)
+	}
+	return templates, rows.Err()
+}
+
+// GetTemplate loads a single template
+func GetTemplate(ctx context.Context, id int) (*models.PlanTemplate, error) {
+	return scanTemplate(database.DB.QueryRowContext(ctx, `
+		SELECT `+templateColumns+`
+		FROM plan_templates t
+		JOIN subjects s ON t.subject_id = s.id
+		WHERE t.id = $1
+	`, id))
+}
+
+// lockTemplate loads a template inside a transaction and locks its row
+func lockTemplate(ctx context.Context, tx *sql.Tx, id int) (*models.PlanTemplate, error) {
+	return scanTemplate(tx.QueryRowContext(ctx, `
+		SELECT `+templateColumns+`
+		FROM plan_templates t
+		JOIN subjects s ON t.subject_id = s.id
+		WHERE t.id = $1
+		FOR UPDATE OF t
+	`, id))
+}
+
+// CreateTemplate saves a template and materializes its occurrences from
+// today until the given date
+func CreateTemplate(ctx context.Context, input models.CreatePlanTemplateInput, today, until time.Time) (int, int, error) {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	if input.Exceptions == nil {
+		input.Exceptions = []string{}
+	}
+	if input.EndDate != nil && *input.EndDate == "" {
+		input.EndDate = nil
+	}
+
+	var id int
+	err = tx.QueryRowContext(ctx,
+		"INSERT INTO plan_templates (subject_id, rrule, hours_planned, notes, start_date, end_date, exceptions) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id",
+		input.SubjectID, input.RRule, input.HoursPlanned, input.Notes, input.StartDate, input.EndDate, pq.Array(input.Exceptions),
+	).Scan(&id)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	created, err := expand(ctx, tx, id, today, until)
+	if err != nil {
+		return 0, 0, err
+	}
+	return id, created, tx.Commit()
+}
+
+// Expand materializes a template's occurrences between from and until as
+// study_plan rows and returns how many were created. Dates that already have
+// a row for the template, including trashed ones, are skipped.
+func Expand(ctx context.Context, id int, from, until time.Time) (int, error) {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	created, err := expand(ctx, tx, id, from, until)
+	if err != nil {
+		return 0, err
+	}
+	return created, tx.Commit()
+}
+
+// expand is Expand within an existing transaction
+func expand(ctx context.Context, tx *sql.Tx, id int, from, until time.Time) (int, error) {
+	t, err := lockTemplate(ctx, tx, id)
+	if err != nil {
+		return 0, err
+	}
+
+	rule, err := ParseRRule(t.RRule)
+	if err != nil {
+		return 0, err
+	}
+
+	start, _ := time.Parse("2006-01-02", t.StartDate)
+	if t.EndDate != nil {
+		if end, err := time.Parse("2006-01-02", *t.EndDate); err == nil && end.Before(until) {
+			until = end
+		}
+	}
+
+	exceptions := map[string]bool{}
+	for _, e := range t.Exceptions {
+		exceptions[e] = true
+	}
+
+	from = dateOnly(from)
+	created := 0
+	for _, day := range rule.Occurrences(start, until) {
+		date := day.Format("2006-01-02")
+		if day.Before(from) || exceptions[date] {
+			continue
+		}
+
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO study_plan (subject_id, study_date, hours_planned, notes, template_id)
+			SELECT $1, $2, $3, $4, $5
+			WHERE NOT EXISTS (SELECT 1 FROM study_plan WHERE template_id = $5 AND study_date = $2)
+		`, t.SubjectID, date, t.HoursPlanned, t.Notes, t.ID)
+		if err != nil {
+			return 0, err
+		}
+		rowsAffected, _ := result.RowsAffected()
+		created += int(rowsAffected)
+	}
+	return created, nil
+}
+
+// EditInstance changes only the occurrence on date. The date becomes an
+// exception of the template and its study_plan row is detached from it, so
+// later expansions and "future" edits leave it alone. It returns the ID of
+// the detached study_plan row.
+func EditInstance(ctx context.Context, id int, date string, hours *float64, notes *string) (int, error) {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	t, err := lockTemplate(ctx, tx, id)
+	if err != nil {
+		return 0, err
+	}
+	occurs, err := isOccurrence(t, date)
+	if err != nil {
+		return 0, err
+	}
+	if !occurs {
+		return 0, ErrNotOccurrence
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE plan_templates SET exceptions = array_append(exceptions, $1::DATE) WHERE id = $2 AND NOT ($1::DATE = ANY(exceptions))",
+		date, id,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	var planID int
+	err = tx.QueryRowContext(ctx, `
+		UPDATE study_plan
+		SET template_id = NULL, hours_planned = COALESCE($1, hours_planned), notes = COALESCE($2, notes)
+		WHERE template_id = $3 AND study_date = $4 AND deleted_at IS NULL
+		RETURNING id
+	`, hours, notes, id, date).Scan(&planID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Not materialized yet, so create the one-off entry directly
+		h, n := t.HoursPlanned, t.Notes
+		if hours != nil {
+			h = *hours
+		}
+		if notes != nil {
+			n = *notes
+		}
+		err = tx.QueryRowContext(ctx,
+			"INSERT INTO study_plan (subject_id, study_date, hours_planned, notes) VALUES ($1, $2, $3, $4) RETURNING id",
+			t.SubjectID, date, h, n,
+		).Scan(&planID)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return planID, tx.Commit()
+}
+
+// isOccurrence reports whether the template's rule produces date, between its
+// start and end dates
+func isOccurrence(t *models.PlanTemplate, date string) (bool, error) {
+	rule, err := ParseRRule(t.RRule)
+	if err != nil {
+		return false, err
+	}
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return false, nil
+	}
+	if t.EndDate != nil && date > *t.EndDate {
+		return false, nil
+	}
+	start, _ := time.Parse("2006-01-02", t.StartDate)
+	dates := rule.Occurrences(start, day)
+	return len(dates) > 0 && dates[len(dates)-1].Equal(day), nil
+}
+
+// EditFuture changes every occurrence from date onwards. When date is after
+// the template's start, the template is split: the original ends the day
+// before and a new template carries the changes. Upcoming rows that have no
+// time logged are regenerated from the edited template, from today or date,
+// whichever is later, until the given date. It returns the ID of the
+// template that now owns the future occurrences.
+func EditFuture(ctx context.Context, id int, input models.UpdatePlanTemplateInput, today, until time.Time) (int, error) {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	t, err := lockTemplate(ctx, tx, id)
+	if err != nil {
+		return 0, err
+	}
+
+	day, err := time.Parse("2006-01-02", input.Date)
+	if err != nil {
+		return 0, err
+	}
+	start, _ := time.Parse("2006-01-02", t.StartDate)
+
+	rrule, hours, notes, endDate := t.RRule, t.HoursPlanned, t.Notes, t.EndDate
+	if input.RRule != "" {
+		rrule = input.RRule
+	}
+	if input.HoursPlanned != nil {
+		hours = *input.HoursPlanned
+	}
+	if input.Notes != nil {
+		notes = *input.Notes
+	}
+	if input.EndDate != nil {
+		endDate = input.EndDate
+		if *endDate == "" {
+			endDate = nil
+		}
+	}
+
+	targetID := id
+	if !day.After(start) {
+		_, err = tx.ExecContext(ctx,
+			"UPDATE plan_templates SET rrule = $1, hours_planned = $2, notes = $3, end_date = $4 WHERE id = $5",
+			rrule, hours, notes, endDate, id,
+		)
+	} else {
+		_, err = tx.ExecContext(ctx, "UPDATE plan_templates SET end_date = $1::DATE - 1 WHERE id = $2", input.Date, id)
+		if err == nil {
+			err = tx.QueryRowContext(ctx, `
+				INSERT INTO plan_templates (subject_id, rrule, hours_planned, notes, start_date, end_date, exceptions)
+				SELECT subject_id, $1, $2, $3, $4, $5, ARRAY(SELECT e FROM UNNEST(exceptions) e WHERE e >= $4::DATE)
+				FROM plan_templates WHERE id = $6
+				RETURNING id
+			`, rrule, hours, notes, input.Date, endDate, id).Scan(&targetID)
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	from := day
+	if today.After(from) {
+		from = today
+	}
+
+	// Trash untouched generated rows so they are rebuilt from the new rule.
+	// They are detached first, or expand would take them for occurrences
+	// the user trashed and skip their dates. Rows with logged time, and
+	// trashed occurrences, move to the template that now owns them.
+	_, err = tx.ExecContext(ctx, `
+		UPDATE study_plan SET deleted_at = CURRENT_TIMESTAMP, template_id = NULL
+		WHERE template_id = $1 AND study_date >= $2 AND hours_completed = 0 AND deleted_at IS NULL
+	`, id, from.Format("2006-01-02"))
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.ExecContext(ctx,
+		"UPDATE study_plan SET template_id = $1 WHERE template_id = $2 AND study_date >= $3",
+		targetID, id, input.Date,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err = expand(ctx, tx, targetID, from, until); err != nil {
+		return 0, err
+	}
+	return targetID, tx.Commit()
+}
+
+// DeleteTemplate deletes a template and moves its upcoming rows that have no
+// time logged to the trash. Past rows stay in the plan, detached from the
+// template.
+func DeleteTemplate(ctx context.Context, id int, today time.Time) (bool, error) {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE study_plan SET deleted_at = CURRENT_TIMESTAMP WHERE template_id = $1 AND study_date >= $2 AND hours_completed = 0 AND deleted_at IS NULL",
+		id, today.Format("2006-01-02"),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM plan_templates WHERE id = $1", id)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return false, nil
+	}
+	return true, tx.Commit()
+}
@@ -0,0 +1,275 @@
+package planner
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"exam-prep/database"
+	"exam-prep/models"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Plan issue types
+const (
+	IssueDailyCap      = "daily_cap_exceeded"
+	IssueAfterExam     = "after_exam"
+	IssueNoTimePlanned = "no_time_before_exam"
+	IssueDuplicate     = "duplicate_entry"
+	IssueOverlap       = "time_overlap"
+)
+
+// IssuesError carries the plan checks that stopped entries from being saved
+type IssuesError struct {
+	Issues []models.PlanIssue
+}
+
+func (e *IssuesError) Error() string {
+	return e.Issues[0].Message
+}
+
+// CheckEntry validates a study plan entry before it is saved. It returns the
+// problems that should stop the entry from being saved.
+func CheckEntry(ctx context.Context, e Entry, dailyCap float64, defaultExam time.Time) ([]models.PlanIssue, error) {
+	return CheckEntryIn(ctx, database.DB, e, dailyCap, defaultExam)
+}
+
+// CheckEntryIn is CheckEntry run through q, so that inside a transaction it
+// sees the entries the transaction has already written
+func CheckEntryIn(ctx context.Context, q database.RowQuerier, e Entry, dailyCap float64, defaultExam time.Time) ([]models.PlanIssue, error) {
+	var issues []models.PlanIssue
+	subjectID, studyDate, hours, excludeID := e.SubjectID, e.StudyDate, e.Hours, e.ID
+
+	var dayTotal float64
+	err := q.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(hours_planned), 0)
+		FROM study_plan
+		WHERE study_date = $1 AND id <> $2 AND deleted_at IS NULL
+	`, studyDate, excludeID).Scan(&dayTotal)
+	if err != nil {
+		return nil, err
+	}
+	if dayTotal+hours > dailyCap+epsilon {
+		issues = append(issues, models.PlanIssue{
+			Type:    IssueDailyCap,
+			Message: fmt.Sprintf("%s would have %.1f hours planned, over the daily cap of %.1f", studyDate, dayTotal+hours, dailyCap),
+			Date:    studyDate,
+			Hours:   dayTotal + hours,
+		})
+	}
+
+	var examDate time.Time
+	var afterExam bool
+	err = q.QueryRowContext(ctx, `
+		SELECT COALESCE(exam_date, $2::DATE), $3::DATE > COALESCE(exam_date, $2::DATE)
+		FROM subjects
+		WHERE id = $1
+	`, subjectID, defaultExam.Format("2006-01-02"), studyDate).Scan(&examDate, &afterExam)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if afterExam {
+		issues = append(issues, models.PlanIssue{
+			Type:      IssueAfterExam,
+			Message:   fmt.Sprintf("%s is after the exam on %s", studyDate, examDate.Format("2006-01-02")),
+			Date:      studyDate,
+			SubjectID: subjectID,
+		})
+	}
+
+	var duplicates []int64
+	err = q.QueryRowContext(ctx, `
+		SELECT COALESCE(ARRAY_AGG(id ORDER BY id), '{}')
+		FROM study_plan
+		WHERE subject_id = $1 AND study_date = $2 AND id <> $3 AND deleted_at IS NULL
+	`, subjectID, studyDate, excludeID).Scan(pq.Array(&duplicates))
+	if err != nil {
+		return nil, err
+	}
+	if len(duplicates) > 0 {
+		issues = append(issues, models.PlanIssue{
+			Type:      IssueDuplicate,
+			Message:   fmt.Sprintf("This subject already has an entry on %s", studyDate),
+			Date:      studyDate,
+			SubjectID: subjectID,
+			PlanIDs:   duplicates,
+		})
+	}
+
+	if e.StartTime != nil && e.EndTime != nil {
+		var overlapping []int64
+		err = q.QueryRowContext(ctx, `
+			SELECT COALESCE(ARRAY_AGG(id ORDER BY start_time), '{}')
+			FROM study_plan
+			WHERE study_date = $1 AND id <> $2 AND deleted_at IS NULL
+			  AND start_time IS NOT NULL AND start_time < $4::TIME AND end_time > $3::TIME
+		`, studyDate, excludeID, *e.StartTime, *e.EndTime).Scan(pq.Array(&overlapping))
+		if err != nil {
+			return nil, err
+		}
+		if len(overlapping) > 0 {
+			issues = append(issues, models.PlanIssue{
+				Type:    IssueOverlap,
+				Message: fmt.Sprintf("%s-%s overlaps another session on %s", *e.StartTime, *e.EndTime, studyDate),
+				Date:    studyDate,
+				PlanIDs: overlapping,
+			})
+		}
+	}
+
+	return issues, nil
+}
+
+// FindIssues scans the whole plan for days over the cap, sessions after their
+// subject's exam, subjects with no time planned before an upcoming exam,
+// duplicate subject/date entries and overlapping time slots
+func FindIssues(ctx context.Context, dailyCap float64, today, defaultExam time.Time) ([]models.PlanIssue, error) {
+	issues := []models.PlanIssue{}
+	exam := defaultExam.Format("2006-01-02")
+
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT sp.study_date, SUM(sp.hours_planned), ARRAY_AGG(sp.id ORDER BY sp.id)
+		FROM study_plan sp
+		JOIN subjects s ON sp.subject_id = s.id
+		WHERE sp.deleted_at IS NULL AND s.deleted_at IS NULL
+		GROUP BY sp.study_date
+		HAVING SUM(sp.hours_planned) > $1
+		ORDER BY sp.study_date
+	`, dailyCap)
+	if err != nil {
+		return nil, err
+	}
+	err = scanIssues(rows, func(rows *sql.Rows) (models.PlanIssue, error) {
+		var day time.Time
+		issue := models.PlanIssue{Type: IssueDailyCap}
+		err := rows.Scan(&day, &issue.Hours, pq.Array(&issue.PlanIDs))
+		issue.Date = day.Format("2006-01-02")
+		issue.Message = fmt.Sprintf("%s has %.1f hours planned, over the daily cap of %.1f", issue.Date, issue.Hours, dailyCap)
+		return issue, err
+	}, &issues)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = database.DB.QueryContext(ctx, `
+		SELECT sp.id, sp.subject_id, s.name, sp.study_date, COALESCE(s.exam_date, $1::DATE)
+		FROM study_plan sp
+		JOIN subjects s ON sp.subject_id = s.id
+		WHERE sp.deleted_at IS NULL AND s.deleted_at IS NULL
+		  AND sp.study_date > COALESCE(s.exam_date, $1::DATE)
+		ORDER BY sp.study_date, sp.id
+	`, exam)
+	if err != nil {
+		return nil, err
+	}
+	err = scanIssues(rows, func(rows *sql.Rows) (models.PlanIssue, error) {
+		var id int64
+		var name string
+		var day, examDate time.Time
+		issue := models.PlanIssue{Type: IssueAfterExam}
+		err := rows.Scan(&id, &issue.SubjectID, &name, &day, &examDate)
+		issue.PlanIDs = []int64{id}
+		issue.Date = day.Format("2006-01-02")
+		issue.Message = fmt.Sprintf("%s is planned on %s, after its exam on %s", name, issue.Date, examDate.Format("2006-01-02"))
+		return issue, err
+	}, &issues)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = database.DB.QueryContext(ctx, `
+		SELECT s.id, s.name, COALESCE(s.exam_date, $1::DATE)
+		FROM subjects s
+		WHERE s.deleted_at IS NULL
+		  AND COALESCE(s.exam_date, $1::DATE) >= $2::DATE
+		  AND NOT EXISTS (
+			SELECT 1 FROM study_plan sp
+			WHERE sp.subject_id = s.id AND sp.deleted_at IS NULL AND sp.hours_planned > 0
+			  AND sp.study_date >= $2::DATE AND sp.study_date < COALESCE(s.exam_date, $1::DATE)
+		  )
+		ORDER BY s.id
+	`, exam, today.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	err = scanIssues(rows, func(rows *sql.Rows) (models.PlanIssue, error) {
+		var name string
+		var examDate time.Time
+		issue := models.PlanIssue{Type: IssueNoTimePlanned}
+		err := rows.Scan(&issue.SubjectID, &name, &examDate)
+		issue.Date = examDate.Format("2006-01-02")
+		issue.Message = fmt.Sprintf("%s has no study time planned before its exam on %s", name, issue.Date)
+		return issue, err
+	}, &issues)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = database.DB.QueryContext(ctx, `
+		SELECT sp.subject_id, s.name, sp.study_date, ARRAY_AGG(sp.id ORDER BY sp.id)
+		FROM study_plan sp
+		JOIN subjects s ON sp.subject_id = s.id
+		WHERE sp.deleted_at IS NULL AND s.deleted_at IS NULL
+		GROUP BY sp.subject_id, s.name, sp.study_date
+		HAVING COUNT(*) > 1
+		ORDER BY sp.study_date, sp.subject_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	err = scanIssues(rows, func(rows *sql.Rows) (models.PlanIssue, error) {
+		var name string
+		var day time.Time
+		issue := models.PlanIssue{Type: IssueDuplicate}
+		err := rows.Scan(&issue.SubjectID, &name, &day, pq.Array(&issue.PlanIDs))
+		issue.Date = day.Format("2006-01-02")
+		issue.Message = fmt.Sprintf("%s has %d entries on %s", name, len(issue.PlanIDs), issue.Date)
+		return issue, err
+	}, &issues)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = database.DB.QueryContext(ctx, `
+		SELECT a.study_date, a.id, b.id
+		FROM study_plan a
+		JOIN study_plan b ON a.study_date = b.study_date AND a.id < b.id
+		WHERE a.deleted_at IS NULL AND b.deleted_at IS NULL
+		  AND a.start_time IS NOT NULL AND b.start_time IS NOT NULL
+		  AND a.start_time < b.end_time AND b.start_time < a.end_time
+		ORDER BY a.study_date, a.start_time
+	`)
+	if err != nil {
+		return nil, err
+	}
+	err = scanIssues(rows, func(rows *sql.Rows) (models.PlanIssue, error) {
+		var day time.Time
+		var first, second int64
+		issue := models.PlanIssue{Type: IssueOverlap}
+		err := rows.Scan(&day, &first, &second)
+		issue.Date = day.Format("2006-01-02")
+		issue.PlanIDs = []int64{first, second}
+		issue.Message = fmt.Sprintf("Two sessions overlap on %s", issue.Date)
+		return issue, err
+	}, &issues)
+	if err != nil {
+		return nil, err
+	}
+
+	return issues, nil
+}
+
+// scanIssues turns each row into an issue and appends it
+func scanIssues(rows *sql.Rows, scan func(rows *sql.Rows) (models.PlanIssue, error), issues *[]models.PlanIssue) error {
+	defer rows.Close()
+	for rows.Next() {
+		issue, err := scan(rows)
+		if err != nil {
+			return err
+		}
+		*issues = append(*issues, issue)
+	}
+	return rows.Err()
+}
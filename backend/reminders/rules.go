@@ -0,0 +1,300 @@
+package reminders
+
+import (
+	"context"
+	"exam-prep/database"
+	"exam-prep/models"
+	"exam-prep/notify"
+	"exam-prep/utils"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Reminder rule types
+const (
+	RuleDailyDigest   = "daily_digest"
+	RuleMissedPlan    = "missed_plan"
+	RuleExamCountdown = "exam_countdown"
+	RuleWeakTopics    = "weak_topics"
+)
+
+// DefaultSendHours is the hour of day each rule type fires at unless configured
+var DefaultSendHours = map[string]int{
+	RuleDailyDigest:   8,
+	RuleMissedPlan:    20,
+	RuleExamCountdown: 9,
+	RuleWeakTopics:    18,
+}
+
+// DefaultThresholds are the exam countdown days that trigger a reminder
+var DefaultThresholds = []int64{14, 7, 3, 1}
+
+// ValidRuleType reports whether a rule type is supported
+func ValidRuleType(ruleType string) bool {
+	_, ok := DefaultSendHours[ruleType]
+	return ok
+}
+
+// RunDue evaluates every enabled rule whose send hour has passed today and
+// that has not fired yet today, and delivers the resulting messages. "Today"
+// and the send hour are taken in each rule owner's timezone.
+func RunDue(ctx context.Context, now time.Time) error {
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT r.id, r.user_name, r.rule_type, r.channel, COALESCE(r.target, ''), r.send_hour, r.thresholds,
+			   TO_CHAR(r.last_sent_on, 'YYYY-MM-DD'), COALESCE(us.timezone, '')
+		FROM reminder_rules r
+		LEFT JOIN user_settings us ON us.user_name = r.user_name
+		WHERE r.enabled
+		ORDER BY r.id
+	`)
+	if err != nil {
+		return err
+	}
+
+	type dueRule struct {
+		rule  models.ReminderRule
+		local time.Time
+	}
+	var due []dueRule
+	for rows.Next() {
+		var r models.ReminderRule
+		var timezone string
+		err := rows.Scan(&r.ID, &r.UserName, &r.RuleType, &r.Channel, &r.Target, &r.SendHour,
+			pq.Array(&r.Thresholds), &r.LastSentOn, &timezone)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+
+		local := now.In(userLocation(timezone))
+		if local.Hour() < r.SendHour || (r.LastSentOn != nil && *r.LastSentOn >= local.Format("2006-01-02")) {
+			continue
+		}
+		due = append(due, dueRule{rule: r, local: local})
+	}
+	rows.Close()
+
+	for _, d := range due {
+		if err := run(ctx, d.rule, d.local); err != nil {
+			slog.WarnContext(ctx, "Reminder rule failed", "rule_id", d.rule.ID, "rule_type", d.rule.RuleType, "error", err)
+		}
+
+		// Mark the rule as handled for today even on failure so a broken
+		// channel is not retried every tick
+		_, err := database.DB.ExecContext(ctx, "UPDATE reminder_rules SET last_sent_on = $1 WHERE id = $2", d.local.Format("2006-01-02"), d.rule.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// userLocation resolves a saved timezone name, falling back to the default
+func userLocation(name string) *time.Location {
+	if name != "" {
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc
+		}
+	}
+	return utils.DefaultLocation()
+}
+
+// run evaluates a single rule and sends its message if there is one
+func run(ctx context.Context, rule models.ReminderRule, now time.Time) error {
+	msg, err := Evaluate(ctx, rule, now)
+	if err != nil || msg == nil {
+		return err
+	}
+
+	notifier, err := notify.ForChannel(rule.Channel)
+	if err != nil {
+		return err
+	}
+	return notifier.Send(ctx, *msg)
+}
+
+// Evaluate builds the message a rule produces at the given time, or nil if
+// there is nothing to remind about. now should be in the rule owner's timezone.
+func Evaluate(ctx context.Context, rule models.ReminderRule, now time.Time) (*notify.Message, error) {
+	msg := &notify.Message{User: rule.UserName, Recipient: rule.Target, Kind: rule.RuleType}
+	today := now.Format("2006-01-02")
+
+	switch rule.RuleType {
+	case RuleDailyDigest:
+		lines, err := planLines(ctx, today, false)
+		if err != nil {
+			return nil, err
+		}
+		msg.Title = "Today's study plan"
+		if len(lines) == 0 {
+			msg.Body = "Nothing is planned for today."
+		} else {
+			msg.Body = strings.Join(lines, "\n")
+		}
+
+	case RuleMissedPlan:
+		lines, err := planLines(ctx, today, true)
+		if err != nil || len(lines) == 0 {
+			return nil, err
+		}
+		msg.Title = "Today's study plan is still untouched"
+		msg.Body = "You haven't logged any time for:\n" + strings.Join(lines, "\n")
+
+	case RuleExamCountdown:
+		thresholds := rule.Thresholds
+		if len(thresholds) == 0 {
+			thresholds = DefaultThresholds
+		}
+		exams, err := upcomingExams(ctx, now, thresholds)
+		if err != nil || len(exams) == 0 {
+			return nil, err
+		}
+		if len(exams) == 1 {
+			msg.Title = fmt.Sprintf("%d days until your exam", exams[0].days)
+			msg.Body = fmt.Sprintf("Your exam is on %s.", exams[0].date)
+			if exams[0].subjects != "" {
+				msg.Body = fmt.Sprintf("Your %s exam is on %s.", exams[0].subjects, exams[0].date)
+			}
+			break
+		}
+		lines := make([]string, len(exams))
+		for i, e := range exams {
+			lines[i] = fmt.Sprintf("- %s: %d days, on %s", e.subjects, e.days, e.date)
+		}
+		msg.Title = fmt.Sprintf("%d exams coming up", len(exams))
+		msg.Body = strings.Join(lines, "\n")
+
+	case RuleWeakTopics:
+		topics, err := weakTopics(ctx)
+		if err != nil || len(topics) == 0 {
+			return nil, err
+		}
+		msg.Title = fmt.Sprintf("%d weak topics need attention", len(topics))
+		msg.Body = "Consider reviewing:\n" + strings.Join(topics, "\n")
+
+	default:
+		return nil, fmt.Errorf("unknown rule type %q", rule.RuleType)
+	}
+
+	return msg, nil
+}
+
+// planLines lists the day's study plan entries, optionally only those with no
+// time logged yet
+func planLines(ctx context.Context, day string, untouchedOnly bool) ([]string, error) {
+	query := `
+		SELECT s.name, sp.hours_planned, sp.hours_completed
+		FROM study_plan sp
+		JOIN subjects s ON sp.subject_id = s.id
+		WHERE sp.study_date = $1 AND sp.deleted_at IS NULL AND s.deleted_at IS NULL`
+	if untouchedOnly {
+		query += " AND sp.hours_completed = 0"
+	}
+	query += " ORDER BY sp.id"
+
+	rows, err := database.DB.QueryContext(ctx, query, day)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lines []string
+	for rows.Next() {
+		var name string
+		var planned, completed float64
+		if err := rows.Scan(&name, &planned, &completed); err != nil {
+			return nil, err
+		}
+		lines = append(lines, fmt.Sprintf("- %s: %.1f/%.1f h", name, completed, planned))
+	}
+	return lines, rows.Err()
+}
+
+// weakTopics lists up to five incomplete weak topics
+func weakTopics(ctx context.Context) ([]string, error) {
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT s.name, t.name
+		FROM topics t
+		JOIN subjects s ON t.subject_id = s.id
+		WHERE t.is_weak AND NOT t.is_completed AND t.deleted_at IS NULL AND s.deleted_at IS NULL
+		ORDER BY t.id
+		LIMIT 5
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var topics []string
+	for rows.Next() {
+		var subject, topic string
+		if err := rows.Scan(&subject, &topic); err != nil {
+			return nil, err
+		}
+		topics = append(topics, fmt.Sprintf("- %s: %s", subject, topic))
+	}
+	return topics, rows.Err()
+}
+
+// exam is an exam date and the subjects sitting it
+type exam struct {
+	date     string
+	days     int
+	subjects string
+}
+
+// upcomingExams finds the exam dates that are a threshold number of days
+// away. Each subject sits its own exam_date, or the configured exam date if
+// it has none; with no subjects the configured date alone is counted down.
+func upcomingExams(ctx context.Context, now time.Time, thresholds []int64) ([]exam, error) {
+	defaultExam, defaultExamStr := utils.ExamDate()
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT COALESCE(exam_date, $1::DATE), STRING_AGG(name, ', ' ORDER BY name)
+		FROM subjects
+		WHERE deleted_at IS NULL
+		GROUP BY 1
+		ORDER BY 1
+	`, defaultExamStr)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var exams []exam
+	found := false
+	for rows.Next() {
+		var date time.Time
+		var subjects string
+		if err := rows.Scan(&date, &subjects); err != nil {
+			return nil, err
+		}
+		found = true
+		if days := utils.DaysBetween(now, date); containsDay(thresholds, days) {
+			exams = append(exams, exam{date: date.Format("2006-01-02"), days: days, subjects: subjects})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if !found {
+		if days := utils.DaysUntilExam(defaultExam, now); containsDay(thresholds, days) {
+			exams = append(exams, exam{date: defaultExamStr, days: days})
+		}
+	}
+	return exams, nil
+}
+
+// containsDay reports whether days is one of the thresholds
+func containsDay(thresholds []int64, days int) bool {
+	for _, t := range thresholds {
+		if int(t) == days {
+			return true
+		}
+	}
+	return false
+}
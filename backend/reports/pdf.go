@@ -0,0 +1,145 @@
+package reports
+
+import (
+	"bytes"
+	"exam-prep/models"
+	"fmt"
+	"strings"
+)
+
+// Page layout for the PDF renderer, in points on A4 paper
+const (
+	pdfPageWidth  = 595
+	pdfPageHeight = 842
+	pdfMargin     = 56
+	pdfLeading    = 16
+	pdfWrapWidth  = 88
+)
+
+// pdfText is one positioned run of text on a page
+type pdfText struct {
+	font string
+	size int
+	x, y int
+	text string
+}
+
+// PDF renders the report as a plain PDF document using the standard
+// Helvetica fonts, so no font files or third-party libraries are needed
+func PDF(report *models.WeeklyReport) []byte {
+	var pages [][]pdfText
+	var page []pdfText
+	y := pdfPageHeight - pdfMargin
+
+	add := func(font string, size, indent int, text string, gap int) {
+		if y-gap < pdfMargin {
+			pages = append(pages, page)
+			page = nil
+			y = pdfPageHeight - pdfMargin
+		} else {
+			y -= gap
+		}
+		page = append(page, pdfText{font: font, size: size, x: pdfMargin + indent, y: y, text: text})
+	}
+
+	add("F2", 16, 0, Title(report), 0)
+	for _, l := range outline(report) {
+		switch {
+		case l.heading:
+			add("F2", 13, 0, l.text, pdfLeading*2)
+		case l.bullet:
+			for i, part := range wrap(l.text, pdfWrapWidth-4) {
+				prefix := "- "
+				if i > 0 {
+					prefix = "  "
+				}
+				add("F1", 11, 8, prefix+part, pdfLeading)
+			}
+		default:
+			for _, part := range wrap(l.text, pdfWrapWidth) {
+				add("F1", 11, 0, part, pdfLeading)
+			}
+		}
+	}
+	pages = append(pages, page)
+
+	// Objects: 1 catalog, 2 page tree, 3-4 fonts, then a page and its
+	// content stream for every page
+	var objects []string
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+i*2)
+	}
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+	)
+	for i, texts := range pages {
+		var stream bytes.Buffer
+		for _, t := range texts {
+			fmt.Fprintf(&stream, "BT /%s %d Tf %d %d Td (%s) Tj ET\n", t.font, t.size, t.x, t.y, pdfEscape(t.text))
+		}
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+				pdfPageWidth, pdfPageHeight, 6+i*2),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", stream.Len(), stream.String()),
+		)
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return out.Bytes()
+}
+
+// wrap splits text into lines of at most width characters at word boundaries
+func wrap(text string, width int) []string {
+	var lines []string
+	current := ""
+	for _, word := range strings.Fields(text) {
+		if current != "" && len(current)+1+len(word) > width {
+			lines = append(lines, current)
+			current = word
+			continue
+		}
+		if current != "" {
+			current += " "
+		}
+		current += word
+	}
+	return append(lines, current)
+}
+
+// pdfEscape escapes a PDF string literal. Characters outside Latin-1 cannot
+// be shown with the standard fonts and are replaced with '?'.
+func pdfEscape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 32:
+			b.WriteByte(' ')
+		case r < 128:
+			b.WriteRune(r)
+		case r < 256:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
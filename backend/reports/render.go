@@ -0,0 +1,152 @@
+package reports
+
+import (
+	"bytes"
+	"exam-prep/models"
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+// Report output formats
+const (
+	FormatHTML     = "html"
+	FormatMarkdown = "md"
+	FormatPDF      = "pdf"
+)
+
+// ValidFormat reports whether a report format is supported
+func ValidFormat(format string) bool {
+	return format == FormatHTML || format == FormatMarkdown || format == FormatPDF
+}
+
+// Render writes the report in the given format and returns it with its
+// content type
+func Render(report *models.WeeklyReport, format string) ([]byte, string, error) {
+	switch format {
+	case FormatMarkdown:
+		return []byte(Markdown(report)), "text/markdown; charset=utf-8", nil
+	case FormatHTML:
+		var buf bytes.Buffer
+		if err := htmlTemplate.Execute(&buf, report); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "text/html; charset=utf-8", nil
+	case FormatPDF:
+		return PDF(report), "application/pdf", nil
+	}
+	return nil, "", fmt.Errorf("unknown report format %q", format)
+}
+
+// Title is the report's heading
+func Title(report *models.WeeklyReport) string {
+	return fmt.Sprintf("Weekly progress report: %s to %s", report.WeekStart, report.WeekEnd)
+}
+
+// line is one line of the report outline shared by the Markdown and PDF
+// renderers
+type line struct {
+	heading bool
+	bullet  bool
+	text    string
+}
+
+// outline lays the report out as headings, paragraphs and bullet lists
+func outline(report *models.WeeklyReport) []line {
+	lines := []line{}
+	heading := func(text string) { lines = append(lines, line{heading: true, text: text}) }
+	text := func(format string, args ...interface{}) {
+		lines = append(lines, line{text: fmt.Sprintf(format, args...)})
+	}
+	bullet := func(format string, args ...interface{}) {
+		lines = append(lines, line{bullet: true, text: fmt.Sprintf(format, args...)})
+	}
+
+	heading("Hours")
+	text("Studied %.1f of %.1f planned hours (%.0f%%).", report.HoursCompleted, report.HoursPlanned, report.CompletionRatio*100)
+	for _, s := range report.Subjects {
+		bullet("%s: %.1f / %.1f h", s.SubjectName, s.HoursCompleted, s.HoursPlanned)
+	}
+
+	heading(fmt.Sprintf("Topics completed (%d)", len(report.TopicsCompleted)))
+	if len(report.TopicsCompleted) == 0 {
+		text("No topics were completed this week.")
+	}
+	for _, t := range report.TopicsCompleted {
+		bullet("%s: %s", t.SubjectName, t.TopicName)
+	}
+
+	heading(fmt.Sprintf("Weak topics outstanding (%d)", len(report.WeakTopics)))
+	if len(report.WeakTopics) == 0 {
+		text("No weak topics left.")
+	}
+	for _, t := range report.WeakTopics {
+		bullet("%s: %s", t.SubjectName, t.TopicName)
+	}
+
+	heading("Exams")
+	for _, e := range report.Exams {
+		bullet("%s: %s (%d days)", e.SubjectName, e.ExamDate, e.DaysUntilExam)
+	}
+
+	return lines
+}
+
+// Markdown renders the report as Markdown
+func Markdown(report *models.WeeklyReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", Title(report))
+	for _, l := range outline(report) {
+		switch {
+		case l.heading:
+			fmt.Fprintf(&b, "\n## %s\n\n", l.text)
+		case l.bullet:
+			fmt.Fprintf(&b, "- %s\n", l.text)
+		default:
+			fmt.Fprintf(&b, "%s\n\n", l.text)
+		}
+	}
+	return b.String()
+}
+
+var htmlTemplate = template.Must(template.New("weekly").Funcs(template.FuncMap{
+	"title":   Title,
+	"percent": func(ratio float64) string { return fmt.Sprintf("%.0f%%", ratio*100) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{title .}}</title>
+<style>
+body { font-family: sans-serif; max-width: 720px; margin: 2rem auto; color: #222; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: 4px 8px; border-bottom: 1px solid #ddd; }
+</style>
+</head>
+<body>
+<h1>{{title .}}</h1>
+
+<h2>Hours</h2>
+<p>Studied {{printf "%.1f" .HoursCompleted}} of {{printf "%.1f" .HoursPlanned}} planned hours ({{percent .CompletionRatio}}).</p>
+<table>
+<tr><th>Subject</th><th>Completed</th><th>Planned</th></tr>
+{{range .Subjects}}<tr><td>{{.SubjectName}}</td><td>{{printf "%.1f" .HoursCompleted}}</td><td>{{printf "%.1f" .HoursPlanned}}</td></tr>
+{{end}}</table>
+
+<h2>Topics completed ({{len .TopicsCompleted}})</h2>
+{{if .TopicsCompleted}}<ul>
+{{range .TopicsCompleted}}<li>{{.SubjectName}}: {{.TopicName}}</li>
+{{end}}</ul>{{else}}<p>No topics were completed this week.</p>{{end}}
+
+<h2>Weak topics outstanding ({{len .WeakTopics}})</h2>
+{{if .WeakTopics}}<ul>
+{{range .WeakTopics}}<li>{{.SubjectName}}: {{.TopicName}}</li>
+{{end}}</ul>{{else}}<p>No weak topics left.</p>{{end}}
+
+<h2>Exams</h2>
+<ul>
+{{range .Exams}}<li>{{.SubjectName}}: {{.ExamDate}} ({{.DaysUntilExam}} days)</li>
+{{end}}</ul>
+</body>
+</html>
+`))
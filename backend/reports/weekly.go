@@ -0,0 +1,213 @@
+package reports
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"exam-prep/database"
+	"exam-prep/models"
+	"exam-prep/utils"
+	"time"
+)
+
+// WeekBounds returns the Monday and Sunday of the ISO week containing day
+func WeekBounds(day time.Time) (time.Time, time.Time) {
+	date := utils.CalendarDate(day)
+	offset := (int(date.Weekday()) + 6) % 7
+	start := date.AddDate(0, 0, -offset)
+	return start, start.AddDate(0, 0, 6)
+}
+
+// BuildWeekly gathers the report for the week containing now. Dates are
+// taken in now's location, so pass a time already converted to the reader's
+// timezone.
+func BuildWeekly(ctx context.Context, now time.Time) (*models.WeeklyReport, error) {
+	start, end := WeekBounds(now)
+	report := &models.WeeklyReport{
+		WeekStart:       start.Format("2006-01-02"),
+		WeekEnd:         end.Format("2006-01-02"),
+		Timezone:        now.Location().String(),
+		GeneratedAt:     now,
+		Subjects:        []models.ReportSubjectHours{},
+		TopicsCompleted: []models.ReportTopic{},
+		WeakTopics:      []models.ReportTopic{},
+		Exams:           []models.ReportExam{},
+	}
+
+	// Hours studied vs planned, per subject
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT s.id, s.name, COALESCE(SUM(sp.hours_planned), 0), COALESCE(SUM(sp.hours_completed), 0)
+		FROM subjects s
+		LEFT JOIN study_plan sp ON sp.subject_id = s.id AND sp.deleted_at IS NULL
+			AND sp.study_date BETWEEN $1 AND $2
+		WHERE s.deleted_at IS NULL
+		GROUP BY s.id, s.name
+		ORDER BY s.id
+	`, report.WeekStart, report.WeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var s models.ReportSubjectHours
+		if err := rows.Scan(&s.SubjectID, &s.SubjectName, &s.HoursPlanned, &s.HoursCompleted); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		report.HoursPlanned += s.HoursPlanned
+		report.HoursCompleted += s.HoursCompleted
+		report.Subjects = append(report.Subjects, s)
+	}
+	rows.Close()
+	if report.HoursPlanned > 0 {
+		report.CompletionRatio = float64(int(report.HoursCompleted/report.HoursPlanned*1000+0.5)) / 1000
+	}
+
+	// Topics marked complete during the week that are still complete
+	report.TopicsCompleted, err = queryTopics(ctx, `
+		SELECT DISTINCT t.id, t.name, s.name
+		FROM activity_log al
+		JOIN topics t ON t.id = al.entity_id
+		JOIN subjects s ON s.id = t.subject_id
+		WHERE al.entity_type = 'topic' AND al.action = 'complete'
+		  AND al.created_at >= CAST($1 AS DATE) AND al.created_at < CAST($2 AS DATE) + 1
+		  AND t.is_completed AND t.deleted_at IS NULL AND s.deleted_at IS NULL
+		ORDER BY s.name, t.name
+	`, report.WeekStart, report.WeekEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	// Weak topics still outstanding
+	report.WeakTopics, err = queryTopics(ctx, `
+		SELECT t.id, t.name, s.name
+		FROM topics t
+		JOIN subjects s ON s.id = t.subject_id
+		WHERE t.is_weak AND NOT t.is_completed AND t.deleted_at IS NULL AND s.deleted_at IS NULL
+		ORDER BY s.name, t.name
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	// Days to each subject's exam, falling back to EXAM_DATE
+	_, defaultExam := utils.ExamDate()
+	rows, err = database.DB.QueryContext(ctx, `
+		SELECT id, name, TO_CHAR(COALESCE(exam_date, CAST($1 AS DATE)), 'YYYY-MM-DD')
+		FROM subjects
+		WHERE deleted_at IS NULL
+		ORDER BY COALESCE(exam_date, CAST($1 AS DATE)), id
+	`, defaultExam)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var e models.ReportExam
+		if err := rows.Scan(&e.SubjectID, &e.SubjectName, &e.ExamDate); err != nil {
+			return nil, err
+		}
+		examDate, _ := time.Parse("2006-01-02", e.ExamDate)
+		e.DaysUntilExam = utils.DaysUntilExam(examDate, now)
+		report.Exams = append(report.Exams, e)
+	}
+
+	return report, rows.Err()
+}
+
+// queryTopics scans rows of topic id, topic name and subject name
+func queryTopics(ctx context.Context, query string, args ...interface{}) ([]models.ReportTopic, error) {
+	rows, err := database.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	topics := []models.ReportTopic{}
+	for rows.Next() {
+		var t models.ReportTopic
+		if err := rows.Scan(&t.TopicID, &t.TopicName, &t.SubjectName); err != nil {
+			return nil, err
+		}
+		topics = append(topics, t)
+	}
+	return topics, rows.Err()
+}
+
+// Save stores a report for its week. It reports false if a report for that
+// week was already saved.
+func Save(ctx context.Context, report *models.WeeklyReport) (int, bool, error) {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return 0, false, err
+	}
+
+	var id int
+	err = database.DB.QueryRowContext(ctx, `
+		INSERT INTO weekly_reports (week_start, week_end, data)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (week_start) DO NOTHING
+		RETURNING id
+	`, report.WeekStart, report.WeekEnd, data).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return id, true, nil
+}
+
+// MarkEmailed records the address a stored report was emailed to
+func MarkEmailed(ctx context.Context, id int, recipient string) error {
+	_, err := database.DB.ExecContext(ctx, "UPDATE weekly_reports SET emailed_to = $1 WHERE id = $2", recipient, id)
+	return err
+}
+
+// List returns stored reports, newest week first, without their contents
+func List(ctx context.Context, limit int) ([]models.StoredReport, error) {
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT id, TO_CHAR(week_start, 'YYYY-MM-DD'), TO_CHAR(week_end, 'YYYY-MM-DD'), emailed_to, created_at
+		FROM weekly_reports
+		ORDER BY week_start DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stored := []models.StoredReport{}
+	for rows.Next() {
+		var r models.StoredReport
+		if err := rows.Scan(&r.ID, &r.WeekStart, &r.WeekEnd, &r.EmailedTo, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		stored = append(stored, r)
+	}
+	return stored, rows.Err()
+}
+
+// Get loads a stored report with its contents. It returns nil if there is no
+// report with that id.
+func Get(ctx context.Context, id int) (*models.StoredReport, error) {
+	var r models.StoredReport
+	var data []byte
+	err := database.DB.QueryRowContext(ctx, `
+		SELECT id, TO_CHAR(week_start, 'YYYY-MM-DD'), TO_CHAR(week_end, 'YYYY-MM-DD'), data, emailed_to, created_at
+		FROM weekly_reports
+		WHERE id = $1
+	`, id).Scan(&r.ID, &r.WeekStart, &r.WeekEnd, &data, &r.EmailedTo, &r.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	r.Report = &models.WeeklyReport{}
+	if err := json.Unmarshal(data, r.Report); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
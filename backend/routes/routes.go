@@ -1,48 +1,151 @@
 package routes
 
 import (
+	"exam-prep/docs"
 	"exam-prep/handlers"
+	"exam-prep/middleware"
+	"exam-prep/store"
+	"exam-prep/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
 // SetupRoutes configures all API routes
 func SetupRoutes(r *gin.Engine) {
+	utils.SetupValidator()
 
 	// API routes
 	api := r.Group("/api")
+	api.Use(middleware.Timezone())
 	{
 		// Dashboard
 		api.GET("/dashboard", handlers.GetDashboard)
 		api.GET("/progress", handlers.GetProgress)
+		api.GET("/analytics/time", handlers.GetTimeAnalytics)
+
+		// Reports
+		api.GET("/reports", handlers.GetReports)
+		api.GET("/reports/weekly", handlers.GetWeeklyReport)
+		api.GET("/reports/:id", handlers.GetReport)
 
 		// Subjects
 		api.GET("/subjects", handlers.GetAllSubjects)
 		api.GET("/subjects/:id", handlers.GetSubject)
 		api.POST("/subjects", handlers.CreateSubject)
 		api.PUT("/subjects/:id", handlers.UpdateSubject)
+		api.PATCH("/subjects/:id", handlers.PatchSubject)
 		api.DELETE("/subjects/:id", handlers.DeleteSubject)
+		api.PUT("/subjects/:id/restore", handlers.RestoreSubject)
 
 		// Topics (nested under subjects for GET)
 		api.GET("/subjects/:id/topics", handlers.GetTopicsBySubject)
+		api.GET("/subjects/:id/topics/order", handlers.GetTopicOrder)
 		api.POST("/topics", handlers.CreateTopic)
 		api.PUT("/topics/:id", handlers.UpdateTopic)
+		api.PATCH("/topics/:id", handlers.PatchTopic)
 		api.PUT("/topics/:id/complete", handlers.ToggleTopicComplete)
 		api.PUT("/topics/:id/weak", handlers.ToggleTopicWeak)
+		api.GET("/topics/weakest", handlers.GetWeakestTopics)
+		api.GET("/topics/:id/history", handlers.GetTopicHistory)
+		api.POST("/topics/:id/reviews", handlers.ReviewTopic)
+		api.GET("/topics/:id/dependencies", handlers.GetTopicDependencies)
+		api.POST("/topics/:id/dependencies", handlers.AddTopicDependency)
+		api.DELETE("/topics/:id/dependencies/:prerequisiteId", handlers.RemoveTopicDependency)
 		api.DELETE("/topics/:id", handlers.DeleteTopic)
+		api.PUT("/topics/:id/restore", handlers.RestoreTopic)
 
 		// Notes
 		api.GET("/notes", handlers.GetAllNotes)
 		api.GET("/subjects/:id/notes", handlers.GetNotesBySubject)
 		api.POST("/notes", handlers.CreateNote)
 		api.PUT("/notes/:id", handlers.UpdateNote)
+		api.PATCH("/notes/:id", handlers.PatchNote)
 		api.DELETE("/notes/:id", handlers.DeleteNote)
+		api.PUT("/notes/:id/restore", handlers.RestoreNote)
 
 		// Study Plan
 		api.GET("/study-plan", handlers.GetAllStudyPlans)
 		api.GET("/study-plan/today", handlers.GetTodayStudyPlan)
+		api.GET("/study-plan/issues", handlers.GetStudyPlanIssues)
+		api.GET("/study-plan/day", handlers.GetStudyPlanDay)
 		api.POST("/study-plan", handlers.CreateStudyPlan)
 		api.PUT("/study-plan/:id", handlers.UpdateStudyPlan)
+		api.PATCH("/study-plan/:id", handlers.PatchStudyPlan)
 		api.DELETE("/study-plan/:id", handlers.DeleteStudyPlan)
+		api.PUT("/study-plan/:id/restore", handlers.RestoreStudyPlan)
+		api.POST("/study-plan/generate", handlers.GenerateStudyPlan)
+		api.POST("/study-plan/rebalance", handlers.RebalanceStudyPlan)
+		api.GET("/study-plan/rebalances", handlers.GetRebalances)
+		api.POST("/study-plan/rebalances/:id/revert", handlers.RevertRebalance)
+		api.POST("/study-plan/bulk", handlers.BatchCreateStudyPlans)
+		api.POST("/study-plan/bulk/shift", handlers.ShiftStudyPlans)
+		api.POST("/study-plan/bulk/copy-week", handlers.CopyStudyPlanWeek)
+		api.POST("/study-plan/bulk/delete", handlers.DeleteStudyPlanRange)
+
+		// Recurring plan templates
+		api.GET("/plan-templates", handlers.GetAllPlanTemplates)
+		api.GET("/plan-templates/:id", handlers.GetPlanTemplate)
+		api.POST("/plan-templates", handlers.CreatePlanTemplate)
+		api.PUT("/plan-templates/:id", handlers.UpdatePlanTemplate)
+		api.DELETE("/plan-templates/:id", handlers.DeletePlanTemplate)
+		api.POST("/plan-templates/:id/expand", handlers.ExpandPlanTemplate)
+
+		// Several writes in one transaction
+		api.POST("/batch", handlers.RunBatch)
+
+		// GraphQL
+		api.GET("/graphql", handlers.GraphQL)
+		api.POST("/graphql", handlers.GraphQL)
+		api.GET("/graphql/schema", handlers.GetGraphQLSchema)
+
+		// Trash
+		api.GET("/trash", handlers.GetTrash)
+
+		// Activity
+		api.GET("/activity", handlers.GetActivity)
+
+		// Real-time events (Server-Sent Events)
+		api.GET("/events", handlers.StreamEvents)
+
+		// Webhooks
+		api.GET("/webhooks", handlers.GetAllWebhooks)
+		api.POST("/webhooks", handlers.CreateWebhook)
+		api.PUT("/webhooks/:id", handlers.UpdateWebhook)
+		api.DELETE("/webhooks/:id", handlers.DeleteWebhook)
+		api.GET("/webhooks/:id/deliveries", handlers.GetWebhookDeliveries)
+		api.POST("/webhooks/:id/test", handlers.TestWebhook)
+
+		// User settings
+		api.GET("/settings", handlers.GetSettings)
+		api.PUT("/settings", handlers.UpdateSettings)
+
+		// Reminders and notifications
+		api.GET("/reminders", handlers.GetReminders)
+		api.POST("/reminders", handlers.CreateReminder)
+		api.PUT("/reminders/:id", handlers.UpdateReminder)
+		api.DELETE("/reminders/:id", handlers.DeleteReminder)
+		api.GET("/notifications", handlers.GetNotifications)
+		api.PUT("/notifications/:id/read", handlers.MarkNotificationRead)
+
+		// API documentation
+		api.GET("/docs", docs.ServePage)
+		api.GET("/docs/openapi.json", docs.ServeSpec)
+	}
+
+	// Version 2: writes respond with the resource, lists are paged. Routes
+	// under /api stay as they are for existing clients.
+	v2 := r.Group("/api/v2")
+	v2.Use(middleware.Timezone())
+	for _, route := range []struct{ path, resource string }{
+		{"/subjects", store.Subject},
+		{"/topics", store.Topic},
+		{"/notes", store.Note},
+		{"/study-plan", store.StudyPlan},
+	} {
+		v2.GET(route.path, handlers.ListV2(route.resource))
+		v2.GET(route.path+"/:id", handlers.GetV2(route.resource))
+		v2.POST(route.path, handlers.CreateV2(route.resource))
+		v2.PATCH(route.path+"/:id", handlers.UpdateV2(route.resource))
+		v2.DELETE(route.path+"/:id", handlers.DeleteV2(route.resource))
 	}
 }
@@ -0,0 +1,211 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"exam-prep/database"
+	"exam-prep/models"
+	"exam-prep/utils"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+// Batch methods
+const (
+	MethodCreate = "create"
+	MethodUpdate = "update"
+	MethodDelete = "delete"
+)
+
+// Change is a write made by a batch, for the activity log. Before is the row
+// as it was inside the transaction, nil for creates.
+type Change struct {
+	Resource string
+	ID       int
+	Method   string
+	Before   json.RawMessage
+}
+
+// created remembers what an operation's Ref stands for
+type created struct {
+	resource string
+	id       int
+}
+
+// RunBatch runs operations in order inside tx. An operation can refer to
+// the ID of an earlier one as "$ref", in its id or in *_id members of its
+// body. The first failure stops the batch with an error that names the
+// operation; the caller should then roll back.
+func RunBatch(ctx context.Context, tx *sql.Tx, ops []models.BatchOperation, dailyCap float64, defaultExam time.Time) ([]models.BatchResult, []Change, error) {
+	refs := map[string]created{}
+	results := make([]models.BatchResult, 0, len(ops))
+	var changes []Change
+
+	for i, op := range ops {
+		if _, taken := refs[op.Ref]; taken && op.Ref != "" {
+			return nil, nil, operationError(i, op, utils.Invalid(utils.FieldError{Field: "ref", Rule: "unique", Message: "is used by an earlier operation"}))
+		}
+
+		result, change, err := runOperation(ctx, tx, op, refs, dailyCap, defaultExam)
+		if err != nil {
+			return nil, nil, operationError(i, op, err)
+		}
+		result.Index = i
+		results = append(results, *result)
+		changes = append(changes, *change)
+
+		if op.Ref != "" {
+			refs[op.Ref] = created{resource: op.Resource, id: result.ID}
+		}
+	}
+	return results, changes, nil
+}
+
+// runOperation runs a single batch operation
+func runOperation(ctx context.Context, tx *sql.Tx, op models.BatchOperation, refs map[string]created, dailyCap float64, defaultExam time.Time) (*models.BatchResult, *Change, error) {
+	result := &models.BatchResult{Ref: op.Ref, Method: op.Method, Resource: op.Resource, Status: http.StatusOK}
+	change := &Change{Resource: op.Resource, Method: op.Method}
+
+	body, err := resolveBody(op.Body, refs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if op.Method == MethodCreate {
+		result.Status = http.StatusCreated
+		result.ID, err = Create(ctx, tx, op.Resource, body, dailyCap, defaultExam)
+		change.ID = result.ID
+		return result, change, err
+	}
+
+	id, err := resolveID(op.ID, op.Resource, refs)
+	if err != nil {
+		return nil, nil, err
+	}
+	result.ID, change.ID = id, id
+	if change.Before, err = database.SnapshotIn(ctx, tx, Tables[op.Resource], id); err != nil {
+		return nil, nil, err
+	}
+
+	if op.Method == MethodDelete {
+		return result, change, Delete(ctx, tx, op.Resource, id)
+	}
+
+	if len(body) == 0 || body[0] != '{' {
+		return nil, nil, utils.InvalidRequest("body must be a JSON merge patch object")
+	}
+	result.Data, _, err = Patch(ctx, tx, op.Resource, id, body, dailyCap, defaultExam)
+	return result, change, err
+}
+
+// Create decodes and validates a create body and inserts the resource
+func Create(ctx context.Context, tx *sql.Tx, resource string, body []byte, dailyCap float64, defaultExam time.Time) (int, error) {
+	switch resource {
+	case Subject:
+		var input models.CreateSubjectInput
+		if err := Decode(body, &input); err != nil {
+			return 0, err
+		}
+		return CreateSubject(ctx, tx, input)
+	case Topic:
+		var input models.CreateTopicInput
+		if err := Decode(body, &input); err != nil {
+			return 0, err
+		}
+		return CreateTopic(ctx, tx, input)
+	case Note:
+		var input models.CreateNoteInput
+		if err := Decode(body, &input); err != nil {
+			return 0, err
+		}
+		return CreateNote(ctx, tx, input)
+	case StudyPlan:
+		var input models.CreateStudyPlanInput
+		if err := Decode(body, &input); err != nil {
+			return 0, err
+		}
+		return CreateStudyPlan(ctx, tx, input, dailyCap, defaultExam)
+	}
+	return 0, utils.InvalidRequest("unknown resource " + resource)
+}
+
+// Decode reads a create body the way ShouldBindJSON would
+func Decode(body []byte, input interface{}) error {
+	if len(body) == 0 {
+		return utils.InvalidRequest("body is required")
+	}
+	if err := json.NewDecoder(bytes.NewReader(body)).Decode(input); err != nil {
+		return err
+	}
+	return binding.Validator.ValidateStruct(input)
+}
+
+// resolveBody replaces "$ref" values of *_id members with the IDs they refer to
+func resolveBody(body json.RawMessage, refs map[string]created) ([]byte, error) {
+	var members map[string]interface{}
+	if len(body) == 0 || json.Unmarshal(body, &members) != nil || members == nil {
+		return body, nil
+	}
+
+	resolved := false
+	for key, value := range members {
+		if !strings.HasSuffix(key, "_id") {
+			continue
+		}
+		if _, ok := value.(string); !ok {
+			continue
+		}
+		id, err := resolveID(value, strings.TrimSuffix(key, "_id"), refs)
+		if err != nil {
+			appErr := utils.AsAppError(err)
+			for i := range appErr.Fields {
+				appErr.Fields[i].Field = key
+			}
+			return nil, appErr
+		}
+		members[key] = id
+		resolved = true
+	}
+	if !resolved {
+		return body, nil
+	}
+	return json.Marshal(members)
+}
+
+// resolveID reads an operation's target, a number or a "$ref" to an earlier
+// operation on the same kind of resource
+func resolveID(value interface{}, resource string, refs map[string]created) (int, error) {
+	switch v := value.(type) {
+	case float64:
+		if v == float64(int(v)) && v > 0 {
+			return int(v), nil
+		}
+	case string:
+		if ref, ok := strings.CutPrefix(v, "$"); ok {
+			target, found := refs[ref]
+			if !found {
+				return 0, utils.Invalid(utils.FieldError{Field: "id", Rule: "ref", Message: fmt.Sprintf("$%s is not the ref of an earlier operation", ref)})
+			}
+			if target.resource != resource {
+				return 0, utils.Invalid(utils.FieldError{Field: "id", Rule: "ref", Message: fmt.Sprintf("$%s is a %s, not a %s", ref, target.resource, resource)})
+			}
+			return target.id, nil
+		}
+	}
+	return 0, utils.Invalid(utils.FieldError{Field: "id", Rule: "required", Message: `must be a positive ID or "$ref"`})
+}
+
+// operationError prefixes a failure with the operation it came from. The
+// status, code and field details of the underlying error are kept.
+func operationError(index int, op models.BatchOperation, err error) error {
+	appErr := *utils.AsAppError(err)
+	appErr.Message = fmt.Sprintf("Operation %d (%s %s) failed: %s", index, op.Method, op.Resource, appErr.Message)
+	appErr.Data = map[string]interface{}{"index": index, "ref": op.Ref, "data": appErr.Data}
+	return &appErr
+}
@@ -0,0 +1,169 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"exam-prep/models"
+	"exam-prep/planner"
+	"exam-prep/utils"
+	"time"
+)
+
+// The Patch functions apply a JSON merge patch (RFC 7396) to a resource: an
+// absent member is left alone, a null member clears the field and an empty
+// string is stored as given. The row is locked while the patch is applied so
+// concurrent patches do not undo each other. They return the patched fields.
+
+// PatchSubject applies a merge patch to a subject
+func PatchSubject(ctx context.Context, tx *sql.Tx, id int, patch []byte) (*models.SubjectPatch, error) {
+	var current models.SubjectPatch
+	err := tx.QueryRowContext(ctx, `
+		SELECT name, description, color, TO_CHAR(exam_date, 'YYYY-MM-DD')
+		FROM subjects WHERE id = $1 AND deleted_at IS NULL FOR UPDATE
+	`, id).Scan(&current.Name, &current.Description, &current.Color, &current.ExamDate)
+	if err != nil {
+		return nil, loadError(Subject, err)
+	}
+	if err := utils.ApplyMergePatch(&current, patch); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE subjects SET name = $1, description = $2, color = $3, exam_date = $4::DATE WHERE id = $5",
+		current.Name, current.Description, current.Color, current.ExamDate, id,
+	)
+	return &current, err
+}
+
+// PatchTopic applies a merge patch to a topic. It also reports whether the
+// patch completed the topic, so callers can warn about prerequisites.
+func PatchTopic(ctx context.Context, tx *sql.Tx, id int, patch []byte) (*models.TopicPatch, bool, error) {
+	var current models.TopicPatch
+	err := tx.QueryRowContext(ctx,
+		"SELECT name, is_completed FROM topics WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", id,
+	).Scan(&current.Name, &current.IsCompleted)
+	if err != nil {
+		return nil, false, loadError(Topic, err)
+	}
+	wasCompleted := *current.IsCompleted
+	if err := utils.ApplyMergePatch(&current, patch); err != nil {
+		return nil, false, err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE topics SET name = $1, is_completed = $2 WHERE id = $3",
+		current.Name, *current.IsCompleted, id,
+	)
+	return &current, *current.IsCompleted && !wasCompleted, err
+}
+
+// PatchNote applies a merge patch to a note. A new topic must belong to the
+// note's subject.
+func PatchNote(ctx context.Context, tx *sql.Tx, id int, patch []byte) (*models.NotePatch, error) {
+	var current models.NotePatch
+	var subjectID int
+	err := tx.QueryRowContext(ctx,
+		"SELECT subject_id, title, content, topic_id FROM notes WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", id,
+	).Scan(&subjectID, &current.Title, &current.Content, &current.TopicID)
+	if err != nil {
+		return nil, loadError(Note, err)
+	}
+	if err := utils.ApplyMergePatch(&current, patch); err != nil {
+		return nil, err
+	}
+	if err := CheckNoteTopic(ctx, tx, subjectID, current.TopicID); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE notes SET title = $1, content = $2, topic_id = $3, updated_at = CURRENT_TIMESTAMP WHERE id = $4",
+		current.Title, current.Content, current.TopicID, id,
+	)
+	return &current, err
+}
+
+// PatchStudyPlan applies a merge patch to a study plan entry. Changes to the
+// hours or time slot go through the plan checks.
+func PatchStudyPlan(ctx context.Context, tx *sql.Tx, id int, patch []byte, dailyCap float64, defaultExam time.Time) (*models.StudyPlanPatch, error) {
+	var current models.StudyPlanPatch
+	entry := planner.Entry{ID: id}
+	var studyDate time.Time
+	err := tx.QueryRowContext(ctx, `
+		SELECT subject_id, study_date, TO_CHAR(start_time, 'HH24:MI'), TO_CHAR(end_time, 'HH24:MI'),
+			   hours_planned, hours_completed, notes
+		FROM study_plan WHERE id = $1 AND deleted_at IS NULL FOR UPDATE
+	`, id).Scan(&entry.SubjectID, &studyDate, &current.StartTime, &current.EndTime,
+		&current.HoursPlanned, &current.HoursCompleted, &current.Notes)
+	if err != nil {
+		return nil, loadError(StudyPlan, err)
+	}
+	original := current
+	if err := utils.ApplyMergePatch(&current, patch); err != nil {
+		return nil, err
+	}
+	current.StartTime = emptyToNil(current.StartTime)
+	current.EndTime = emptyToNil(current.EndTime)
+
+	if *current.HoursPlanned != *original.HoursPlanned ||
+		!sameClock(current.StartTime, original.StartTime) || !sameClock(current.EndTime, original.EndTime) {
+		if _, err := planner.SlotHours(current.StartTime, current.EndTime); err != nil {
+			return nil, utils.InvalidRequest(err.Error())
+		}
+		entry.StudyDate = studyDate.Format("2006-01-02")
+		entry.StartTime, entry.EndTime, entry.Hours = current.StartTime, current.EndTime, *current.HoursPlanned
+		if err := checkEntry(ctx, tx, entry, dailyCap, defaultExam); err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE study_plan
+		SET start_time = $1::TIME, end_time = $2::TIME, hours_planned = $3, hours_completed = $4, notes = $5
+		WHERE id = $6
+	`, current.StartTime, current.EndTime, *current.HoursPlanned, *current.HoursCompleted, current.Notes, id)
+	return &current, err
+}
+
+// Patch applies a merge patch to any resource, returning the patched fields
+// and whether a topic was completed by it
+func Patch(ctx context.Context, tx *sql.Tx, resource string, id int, patch []byte, dailyCap float64, defaultExam time.Time) (interface{}, bool, error) {
+	switch resource {
+	case Subject:
+		patched, err := PatchSubject(ctx, tx, id, patch)
+		return patched, false, err
+	case Topic:
+		return PatchTopic(ctx, tx, id, patch)
+	case Note:
+		patched, err := PatchNote(ctx, tx, id, patch)
+		return patched, false, err
+	case StudyPlan:
+		patched, err := PatchStudyPlan(ctx, tx, id, patch, dailyCap, defaultExam)
+		return patched, false, err
+	}
+	return nil, false, utils.InvalidRequest("unknown resource " + resource)
+}
+
+// loadError turns a missing row into the resource's NOT_FOUND error
+func loadError(resource string, err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return NotFound(resource)
+	}
+	return err
+}
+
+// emptyToNil treats an empty time as no time
+func emptyToNil(value *string) *string {
+	if value == nil || *value == "" {
+		return nil
+	}
+	return value
+}
+
+// sameClock reports whether two optional HH:MM times are equal
+func sameClock(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
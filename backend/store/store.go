@@ -0,0 +1,189 @@
+// Package store holds the writes to subjects, topics, notes and study plan
+// entries that run inside the caller's transaction. The PATCH handlers, the
+// batch endpoint, GraphQL and /api/v2 share them so all apply the same checks.
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"exam-prep/database"
+	"exam-prep/models"
+	"exam-prep/planner"
+	"exam-prep/utils"
+	"net/http"
+	"time"
+)
+
+// Resource names, matching the activity log's entity types
+const (
+	Subject   = "subject"
+	Topic     = "topic"
+	Note      = "note"
+	StudyPlan = "study_plan"
+)
+
+// Tables maps each resource to its table
+var Tables = map[string]string{
+	Subject:   "subjects",
+	Topic:     "topics",
+	Note:      "notes",
+	StudyPlan: "study_plan",
+}
+
+// NotFound is the NOT_FOUND error for a resource
+func NotFound(resource string) error {
+	return utils.NotFound(map[string]string{
+		Subject:   "Subject not found",
+		Topic:     "Topic not found",
+		Note:      "Note not found",
+		StudyPlan: "Study plan not found",
+	}[resource])
+}
+
+// CreateSubject inserts a subject, defaulting the color
+func CreateSubject(ctx context.Context, tx *sql.Tx, input models.CreateSubjectInput) (int, error) {
+	if input.Color == "" {
+		input.Color = "#3498db"
+	}
+	var id int
+	err := tx.QueryRowContext(ctx,
+		"INSERT INTO subjects (name, description, color, exam_date) VALUES ($1, $2, $3, $4) RETURNING id",
+		input.Name, input.Description, input.Color, input.ExamDate,
+	).Scan(&id)
+	return id, err
+}
+
+// CreateTopic inserts a topic
+func CreateTopic(ctx context.Context, tx *sql.Tx, input models.CreateTopicInput) (int, error) {
+	if err := CheckSubject(ctx, tx, input.SubjectID); err != nil {
+		return 0, err
+	}
+	var id int
+	err := tx.QueryRowContext(ctx,
+		"INSERT INTO topics (subject_id, name) VALUES ($1, $2) RETURNING id",
+		input.SubjectID, input.Name,
+	).Scan(&id)
+	return id, err
+}
+
+// CreateNote inserts a note after checking its subject and topic
+func CreateNote(ctx context.Context, tx *sql.Tx, input models.CreateNoteInput) (int, error) {
+	if err := CheckSubject(ctx, tx, input.SubjectID); err != nil {
+		return 0, err
+	}
+	if err := CheckNoteTopic(ctx, tx, input.SubjectID, input.TopicID); err != nil {
+		return 0, err
+	}
+	var id int
+	err := tx.QueryRowContext(ctx,
+		"INSERT INTO notes (subject_id, topic_id, title, content) VALUES ($1, $2, $3, $4) RETURNING id",
+		input.SubjectID, input.TopicID, input.Title, input.Content,
+	).Scan(&id)
+	return id, err
+}
+
+// CreateStudyPlan inserts a study plan entry after the plan checks. Hours
+// default to the slot length, or one hour without a slot.
+func CreateStudyPlan(ctx context.Context, tx *sql.Tx, input models.CreateStudyPlanInput, dailyCap float64, defaultExam time.Time) (int, error) {
+	if err := CheckSubject(ctx, tx, input.SubjectID); err != nil {
+		return 0, err
+	}
+	slotHours, err := planner.SlotHours(input.StartTime, input.EndTime)
+	if err != nil {
+		return 0, utils.InvalidRequest(err.Error())
+	}
+	if input.HoursPlanned == 0 {
+		input.HoursPlanned = 1.0
+		if slotHours > 0 {
+			input.HoursPlanned = slotHours
+		}
+	}
+
+	entry := planner.Entry{
+		SubjectID: input.SubjectID,
+		StudyDate: input.StudyDate,
+		StartTime: input.StartTime,
+		EndTime:   input.EndTime,
+		Hours:     input.HoursPlanned,
+	}
+	if err := checkEntry(ctx, tx, entry, dailyCap, defaultExam); err != nil {
+		return 0, err
+	}
+
+	var id int
+	err = tx.QueryRowContext(ctx,
+		"INSERT INTO study_plan (subject_id, study_date, start_time, end_time, hours_planned, notes) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id",
+		input.SubjectID, input.StudyDate, input.StartTime, input.EndTime, input.HoursPlanned, input.Notes,
+	).Scan(&id)
+	return id, err
+}
+
+// Delete moves a resource to the trash. A subject takes its topics, notes
+// and plan entries with it.
+func Delete(ctx context.Context, tx *sql.Tx, resource string, id int) error {
+	if resource == Subject {
+		found, err := database.SoftDeleteSubjectIn(ctx, tx, id)
+		if err == nil && !found {
+			err = NotFound(resource)
+		}
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, "UPDATE "+Tables[resource]+" SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NULL", id)
+	if err != nil {
+		return err
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return NotFound(resource)
+	}
+	return nil
+}
+
+// CheckSubject makes sure a subject exists and is not in the trash, so
+// nothing new is filed under a trashed subject
+func CheckSubject(ctx context.Context, q database.RowQuerier, id int) error {
+	var exists bool
+	err := q.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM subjects WHERE id = $1 AND deleted_at IS NULL)", id).Scan(&exists)
+	if err == nil && !exists {
+		err = NotFound(Subject)
+	}
+	return err
+}
+
+// CheckNoteTopic makes sure a note's topic exists and belongs to the note's subject
+func CheckNoteTopic(ctx context.Context, q database.RowQuerier, subjectID int, topicID *int) error {
+	if topicID == nil {
+		return nil
+	}
+	var topicSubject int
+	err := q.QueryRowContext(ctx, "SELECT subject_id FROM topics WHERE id = $1 AND deleted_at IS NULL", *topicID).Scan(&topicSubject)
+	if errors.Is(err, sql.ErrNoRows) {
+		return utils.Invalid(utils.FieldError{Field: "topic_id", Rule: "exists", Message: "does not exist"})
+	}
+	if err != nil {
+		return err
+	}
+	if topicSubject != subjectID {
+		return utils.Invalid(utils.FieldError{Field: "topic_id", Rule: "subject", Message: "belongs to a different subject"})
+	}
+	return nil
+}
+
+// checkEntry runs the plan checks in the transaction and turns any issues
+// into an error carrying them
+func checkEntry(ctx context.Context, tx *sql.Tx, entry planner.Entry, dailyCap float64, defaultExam time.Time) error {
+	issues, err := planner.CheckEntryIn(ctx, tx, entry, dailyCap, defaultExam)
+	if err != nil {
+		return err
+	}
+	if len(issues) > 0 {
+		return &utils.AppError{
+			Status:  http.StatusUnprocessableEntity,
+			Code:    utils.CodeValidationFailed,
+			Message: issues[0].Message,
+			Data:    issues,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+// Package telemetry holds the server's structured logging and tracing.
+//
+// Logs are JSON lines from log/slog. Records logged with a context carry the
+// request ID and the current span's trace and span IDs, so a request's log
+// lines, error response and trace can be matched up.
+//
+// Spans are made with the OpenTelemetry SDK and exported over OTLP/HTTP to
+// the collector at OTEL_EXPORTER_OTLP_ENDPOINT, e.g. a local collector on
+// http://localhost:4318.
+package telemetry
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type contextKey int
+
+const requestIDKey contextKey = iota
+
+// SetupLogging makes slog's default logger write JSON to stdout at LOG_LEVEL
+// (debug, info, warn or error; default info). The standard log package then
+// writes through it too, at info level.
+func SetupLogging() {
+	level := slog.LevelInfo
+	if value := os.Getenv("LOG_LEVEL"); value != "" {
+		if err := level.UnmarshalText([]byte(strings.ToUpper(value))); err != nil {
+			defer slog.Warn("Invalid LOG_LEVEL, using info", "value", value)
+		}
+	}
+	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level})
+	slog.SetDefault(slog.New(contextHandler{handler}))
+}
+
+// WithRequestID returns a context carrying the request's ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestID returns the request ID in ctx, or ""
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// contextHandler adds the request ID and trace context to records
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		r.AddAttrs(slog.String("trace_id", span.TraceID().String()), slog.String("span_id", span.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,185 @@
+package telemetry
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WrapConnector traces the statements run through a database/sql connector.
+// Each statement is a client span, a child of its transaction's span or else
+// of the span in its context. Transactions begun with a span in their
+// context get a span of their own, which ends with them. Statements
+// with neither are not traced, so background work does not flood the
+// collector with one-span traces.
+func WrapConnector(c driver.Connector) driver.Connector {
+	return &connector{c}
+}
+
+type connector struct {
+	driver.Connector
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	inner, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: inner}, nil
+}
+
+// conn traces a driver connection. database/sql uses a connection from one
+// goroutine at a time, so the open transaction needs no lock.
+type conn struct {
+	driver.Conn
+	txSpan trace.Span
+	txCtx  context.Context
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	span := c.startStatement(ctx, query)
+	rows, err := queryer.QueryContext(ctx, query, args)
+	endSpan(span, err)
+	return rows, err
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	span := c.startStatement(ctx, query)
+	result, err := execer.ExecContext(ctx, query, args)
+	endSpan(span, err)
+	return result, err
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	var span trace.Span
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		ctx, span = Tracer.Start(ctx, "transaction", trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attribute.String("db.system", "postgresql")))
+	}
+
+	var inner driver.Tx
+	var err error
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		inner, err = beginner.BeginTx(ctx, opts)
+	} else {
+		inner, err = c.Conn.Begin()
+	}
+	if err != nil {
+		endSpan(span, err)
+		return nil, err
+	}
+	if span != nil {
+		c.txSpan, c.txCtx = span, ctx
+	}
+	return &tx{Tx: inner, conn: c}, nil
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *conn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *conn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c *conn) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+// startStatement starts a statement's span, if it has a parent: the open
+// transaction, or else the span in ctx
+func (c *conn) startStatement(ctx context.Context, query string) trace.Span {
+	parent := ctx
+	if c.txSpan != nil {
+		parent = c.txCtx
+	}
+	if !trace.SpanContextFromContext(parent).IsValid() {
+		return nil
+	}
+	query = strings.Join(strings.Fields(query), " ")
+	operation, _, _ := strings.Cut(query, " ")
+	operation = strings.ToUpper(operation)
+	_, span := Tracer.Start(parent, operation, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation.name", operation),
+		attribute.String("db.query.text", query),
+	))
+	return span
+}
+
+// tx ends the transaction's span when it commits or rolls back
+type tx struct {
+	driver.Tx
+	conn *conn
+}
+
+func (t *tx) Commit() error {
+	err := t.Tx.Commit()
+	t.end(err)
+	return err
+}
+
+func (t *tx) Rollback() error {
+	err := t.Tx.Rollback()
+	if err == nil {
+		t.end(errRolledBack)
+	} else {
+		t.end(err)
+	}
+	return err
+}
+
+// errRolledBack marks a transaction's span as failed when it is rolled back
+var errRolledBack = errors.New("rolled back")
+
+func (t *tx) end(err error) {
+	span := t.conn.txSpan
+	t.conn.txSpan, t.conn.txCtx = nil, nil
+	endSpan(span, err)
+}
+
+// endSpan records err on a span that may be nil and ends it
+func endSpan(span trace.Span, err error) {
+	if span == nil {
+		return
+	}
+	if err != nil && !errors.Is(err, driver.ErrSkip) {
+		setError(span, err)
+	}
+	span.End()
+}
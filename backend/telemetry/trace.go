@@ -0,0 +1,112 @@
+package telemetry
+
+import (
+	"context"
+	"log/slog"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracer starts the server's spans. It belongs to the global tracer
+// provider, so it can be taken before SetupTracing installs the SDK.
+var Tracer = otel.Tracer("exam-prep/telemetry")
+
+// SetupTracing installs the OpenTelemetry SDK as the global tracer provider
+// and W3C trace context as the propagator. Spans are exported over OTLP/HTTP
+// when OTEL_EXPORTER_OTLP_ENDPOINT or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT is
+// set, under the service name OTEL_SERVICE_NAME; without a collector,
+// finished spans are logged at debug level instead. The returned function
+// sends the spans still queued and stops the provider.
+func SetupTracing() func(context.Context) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		slog.Warn("Tracing failed", "error", err)
+	}))
+
+	ctx := context.Background()
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", "exam-prep-api")),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		slog.Warn("Incomplete tracing resource", "error", err)
+	}
+	options := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != "" {
+		exporter, err := otlptracehttp.New(ctx)
+		if err != nil {
+			slog.Warn("Failed to set up trace export", "error", err)
+		} else {
+			options = append(options, sdktrace.WithBatcher(exporter))
+			slog.Info("Exporting traces", "service", serviceName(res))
+		}
+	} else if slog.Default().Enabled(ctx, slog.LevelDebug) {
+		options = append(options, sdktrace.WithSyncer(logExporter{}))
+	}
+
+	provider := sdktrace.NewTracerProvider(options...)
+	otel.SetTracerProvider(provider)
+	return func(ctx context.Context) {
+		if err := provider.Shutdown(ctx); err != nil {
+			slog.Warn("Failed to flush traces", "error", err)
+		}
+	}
+}
+
+// serviceName returns the service.name attribute of a resource
+func serviceName(res *resource.Resource) string {
+	value, _ := res.Set().Value("service.name")
+	return value.Emit()
+}
+
+// SetError marks the span in ctx as failed with err
+func SetError(ctx context.Context, err error) {
+	setError(trace.SpanFromContext(ctx), err)
+}
+
+func setError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// logExporter logs finished spans at debug level, for running without a
+// collector
+type logExporter struct{}
+
+func (logExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	for _, s := range spans {
+		attrs := make([]slog.Attr, 0, len(s.Attributes()))
+		for _, kv := range s.Attributes() {
+			attrs = append(attrs, slog.Any(string(kv.Key), kv.Value.AsInterface()))
+		}
+		record := []slog.Attr{
+			slog.String("span", s.Name()),
+			slog.String("trace_id", s.SpanContext().TraceID().String()),
+			slog.String("span_id", s.SpanContext().SpanID().String()),
+			slog.Float64("duration_ms", float64(s.EndTime().Sub(s.StartTime()).Microseconds())/1000),
+			slog.Any("attributes", slog.GroupValue(attrs...)),
+		}
+		if s.Parent().IsValid() {
+			record = append(record, slog.String("parent_span_id", s.Parent().SpanID().String()))
+		}
+		if s.Status().Code == codes.Error {
+			record = append(record, slog.String("error", s.Status().Description))
+		}
+		slog.LogAttrs(ctx, slog.LevelDebug, "Span ended", record...)
+	}
+	return nil
+}
+
+func (logExporter) Shutdown(context.Context) error {
+	return nil
+}
@@ -0,0 +1,284 @@
+package utils
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"exam-prep/telemetry"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/lib/pq"
+)
+
+// Error codes sent in Response.Code. Clients should branch on these rather
+// than on the message, which is for people.
+const (
+	CodeBadRequest       = "BAD_REQUEST"
+	CodeValidationFailed = "VALIDATION_FAILED"
+	CodeNotFound         = "NOT_FOUND"
+	CodeConflict         = "CONFLICT"
+	CodeFKViolation      = "FK_VIOLATION"
+	CodeTooLarge         = "PAYLOAD_TOO_LARGE"
+	CodeInternal         = "INTERNAL"
+)
+
+// FieldError describes one invalid field of a request
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// AppError is an error with an HTTP status and a stable code. Data is sent
+// in the response's data field, e.g. the plan issues that blocked a save.
+type AppError struct {
+	Status  int
+	Code    string
+	Message string
+	Fields  []FieldError
+	Data    interface{}
+	Err     error
+}
+
+func (e *AppError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *AppError) Unwrap() error {
+	return e.Err
+}
+
+// NotFound returns a NOT_FOUND error such as "Subject not found"
+func NotFound(message string) *AppError {
+	return &AppError{Status: http.StatusNotFound, Code: CodeNotFound, Message: message}
+}
+
+// Conflict returns a CONFLICT error
+func Conflict(message string) *AppError {
+	return &AppError{Status: http.StatusConflict, Code: CodeConflict, Message: message}
+}
+
+// Invalid returns a VALIDATION_FAILED error listing the bad fields
+func Invalid(fields ...FieldError) *AppError {
+	return &AppError{
+		Status:  http.StatusBadRequest,
+		Code:    CodeValidationFailed,
+		Message: "Validation failed",
+		Fields:  fields,
+	}
+}
+
+// InvalidRequest returns a VALIDATION_FAILED error about the request as a
+// whole rather than one field
+func InvalidRequest(message string) *AppError {
+	return &AppError{Status: http.StatusBadRequest, Code: CodeValidationFailed, Message: message}
+}
+
+// Fail sends the error response for err. Application, binding and Postgres
+// errors become 4xx responses with a code; anything else is logged, marked
+// on the request's span and reported as a bare 500 so SQL details never reach
+// the client. The request ID lets the client's report be matched to the log.
+func Fail(c *gin.Context, err error) {
+	appErr := AsAppError(err)
+	if appErr.Status >= http.StatusInternalServerError {
+		ctx := c.Request.Context()
+		slog.ErrorContext(ctx, "Request failed", "method", c.Request.Method, "path", c.Request.URL.Path, "error", err)
+		telemetry.SetError(ctx, err)
+	}
+	c.JSON(appErr.Status, Response{
+		Success:   false,
+		Error:     appErr.Message,
+		Code:      appErr.Code,
+		Details:   appErr.Fields,
+		Data:      appErr.Data,
+		RequestID: telemetry.RequestID(c.Request.Context()),
+	})
+}
+
+// AsAppError classifies err as an AppError
+func AsAppError(err error) *AppError {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return NotFound("Not found")
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return fromPostgres(pqErr)
+	}
+
+	var fields validator.ValidationErrors
+	if errors.As(err, &fields) {
+		return fromValidator(fields)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return Invalid(FieldError{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Message: "must be " + jsonType(typeErr.Type),
+		})
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return &AppError{Status: http.StatusRequestEntityTooLarge, Code: CodeTooLarge, Message: fmt.Sprintf("Request body must be at most %d bytes", tooLarge.Limit), Err: err}
+	}
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return &AppError{Status: http.StatusBadRequest, Code: CodeBadRequest, Message: "Request body is not valid JSON", Err: err}
+	}
+
+	return &AppError{Status: http.StatusInternalServerError, Code: CodeInternal, Message: "Internal server error", Err: err}
+}
+
+// pqKeyColumn pulls the column out of a constraint detail such as
+// `Key (subject_id)=(99) is not present in table "subjects".`
+var pqKeyColumn = regexp.MustCompile(`^Key \(([^)]+)\)=`)
+
+// fromPostgres maps the Postgres error classes a request can cause to 4xx
+// responses; server-side failures stay 500
+func fromPostgres(err *pq.Error) *AppError {
+	field := err.Column
+	if m := pqKeyColumn.FindStringSubmatch(err.Detail); m != nil {
+		field = m[1]
+	}
+
+	switch err.Code.Name() {
+	case "foreign_key_violation":
+		// An insert or update pointing at a missing row is the client's
+		// mistake; a delete blocked by references is a conflict
+		if field == "" || strings.Contains(err.Detail, "is still referenced") {
+			return &AppError{Status: http.StatusConflict, Code: CodeFKViolation, Message: "Record is still referenced", Err: err}
+		}
+		return &AppError{
+			Status:  http.StatusUnprocessableEntity,
+			Code:    CodeFKViolation,
+			Message: field + " refers to a record that does not exist",
+			Fields:  []FieldError{{Field: field, Rule: "exists", Message: "does not exist"}},
+			Err:     err,
+		}
+	case "unique_violation":
+		appErr := Conflict("Record already exists")
+		appErr.Err = err
+		if field != "" {
+			appErr.Fields = []FieldError{{Field: field, Rule: "unique", Message: "already exists"}}
+		}
+		return appErr
+	case "not_null_violation":
+		appErr := Invalid(FieldError{Field: field, Rule: "required", Message: "is required"})
+		appErr.Status = http.StatusUnprocessableEntity
+		appErr.Err = err
+		return appErr
+	case "check_violation":
+		appErr := Invalid(FieldError{Field: err.Constraint, Rule: "check", Message: "is out of range"})
+		appErr.Status = http.StatusUnprocessableEntity
+		appErr.Err = err
+		return appErr
+	case "invalid_text_representation", "invalid_datetime_format", "datetime_field_overflow",
+		"numeric_value_out_of_range", "string_data_right_truncation":
+		appErr := Invalid()
+		appErr.Message = "Invalid value (" + strings.ReplaceAll(err.Code.Name(), "_", " ") + ")"
+		appErr.Err = err
+		return appErr
+	}
+	return &AppError{Status: http.StatusInternalServerError, Code: CodeInternal, Message: "Internal server error", Err: err}
+}
+
+// fromValidator turns gin binding failures into field errors
+func fromValidator(errs validator.ValidationErrors) *AppError {
+	fields := make([]FieldError, len(errs))
+	for i, fe := range errs {
+		fields[i] = FieldError{Field: fe.Field(), Rule: fe.Tag(), Message: ruleMessage(fe)}
+	}
+	return Invalid(fields...)
+}
+
+// ruleMessage describes a failed binding rule in plain words
+func ruleMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min", "gte":
+		if fe.Kind() == reflect.String {
+			return "must have at least " + fe.Param() + " characters"
+		}
+		if fe.Kind() == reflect.Slice {
+			return "must have at least " + fe.Param() + " items"
+		}
+		return "must be at least " + fe.Param()
+	case "max", "lte":
+		if fe.Kind() == reflect.String {
+			return "must have at most " + fe.Param() + " characters"
+		}
+		if fe.Kind() == reflect.Slice {
+			return "must have at most " + fe.Param() + " items"
+		}
+		return "must be at most " + fe.Param()
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "lt":
+		return "must be less than " + fe.Param()
+	case "oneof":
+		return "must be one of " + fe.Param()
+	case "url":
+		return "must be a URL"
+	case "email":
+		return "must be an email address"
+	case "color":
+		return "must be a hex color such as #3498db"
+	case "date", "date_or_empty":
+		return "must be a date between " + MinDate + " and " + MaxDate + " in YYYY-MM-DD form"
+	case "clock":
+		return "must be a time in HH:MM form"
+	}
+	return "failed the " + fe.Tag() + " rule"
+}
+
+// jsonType names a Go type the way a JSON client would see it
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	}
+	return "an object"
+}
+
+// codeForStatus picks the code for responses built from a bare status
+func codeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return CodeValidationFailed
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	}
+	if status >= http.StatusInternalServerError {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"os"
+	"time"
+)
+
+// DefaultExamDate is used when EXAM_DATE is not set or cannot be parsed
+const DefaultExamDate = "2026-02-11"
+
+// ExamDate returns the exam date configured in EXAM_DATE along with the
+// string it was parsed from
+func ExamDate() (time.Time, string) {
+	examDateStr := os.Getenv("EXAM_DATE")
+	if examDateStr == "" {
+		examDateStr = DefaultExamDate
+	}
+
+	examDate, err := time.Parse("2006-01-02", examDateStr)
+	if err != nil {
+		examDate = time.Date(2026, 2, 11, 0, 0, 0, 0, time.UTC)
+	}
+	return examDate, examDateStr
+}
+
+// DaysUntilExam returns the number of calendar days from now's date, in
+// now's location, to the exam date. It is 1 the day before the exam and 0
+// on the day itself, and never negative.
+func DaysUntilExam(examDate, now time.Time) int {
+	days := DaysBetween(now, examDate)
+	if days < 0 {
+		days = 0
+	}
+	return days
+}
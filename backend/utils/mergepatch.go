@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// MergePatchType is the media type of an RFC 7396 JSON merge patch
+const MergePatchType = "application/merge-patch+json"
+
+// MergePatch applies an RFC 7396 merge patch to a JSON document. Members of
+// the patch replace those of the target, null members remove them, and
+// nested objects are merged recursively.
+func MergePatch(target, patch []byte) ([]byte, error) {
+	var t, p interface{}
+	if len(bytes.TrimSpace(target)) > 0 {
+		if err := json.Unmarshal(target, &t); err != nil {
+			return nil, err
+		}
+	}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, err
+	}
+	return json.Marshal(mergeValue(t, p))
+}
+
+func mergeValue(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+	for key, value := range p {
+		if value == nil {
+			delete(t, key)
+		} else {
+			t[key] = mergeValue(t[key], value)
+		}
+	}
+	return t
+}
+
+// ReadMergePatch reads a merge patch from the request body. The body must be
+// a non-empty JSON object sent as application/merge-patch+json or plain JSON.
+func ReadMergePatch(c *gin.Context) ([]byte, error) {
+	if contentType := c.GetHeader("Content-Type"); contentType != "" {
+		mediaType, _, _ := mime.ParseMediaType(contentType)
+		if mediaType != MergePatchType && mediaType != binding.MIMEJSON {
+			return nil, &AppError{
+				Status:  http.StatusUnsupportedMediaType,
+				Code:    CodeBadRequest,
+				Message: "Content-Type must be " + MergePatchType,
+			}
+		}
+	}
+
+	patch, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, err
+	}
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &members); err != nil || members == nil {
+		return nil, InvalidRequest("Merge patch must be a JSON object")
+	}
+	if len(members) == 0 {
+		return nil, InvalidRequest("Nothing to update")
+	}
+	return patch, nil
+}
+
+// ApplyMergePatch applies a merge patch to current and decodes the result
+// back into it. current should be a pointer to a struct of the resource's
+// patchable fields, loaded from the database, with pointer types for the
+// fields that may be null. Absent members keep their value, null members
+// become nil or zero, and the result is validated with the struct's binding
+// tags. Members that current does not have are rejected.
+func ApplyMergePatch(current interface{}, patch []byte) error {
+	original, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+	merged, err := MergePatch(original, patch)
+	if err != nil {
+		return InvalidRequest("Merge patch must be a JSON object")
+	}
+
+	// Start from zero values so removed members really are cleared
+	zeroValue(current)
+	decoder := json.NewDecoder(bytes.NewReader(merged))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(current); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return err
+		}
+		return InvalidRequest("Merge patch cannot be applied: " + strings.TrimPrefix(err.Error(), "json: "))
+	}
+	return binding.Validator.ValidateStruct(current)
+}
+
+// zeroValue resets the struct v points to
+func zeroValue(v interface{}) {
+	target := reflect.ValueOf(v).Elem()
+	target.Set(reflect.Zero(target.Type()))
+}
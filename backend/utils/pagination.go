@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Page sizes for paged lists
+const (
+	DefaultPerPage = 50
+	MaxPerPage     = 200
+)
+
+// Pagination describes one page of a list
+type Pagination struct {
+	Page       int `json:"page"`
+	PerPage    int `json:"per_page"`
+	Total      int `json:"total"`
+	TotalPages int `json:"total_pages"`
+}
+
+// PageParams reads ?page= (from 1) and ?per_page= (1 to MaxPerPage),
+// defaulting to the first page of DefaultPerPage items
+func PageParams(c *gin.Context) (page, perPage int, err error) {
+	page, perPage = 1, DefaultPerPage
+	var fields []FieldError
+	if value := c.Query("page"); value != "" {
+		if page, err = strconv.Atoi(value); err != nil || page < 1 {
+			fields = append(fields, FieldError{Field: "page", Rule: "min", Message: "page must be a whole number from 1"})
+		}
+	}
+	if value := c.Query("per_page"); value != "" {
+		if perPage, err = strconv.Atoi(value); err != nil || perPage < 1 || perPage > MaxPerPage {
+			fields = append(fields, FieldError{
+				Field:   "per_page",
+				Rule:    "range",
+				Message: "per_page must be a whole number from 1 to " + strconv.Itoa(MaxPerPage),
+			})
+		}
+	}
+	if len(fields) > 0 {
+		return 0, 0, Invalid(fields...)
+	}
+	return page, perPage, nil
+}
+
+// NewPagination describes page of a list of total items
+func NewPagination(page, perPage, total int) Pagination {
+	return Pagination{Page: page, PerPage: perPage, Total: total, TotalPages: (total + perPage - 1) / perPage}
+}
+
+// Offset is the number of items before the page
+func (p Pagination) Offset() int {
+	return (p.Page - 1) * p.PerPage
+}
+
+// PageResponse sends one page of a list with its pagination
+func PageResponse(c *gin.Context, message string, data interface{}, pagination Pagination) {
+	c.JSON(http.StatusOK, Response{
+		Success:    true,
+		Message:    message,
+		Data:       data,
+		Pagination: &pagination,
+	})
+}
@@ -0,0 +1,12 @@
+package utils
+
+import "github.com/gin-gonic/gin"
+
+// Actor returns the name of the user making the request, taken from the
+// X-User header. Requests without it are attributed to "anonymous".
+func Actor(c *gin.Context) string {
+	if user := c.GetHeader("X-User"); user != "" {
+		return user
+	}
+	return "anonymous"
+}
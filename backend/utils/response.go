@@ -1,13 +1,23 @@
 package utils
 
-import "github.com/gin-gonic/gin"
+import (
+	"exam-prep/telemetry"
+
+	"github.com/gin-gonic/gin"
+)
 
 // Response is a standard API response structure
 type Response struct {
-	Success bool        `json:"success"`
-	Message string      `json:"message,omitempty"`
-	Data    interface{} `json:"data,omitempty"`
-	Error   string      `json:"error,omitempty"`
+	Success bool         `json:"success"`
+	Message string       `json:"message,omitempty"`
+	Data    interface{}  `json:"data,omitempty"`
+	Error   string       `json:"error,omitempty"`
+	Code    string       `json:"code,omitempty"`
+	Details []FieldError `json:"details,omitempty"`
+	// Pagination describes the page of a list in data, for paged lists
+	Pagination *Pagination `json:"pagination,omitempty"`
+	// RequestID identifies the request in the server's logs, on errors
+	RequestID string `json:"request_id,omitempty"`
 }
 
 // SuccessResponse sends a success response
@@ -19,10 +29,23 @@ func SuccessResponse(c *gin.Context, statusCode int, message string, data interf
 	})
 }
 
-// ErrorResponse sends an error response
+// ErrorResponse sends an error response with the code for its status
 func ErrorResponse(c *gin.Context, statusCode int, message string) {
 	c.JSON(statusCode, Response{
-		Success: false,
-		Error:   message,
+		Success:   false,
+		Error:     message,
+		Code:      codeForStatus(statusCode),
+		RequestID: telemetry.RequestID(c.Request.Context()),
+	})
+}
+
+// ErrorResponseWithData sends an error response with details in the data field
+func ErrorResponseWithData(c *gin.Context, statusCode int, message string, data interface{}) {
+	c.JSON(statusCode, Response{
+		Success:   false,
+		Error:     message,
+		Code:      codeForStatus(statusCode),
+		Data:      data,
+		RequestID: telemetry.RequestID(c.Request.Context()),
 	})
 }
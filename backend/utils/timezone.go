@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LocationKey is the gin context key holding the request's *time.Location
+const LocationKey = "location"
+
+// DefaultLocation returns the timezone from APP_TIMEZONE, or the server's
+// local zone when it is unset or invalid
+func DefaultLocation() *time.Location {
+	if name := os.Getenv("APP_TIMEZONE"); name != "" {
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc
+		}
+	}
+	return time.Local
+}
+
+// RequestLocation picks the timezone named by the X-Timezone header or the tz
+// query parameter (IANA names such as "Africa/Mogadishu"). It reports false
+// when the request does not name a valid zone.
+func RequestLocation(c *gin.Context) (*time.Location, bool) {
+	for _, name := range []string{c.GetHeader("X-Timezone"), c.Query("tz")} {
+		if name == "" {
+			continue
+		}
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc, true
+		}
+	}
+	return nil, false
+}
+
+// Location returns the timezone a request's dates should be computed in: the
+// one resolved by the timezone middleware, otherwise the request's own
+// header or query parameter, otherwise DefaultLocation
+func Location(c *gin.Context) *time.Location {
+	if value, ok := c.Get(LocationKey); ok {
+		if loc, ok := value.(*time.Location); ok {
+			return loc
+		}
+	}
+	if loc, ok := RequestLocation(c); ok {
+		return loc
+	}
+	return DefaultLocation()
+}
+
+// Now returns the current time in the request's timezone
+func Now(c *gin.Context) time.Time {
+	return time.Now().In(Location(c))
+}
+
+// Today returns the current calendar date in the request's timezone as YYYY-MM-DD
+func Today(c *gin.Context) string {
+	return Now(c).Format("2006-01-02")
+}
+
+// CalendarDate returns the calendar day of t, in t's own location, as
+// midnight UTC. Differences between calendar dates are then always whole
+// multiples of 24 hours, regardless of daylight saving changes.
+func CalendarDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// DaysBetween returns the number of calendar days from a to b
+func DaysBetween(a, b time.Time) int {
+	return int(CalendarDate(b).Sub(CalendarDate(a)).Hours() / 24)
+}
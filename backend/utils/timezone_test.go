@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("load %s: %v", name, err)
+	}
+	return loc
+}
+
+func TestCalendarDate(t *testing.T) {
+	newYork := mustLoad(t, "America/New_York")
+	london := mustLoad(t, "Europe/London")
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want string
+	}{
+		{"before spring forward", time.Date(2026, 3, 8, 1, 30, 0, 0, newYork), "2026-03-08"},
+		{"after spring forward, next day in UTC", time.Date(2026, 3, 8, 23, 30, 0, 0, newYork), "2026-03-08"},
+		{"repeated hour at fall back", time.Date(2026, 11, 1, 1, 30, 0, 0, newYork), "2026-11-01"},
+		{"after fall back, next day in UTC", time.Date(2026, 11, 1, 23, 30, 0, 0, newYork), "2026-11-01"},
+		{"London before BST", time.Date(2026, 3, 29, 0, 30, 0, 0, london), "2026-03-29"},
+		{"London in BST, previous day in UTC", time.Date(2026, 3, 30, 0, 30, 0, 0, london), "2026-03-30"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CalendarDate(tt.t)
+			if got.Format("2006-01-02") != tt.want || got.Location() != time.UTC || got.Hour() != 0 {
+				t.Errorf("CalendarDate(%v) = %v, want %s at midnight UTC", tt.t, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDaysBetween(t *testing.T) {
+	newYork := mustLoad(t, "America/New_York")
+	london := mustLoad(t, "Europe/London")
+
+	tests := []struct {
+		name string
+		a, b time.Time
+		want int
+	}{
+		{"23 hour day at spring forward", time.Date(2026, 3, 8, 0, 0, 0, 0, newYork), time.Date(2026, 3, 9, 0, 0, 0, 0, newYork), 1},
+		{"less than 24 hours across spring forward", time.Date(2026, 3, 7, 23, 0, 0, 0, newYork), time.Date(2026, 3, 8, 23, 0, 0, 0, newYork), 1},
+		{"25 hour day at fall back", time.Date(2026, 11, 1, 0, 0, 0, 0, newYork), time.Date(2026, 11, 2, 0, 0, 0, 0, newYork), 1},
+		{"nearly two days of hours across fall back", time.Date(2026, 10, 31, 0, 30, 0, 0, newYork), time.Date(2026, 11, 1, 23, 30, 0, 0, newYork), 1},
+		{"same day across spring forward", time.Date(2026, 3, 8, 0, 5, 0, 0, newYork), time.Date(2026, 3, 8, 23, 55, 0, 0, newYork), 0},
+		{"two weeks across spring forward", time.Date(2026, 3, 1, 12, 0, 0, 0, newYork), time.Date(2026, 3, 15, 12, 0, 0, 0, newYork), 14},
+		{"backwards across fall back", time.Date(2026, 11, 2, 0, 0, 0, 0, newYork), time.Date(2026, 11, 1, 0, 0, 0, 0, newYork), -1},
+		{"London 23 hour day", time.Date(2026, 3, 28, 12, 0, 0, 0, london), time.Date(2026, 3, 29, 12, 0, 0, 0, london), 1},
+		{"London 25 hour day", time.Date(2026, 10, 25, 0, 0, 0, 0, london), time.Date(2026, 10, 26, 0, 0, 0, 0, london), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DaysBetween(tt.a, tt.b); got != tt.want {
+				t.Errorf("DaysBetween(%v, %v) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDaysUntilExam(t *testing.T) {
+	newYork := mustLoad(t, "America/New_York")
+	london := mustLoad(t, "Europe/London")
+	exam := func(date string) time.Time {
+		d, _ := time.Parse("2006-01-02", date)
+		return d
+	}
+
+	tests := []struct {
+		name string
+		exam time.Time
+		now  time.Time
+		want int
+	}{
+		{"evening before, already the exam day in UTC", exam("2026-03-09"), time.Date(2026, 3, 8, 22, 0, 0, 0, newYork), 1},
+		{"day of spring forward", exam("2026-03-08"), time.Date(2026, 3, 8, 9, 0, 0, 0, newYork), 0},
+		{"two weeks across spring forward", exam("2026-03-15"), time.Date(2026, 3, 1, 0, 30, 0, 0, newYork), 14},
+		{"night of fall back", exam("2026-11-02"), time.Date(2026, 11, 1, 23, 30, 0, 0, newYork), 1},
+		{"week across fall back", exam("2026-11-05"), time.Date(2026, 10, 29, 8, 0, 0, 0, newYork), 7},
+		{"exam passed", exam("2026-11-01"), time.Date(2026, 11, 3, 8, 0, 0, 0, newYork), 0},
+		{"London late evening in BST", exam("2026-03-30"), time.Date(2026, 3, 29, 23, 30, 0, 0, london), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DaysUntilExam(tt.exam, tt.now); got != tt.want {
+				t.Errorf("DaysUntilExam(%s, %v) = %d, want %d", tt.exam.Format("2006-01-02"), tt.now, got, tt.want)
+			}
+		})
+	}
+}
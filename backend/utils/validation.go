@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// Bounds for dates accepted by the date rule
+const (
+	MinDate = "2000-01-01"
+	MaxDate = "2100-12-31"
+)
+
+var (
+	hexColor  = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+	clockTime = regexp.MustCompile(`^(?:[01]\d|2[0-3]):[0-5]\d(?::[0-5]\d)?$`)
+)
+
+// SetupValidator registers the custom binding rules used by the input models
+// and makes binding errors name fields by their JSON key (subject_id)
+// instead of the Go field (SubjectID):
+//
+//	color          a #rgb or #rrggbb hex color, fitting subjects.color VARCHAR(7)
+//	date           a YYYY-MM-DD date between MinDate and MaxDate
+//	date_or_empty  a date as above, or empty to clear it
+//	clock          an HH:MM or HH:MM:SS wall-clock time, or empty to clear a slot
+func SetupValidator() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return f.Name
+		}
+		return name
+	})
+	v.RegisterValidation("color", func(fl validator.FieldLevel) bool {
+		return hexColor.MatchString(fl.Field().String())
+	})
+	v.RegisterValidation("date", func(fl validator.FieldLevel) bool {
+		return ValidDate(fl.Field().String())
+	})
+	v.RegisterValidation("date_or_empty", func(fl validator.FieldLevel) bool {
+		value := fl.Field().String()
+		return value == "" || ValidDate(value)
+	})
+	v.RegisterValidation("clock", func(fl validator.FieldLevel) bool {
+		value := fl.Field().String()
+		return value == "" || clockTime.MatchString(value)
+	})
+}
+
+// ValidDate reports whether value is a YYYY-MM-DD date within the accepted range
+func ValidDate(value string) bool {
+	if _, err := time.Parse("2006-01-02", value); err != nil {
+		return false
+	}
+	return value >= MinDate && value <= MaxDate
+}
@@ -0,0 +1,291 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"exam-prep/database"
+	"exam-prep/events"
+	"exam-prep/models"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// MaxAttempts is how many times a delivery is tried before it is marked failed
+	MaxAttempts = 8
+	// baseBackoff is the wait after the first failed attempt; it doubles each retry
+	baseBackoff = 30 * time.Second
+	// maxBackoff caps the wait between retries
+	maxBackoff = 6 * time.Hour
+	// claimLease keeps a claimed delivery from being picked up again while in flight
+	claimLease = "5 minutes"
+)
+
+// Delivery statuses
+const (
+	StatusPending = "pending"
+	StatusSuccess = "success"
+	StatusFailed  = "failed"
+)
+
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// Sign returns the hex encoded HMAC-SHA256 of the body using the webhook secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewSecret generates a random signing secret
+func NewSecret() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// Backoff returns how long to wait before the next attempt after the given
+// number of failed attempts
+func Backoff(attempts int) time.Duration {
+	wait := baseBackoff
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return wait
+}
+
+// queued wakes the delivery worker when deliveries are queued
+var queued = make(chan struct{}, 1)
+
+// Queued returns a channel that receives when new deliveries have been
+// queued, so a worker can send them without waiting for its next poll
+func Queued() <-chan struct{} {
+	return queued
+}
+
+// Enqueue queues a delivery of the event for every active webhook subscribed
+// to its type. A webhook with no event types receives everything.
+func Enqueue(ctx context.Context, e events.Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	result, err := database.DB.ExecContext(ctx, `
+		INSERT INTO webhook_deliveries (webhook_id, event_type, payload)
+		SELECT id, $1, $2 FROM webhooks
+		WHERE active AND (cardinality(event_types) = 0 OR $1 = ANY(event_types))
+	`, e.Type, payload)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n > 0 {
+		select {
+		case queued <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// EnqueueTest queues a webhook.test event for a single webhook and returns
+// the delivery ID. The delivery is queued already claimed, as DeliverDue
+// claims deliveries, so the caller can Deliver it without the worker
+// sending it too.
+func EnqueueTest(ctx context.Context, webhookID int) (int, error) {
+	payload, err := json.Marshal(events.Event{
+		Type:     "webhook.test",
+		Actor:    "system",
+		EntityID: webhookID,
+		Time:     time.Now(),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var id int
+	err = database.DB.QueryRowContext(ctx,
+		"INSERT INTO webhook_deliveries (webhook_id, event_type, payload, next_attempt_at) VALUES ($1, 'webhook.test', $2, CURRENT_TIMESTAMP + INTERVAL '"+claimLease+"') RETURNING id",
+		webhookID, payload,
+	).Scan(&id)
+	return id, err
+}
+
+// DeliverDue claims pending deliveries whose next attempt is due and tries
+// each of them once. It returns how many deliveries were attempted. A
+// delivery that cannot be recorded is logged and the rest are still tried,
+// so none is left claimed until its lease runs out.
+func DeliverDue(ctx context.Context) (int, error) {
+	rows, err := database.DB.QueryContext(ctx, `
+		UPDATE webhook_deliveries SET next_attempt_at = CURRENT_TIMESTAMP + INTERVAL '`+claimLease+`'
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= CURRENT_TIMESTAMP
+			ORDER BY next_attempt_at
+			LIMIT 20
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id
+	`)
+	if err != nil {
+		return 0, err
+	}
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	for _, id := range ids {
+		if _, err := Deliver(ctx, id); err != nil {
+			slog.Warn("Failed to deliver webhook", "delivery_id", id, "error", err)
+		}
+	}
+	return len(ids), nil
+}
+
+// Deliver makes one attempt at sending a delivery and records the outcome.
+// Failed attempts are rescheduled with exponential backoff until MaxAttempts.
+func Deliver(ctx context.Context, id int) (*models.WebhookDelivery, error) {
+	var url, secret, eventType string
+	var payload []byte
+	var attempts int
+	err := database.DB.QueryRowContext(ctx, `
+		SELECT w.url, w.secret, d.event_type, d.payload, d.attempts
+		FROM webhook_deliveries d
+		JOIN webhooks w ON d.webhook_id = w.id
+		WHERE d.id = $1
+	`, id).Scan(&url, &secret, &eventType, &payload, &attempts)
+	if err != nil {
+		return nil, err
+	}
+
+	statusCode, sendErr := send(ctx, url, secret, id, eventType, payload)
+	attempts++
+
+	var code interface{}
+	if statusCode != 0 {
+		code = statusCode
+	}
+
+	if sendErr == nil {
+		_, err = database.DB.ExecContext(ctx, `
+			UPDATE webhook_deliveries
+			SET status = 'success', attempts = $1, last_status_code = $2, last_error = NULL,
+				delivered_at = CURRENT_TIMESTAMP, next_attempt_at = NULL
+			WHERE id = $3
+		`, attempts, code, id)
+	} else if attempts >= MaxAttempts {
+		_, err = database.DB.ExecContext(ctx, `
+			UPDATE webhook_deliveries
+			SET status = 'failed', attempts = $1, last_status_code = $2, last_error = $3, next_attempt_at = NULL
+			WHERE id = $4
+		`, attempts, code, sendErr.Error(), id)
+	} else {
+		_, err = database.DB.ExecContext(ctx, `
+			UPDATE webhook_deliveries
+			SET attempts = $1, last_status_code = $2, last_error = $3,
+				next_attempt_at = CURRENT_TIMESTAMP + $4 * INTERVAL '1 second'
+			WHERE id = $5
+		`, attempts, code, sendErr.Error(), int64(Backoff(attempts).Seconds()), id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return GetDelivery(ctx, id)
+}
+
+// send posts a signed payload and returns the response status code
+func send(ctx context.Context, url, secret string, deliveryID int, eventType string, payload []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "exam-prep-webhooks/1.0")
+	req.Header.Set("X-Webhook-Event", eventType)
+	req.Header.Set("X-Webhook-Delivery", strconv.Itoa(deliveryID))
+	req.Header.Set("X-Webhook-Signature", "sha256="+Sign(secret, payload))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// deliveryColumns is the column list scanned by scanDelivery
+const deliveryColumns = `id, webhook_id, event_type, payload, status, attempts, last_status_code,
+	COALESCE(last_error, ''), next_attempt_at, delivered_at, created_at`
+
+// scanner is implemented by *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanDelivery scans a row selected with deliveryColumns
+func scanDelivery(row scanner) (*models.WebhookDelivery, error) {
+	var d models.WebhookDelivery
+	var payload []byte
+	err := row.Scan(&d.ID, &d.WebhookID, &d.EventType, &payload, &d.Status, &d.Attempts,
+		&d.LastStatusCode, &d.LastError, &d.NextAttemptAt, &d.DeliveredAt, &d.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	d.Payload = payload
+	return &d, nil
+}
+
+// GetDelivery loads a single delivery
+func GetDelivery(ctx context.Context, id int) (*models.WebhookDelivery, error) {
+	return scanDelivery(database.DB.QueryRowContext(ctx, "SELECT "+deliveryColumns+" FROM webhook_deliveries WHERE id = $1", id))
+}
+
+// ListDeliveries returns the most recent deliveries for a webhook
+func ListDeliveries(ctx context.Context, webhookID, limit int) ([]models.WebhookDelivery, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT "+deliveryColumns+" FROM webhook_deliveries WHERE webhook_id = $1 ORDER BY created_at DESC, id DESC LIMIT $2",
+		webhookID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []models.WebhookDelivery
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, *d)
+	}
+	return deliveries, rows.Err()
+}
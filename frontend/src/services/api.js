@@ -10,6 +10,7 @@ if (!apiUrl.endsWith('/api')) {
   apiUrl += '/api';
 }
 const API_BASE_URL = apiUrl;
+const mergePatch = { headers: { 'Content-Type': 'application/merge-patch+json' } };
 
 const api = axios.create({
   baseURL: API_BASE_URL,
@@ -21,20 +22,31 @@ const api = axios.create({
 // Dashboard
 export const getDashboard = () => api.get('/dashboard');
 export const getProgress = () => api.get('/progress');
+export const getTimeAnalytics = (params) => api.get('/analytics/time', { params });
+export const getWeeklyReportUrl = (format = 'html') => `${API_BASE_URL}/reports/weekly?format=${format}`;
 
 // Subjects
 export const getSubjects = () => api.get('/subjects');
 export const getSubject = (id) => api.get(`/subjects/${id}`);
 export const createSubject = (data) => api.post('/subjects', data);
 export const updateSubject = (id, data) => api.put(`/subjects/${id}`, data);
+export const patchSubject = (id, patch) => api.patch(`/subjects/${id}`, patch, mergePatch);
 export const deleteSubject = (id) => api.delete(`/subjects/${id}`);
 
 // Topics
 export const getTopicsBySubject = (subjectId) => api.get(`/subjects/${subjectId}/topics`);
 export const createTopic = (data) => api.post('/topics', data);
 export const updateTopic = (id, data) => api.put(`/topics/${id}`, data);
+export const patchTopic = (id, patch) => api.patch(`/topics/${id}`, patch, mergePatch);
 export const toggleTopicComplete = (id) => api.put(`/topics/${id}/complete`);
 export const toggleTopicWeak = (id) => api.put(`/topics/${id}/weak`);
+export const reviewTopic = (id, data) => api.post(`/topics/${id}/reviews`, data);
+export const getTopicHistory = (id) => api.get(`/topics/${id}/history`);
+export const getWeakestTopics = (params) => api.get('/topics/weakest', { params });
+export const getTopicDependencies = (id) => api.get(`/topics/${id}/dependencies`);
+export const addTopicDependency = (id, prerequisiteId) => api.post(`/topics/${id}/dependencies`, { prerequisite_id: prerequisiteId });
+export const removeTopicDependency = (id, prerequisiteId) => api.delete(`/topics/${id}/dependencies/${prerequisiteId}`);
+export const getTopicOrder = (subjectId) => api.get(`/subjects/${subjectId}/topics/order`);
 export const deleteTopic = (id) => api.delete(`/topics/${id}`);
 
 // Notes
@@ -42,6 +54,7 @@ export const getNotes = () => api.get('/notes');
 export const getNotesBySubject = (subjectId) => api.get(`/subjects/${subjectId}/notes`);
 export const createNote = (data) => api.post('/notes', data);
 export const updateNote = (id, data) => api.put(`/notes/${id}`, data);
+export const patchNote = (id, patch) => api.patch(`/notes/${id}`, patch, mergePatch);
 export const deleteNote = (id) => api.delete(`/notes/${id}`);
 
 // Study Plan
@@ -49,6 +62,42 @@ export const getStudyPlans = () => api.get('/study-plan');
 export const getTodayStudyPlan = () => api.get('/study-plan/today');
 export const createStudyPlan = (data) => api.post('/study-plan', data);
 export const updateStudyPlan = (id, data) => api.put(`/study-plan/${id}`, data);
+export const patchStudyPlan = (id, patch) => api.patch(`/study-plan/${id}`, patch, mergePatch);
 export const deleteStudyPlan = (id) => api.delete(`/study-plan/${id}`);
+export const generateStudyPlan = (data) => api.post('/study-plan/generate', data);
+
+// Batch: [{ ref, method: 'create' | 'update' | 'delete', resource, id, body }]
+export const runBatch = (operations) => api.post('/batch', { operations });
+
+// GraphQL: the response body is { data, errors } rather than the usual envelope
+export const graphql = (query, variables = {}) => api.post('/graphql', { query, variables });
+
+// Version 2: writes respond with the resource, and lists take { page, per_page }
+// plus filters and respond with pagination next to data
+const v2 = (path) => ({
+  list: (params) => api.get(`/v2${path}`, { params }),
+  get: (id) => api.get(`/v2${path}/${id}`),
+  create: (data) => api.post(`/v2${path}`, data),
+  patch: (id, patch) => api.patch(`/v2${path}/${id}`, patch, mergePatch),
+  remove: (id) => api.delete(`/v2${path}/${id}`),
+});
+export const v2Subjects = v2('/subjects');
+export const v2Topics = v2('/topics');
+export const v2Notes = v2('/notes');
+export const v2StudyPlan = v2('/study-plan');
+
+// Trash
+export const getTrash = () => api.get('/trash');
+export const restoreSubject = (id) => api.put(`/subjects/${id}/restore`);
+export const restoreTopic = (id) => api.put(`/topics/${id}/restore`);
+export const restoreNote = (id) => api.put(`/notes/${id}/restore`);
+export const restoreStudyPlan = (id) => api.put(`/study-plan/${id}/restore`);
+
+// Activity
+export const getActivity = (params) => api.get('/activity', { params });
+
+// Real-time events
+export const subscribeEvents = (params = {}) =>
+  new EventSource(`${API_BASE_URL}/events?${new URLSearchParams(params)}`);
 
 export default api;